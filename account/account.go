@@ -33,7 +33,7 @@ func NewAccount(cfg *config.Config) (*Account, error) {
 	var acc Account
 	acc.Password = tool.GenerateRandomString(8)
 
-	ks := keystore.NewKeyStore(config.KeyStoreDirETH(), keystore.StandardScryptN, keystore.StandardScryptP)
+	ks := keystore.NewKeyStore(config.KeyStoreDir(cfg), keystore.StandardScryptN, keystore.StandardScryptP)
 	account, err := ks.NewAccount(acc.Password)
 	if err != nil {
 		return nil, err
@@ -71,19 +71,22 @@ func saveAccountToConfig(cfg *config.Config, account *Account) error {
 	}
 	acc := base64.StdEncoding.EncodeToString(bytes)
 	cfg.Account = acc
-	return config.SaveConfig(cfg)
+	return config.SaveDir(cfg.Dir(), cfg)
 }
 
-// Check ...
-func (acc *Account) Check() error {
-	path := filepath.Join(config.KeyStoreDirETH(), acc.Address)
+// Check writes the keystore file of the account when the keystore of the config misses it
+func (acc *Account) Check(cfg *config.Config) error {
+	path := filepath.Join(config.KeyStoreDir(cfg), acc.Address)
 	_, e := os.Stat(path)
 	if e != nil && os.IsNotExist(e) {
+		if e := os.MkdirAll(filepath.Dir(path), 0700); e != nil {
+			return e
+		}
 		bytes, e := json.Marshal(acc.KeyStore)
 		if e != nil {
 			return e
 		}
-		e = ioutil.WriteFile(path, bytes, 0755)
+		e = ioutil.WriteFile(path, bytes, 0600)
 		if e != nil {
 			return e
 		}
@@ -94,7 +97,7 @@ func (acc *Account) Check() error {
 
 // Save ...
 func (acc *Account) Save(cfg *config.Config) error {
-	if err := acc.Check(); err != nil {
+	if err := acc.Check(cfg); err != nil {
 		return err
 	}
 	return saveAccountToConfig(cfg, acc)
@@ -0,0 +1,185 @@
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glvd/accipfs/config"
+	"golang.org/x/crypto/scrypt"
+)
+
+// BackupVersion ...
+const BackupVersion = 1
+
+// ErrPassphrase ...
+var ErrPassphrase = errors.New("wrong passphrase or damaged backup")
+
+// scrypt cost of backups and imported keys
+var (
+	scryptN = keystore.StandardScryptN
+	scryptP = keystore.StandardScryptP
+)
+
+const scryptR = 8
+
+// BackupScrypt ...
+type BackupScrypt struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// Backup is the account and config of a node encrypted with a passphrase
+type Backup struct {
+	Version    int          `json:"version"`
+	Address    string       `json:"address"`
+	Scrypt     BackupScrypt `json:"scrypt"`
+	Nonce      string       `json:"nonce"`
+	CipherText string       `json:"ciphertext"`
+}
+
+type backupPayload struct {
+	Account *Account       `json:"account"`
+	Config  *config.Config `json:"config"`
+}
+
+func backupCipher(passphrase string, s BackupScrypt) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(s.Salt)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, s.N, s.R, s.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ExportBackup encrypts the account and the config with the passphrase
+func ExportBackup(cfg *config.Config, acc *Account, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrNoPassword
+	}
+	c := *cfg
+	// the account is kept beside the config
+	c.Account = ""
+	payload, err := json.Marshal(&backupPayload{Account: acc, Config: &c})
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	b := &Backup{
+		Version: BackupVersion,
+		Address: acc.ETHAddress().Hex(),
+		Scrypt:  BackupScrypt{N: scryptN, R: scryptR, P: scryptP, Salt: hex.EncodeToString(salt)},
+	}
+	aead, err := backupCipher(passphrase, b.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	b.Nonce = hex.EncodeToString(nonce)
+	b.CipherText = hex.EncodeToString(aead.Seal(nil, nonce, payload, []byte(b.Address)))
+	return json.MarshalIndent(b, "", " ")
+}
+
+// ImportBackup decrypts a backup, the config path is not restored
+func ImportBackup(data []byte, passphrase string) (*Account, *config.Config, error) {
+	var b Backup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, nil, err
+	}
+	if b.Version != BackupVersion {
+		return nil, nil, fmt.Errorf("unsupported backup version %d", b.Version)
+	}
+	aead, err := backupCipher(passphrase, b.Scrypt)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := hex.DecodeString(b.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, nil, ErrPassphrase
+	}
+	sealed, err := hex.DecodeString(b.CipherText)
+	if err != nil {
+		return nil, nil, ErrPassphrase
+	}
+	payload, err := aead.Open(nil, nonce, sealed, []byte(b.Address))
+	if err != nil {
+		return nil, nil, ErrPassphrase
+	}
+	var p backupPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, nil, err
+	}
+	if p.Account == nil || p.Config == nil {
+		return nil, nil, errors.New("backup has no account")
+	}
+	return p.Account, p.Config, nil
+}
+
+// IsBackup reports whether the data is an account backup
+func IsBackup(data []byte) bool {
+	var b Backup
+	return json.Unmarshal(data, &b) == nil && b.Version > 0 && b.CipherText != ""
+}
+
+func importedAccount(act *accounts.Account, password string) (*Account, error) {
+	acc := &Account{Password: password}
+	if err := acc.loadKey(act); err != nil {
+		return nil, err
+	}
+	acc.getName(act)
+	return acc, nil
+}
+
+// ImportKeyStore adds a geth keystore file, the password must unlock it and
+// stays the password of the account
+func ImportKeyStore(cfg *config.Config, keyJSON []byte, password string) (*Account, error) {
+	if _, err := keystore.DecryptKey(keyJSON, password); err != nil {
+		return nil, fmt.Errorf("unlock keystore: %w", err)
+	}
+	ks := keystore.NewKeyStore(config.KeyStoreDir(cfg), scryptN, scryptP)
+	act, err := ks.Import(keyJSON, password, password)
+	if err != nil {
+		return nil, err
+	}
+	return importedAccount(&act, password)
+}
+
+// ImportKey adds a raw hex private key, it is encrypted with the password
+func ImportKey(cfg *config.Config, hexKey string, password string) (*Account, error) {
+	if password == "" {
+		return nil, ErrNoPassword
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	ks := keystore.NewKeyStore(config.KeyStoreDir(cfg), scryptN, scryptP)
+	act, err := ks.ImportECDSA(key, password)
+	if err != nil {
+		return nil, err
+	}
+	return importedAccount(&act, password)
+}
@@ -0,0 +1,76 @@
+package account
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glvd/accipfs/config"
+)
+
+func TestBackup(t *testing.T) {
+	scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	dir, err := ioutil.TempDir("", "accipfs-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := config.Default()
+	cfg.Path = dir
+	cfg.Port = 20305
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := ImportKey(cfg, hex.EncodeToString(crypto.FromECDSA(key)), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.ETHAddress() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("imported %s", acc.ETHAddress().Hex())
+	}
+
+	data, err := ExportBackup(cfg, acc, "backup pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsBackup(data) {
+		t.Fatal("export is not a backup")
+	}
+	if _, _, err := ImportBackup(data, "wrong"); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("wrong passphrase: %v", err)
+	}
+	restored, restoredCfg, err := ImportBackup(data, "backup pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.KeyStore != acc.KeyStore || restoredCfg.Port != cfg.Port || restoredCfg.Account != "" {
+		t.Fatalf("restored %+v %+v", restored, restoredCfg)
+	}
+	if err := NewKeySigner(restored, PasswordString(restored.Password)).Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	keyJSON, err := json.Marshal(acc.KeyStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := *cfg
+	other.Path = dir + "/other"
+	if _, err := ImportKeyStore(&other, keyJSON, "wrong"); err == nil {
+		t.Fatal("imported keystore with a wrong password")
+	}
+	imported, err := ImportKeyStore(&other, keyJSON, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.ETHAddress() != acc.ETHAddress() {
+		t.Fatalf("imported %s", imported.ETHAddress().Hex())
+	}
+}
@@ -0,0 +1,108 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/glvd/accipfs/config"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ErrNoPassword ...
+var ErrNoPassword = errors.New("no password found")
+
+// PasswordSource ...
+type PasswordSource interface {
+	Password() (string, error)
+}
+
+// PasswordFile reads the password from the first line of a file
+type PasswordFile string
+
+// Password ...
+func (p PasswordFile) Password() (string, error) {
+	bytes, err := ioutil.ReadFile(string(p))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(strings.SplitN(string(bytes), "\n", 2)[0], "\r"), nil
+}
+
+// PasswordEnv reads the password from an environment variable
+type PasswordEnv string
+
+// Password ...
+func (p PasswordEnv) Password() (string, error) {
+	pass, b := os.LookupEnv(string(p))
+	if !b {
+		return "", fmt.Errorf("env %s: %w", string(p), ErrNoPassword)
+	}
+	return pass, nil
+}
+
+// PasswordPrompt asks for the password on the terminal
+type PasswordPrompt string
+
+// Password ...
+func (p PasswordPrompt) Password() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", fmt.Errorf("prompt: stdin is not a terminal: %w", ErrNoPassword)
+	}
+	fmt.Printf("Password for %s: ", string(p))
+	pass, err := terminal.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(pass), nil
+}
+
+// PasswordString is a password that is already known
+type PasswordString string
+
+// Password ...
+func (p PasswordString) Password() (string, error) {
+	return string(p), nil
+}
+
+// passwordChain returns the password of the first source that has one
+type passwordChain []PasswordSource
+
+// Password ...
+func (c passwordChain) Password() (string, error) {
+	var errs []string
+	for _, source := range c {
+		pass, err := source.Password()
+		if err == nil {
+			return pass, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return "", ErrNoPassword
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoPassword, strings.Join(errs, "; "))
+}
+
+// PasswordFromConfig builds the password source from the signer settings,
+// the password saved with the account is used when nothing is set.
+func PasswordFromConfig(cfg *config.Config, acc *Account) PasswordSource {
+	var chain passwordChain
+	if cfg.Signer.PasswordFile != "" {
+		chain = append(chain, PasswordFile(cfg.Signer.PasswordFile))
+	}
+	if cfg.Signer.PasswordEnv != "" {
+		chain = append(chain, PasswordEnv(cfg.Signer.PasswordEnv))
+	}
+	if cfg.Signer.Prompt {
+		chain = append(chain, PasswordPrompt(acc.Name))
+	}
+	if len(chain) == 0 && acc.Password != "" {
+		chain = append(chain, PasswordString(acc.Password))
+	}
+	return chain
+}
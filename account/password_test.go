@@ -0,0 +1,57 @@
+package account
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glvd/accipfs/config"
+)
+
+func TestPasswordFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accipfs-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(file, []byte("from file\r\nsecond line\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	const env = "ACCIPFS_TEST_PASSWORD"
+	if err := os.Setenv(env, "from env"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(env)
+	acc := &Account{Name: "node", Password: "saved"}
+
+	for _, c := range []struct {
+		name   string
+		signer config.SignerConfig
+		want   string
+	}{
+		{"saved", config.SignerConfig{}, "saved"},
+		{"file first", config.SignerConfig{PasswordFile: file, PasswordEnv: env}, "from file"},
+		{"env", config.SignerConfig{PasswordEnv: env}, "from env"},
+		{"missing file", config.SignerConfig{PasswordFile: filepath.Join(dir, "none"), PasswordEnv: env}, "from env"},
+	} {
+		cfg := config.Default()
+		cfg.Signer = c.signer
+		pass, err := PasswordFromConfig(cfg, acc).Password()
+		if err != nil || pass != c.want {
+			t.Errorf("%s: %q %v, want %q", c.name, pass, err, c.want)
+		}
+	}
+
+	// the saved password is not used once a source is configured
+	cfg := config.Default()
+	cfg.Signer = config.SignerConfig{PasswordFile: filepath.Join(dir, "none"), PasswordEnv: "ACCIPFS_TEST_UNSET"}
+	if pass, err := PasswordFromConfig(cfg, acc).Password(); !errors.Is(err, ErrNoPassword) {
+		t.Errorf("no source: %q %v", pass, err)
+	}
+	if _, err := PasswordFromConfig(config.Default(), &Account{}).Password(); !errors.Is(err, ErrNoPassword) {
+		t.Errorf("no password: %v", err)
+	}
+}
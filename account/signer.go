@@ -0,0 +1,191 @@
+package account
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glvd/accipfs/config"
+)
+
+var errNotAuthorized = errors.New("not authorized to sign this account")
+
+// Signer signs the contract transactions of the node account
+type Signer interface {
+	Address() common.Address
+	Unlock() error
+	Transactor(ctx context.Context) (*bind.TransactOpts, error)
+	SignText(data []byte) ([]byte, error)
+}
+
+// NewSigner loads the configured account, the key is decrypted once and kept in memory.
+// When an external signer is set, transactions are signed by it (clef compatible).
+func NewSigner(cfg *config.Config) (Signer, error) {
+	acc, err := LoadAccount(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Signer.External != "" {
+		genesis, err := config.LoadGenesis(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &externalSigner{
+			endpoint: cfg.Signer.External,
+			address:  acc.ETHAddress(),
+			chainID:  big.NewInt(genesis.Config.ChainID),
+		}, nil
+	}
+	return NewKeySigner(acc, PasswordFromConfig(cfg, acc)), nil
+}
+
+// NewKeySigner ...
+func NewKeySigner(acc *Account, password PasswordSource) Signer {
+	return &keySigner{
+		acc:      acc,
+		password: password,
+	}
+}
+
+// ETHAddress ...
+func (acc *Account) ETHAddress() common.Address {
+	return common.HexToAddress(acc.KeyStore.Address)
+}
+
+type keySigner struct {
+	mut      sync.Mutex
+	acc      *Account
+	password PasswordSource
+	key      *ecdsa.PrivateKey
+}
+
+// Address ...
+func (s *keySigner) Address() common.Address {
+	return s.acc.ETHAddress()
+}
+
+// Unlock ...
+func (s *keySigner) Unlock() error {
+	_, err := s.privateKey()
+	return err
+}
+
+func (s *keySigner) privateKey() (*ecdsa.PrivateKey, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.key != nil {
+		return s.key, nil
+	}
+	pass, err := s.password.Password()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := json.Marshal(s.acc.KeyStore)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(bytes, pass)
+	if err != nil {
+		return nil, fmt.Errorf("unlock account %s: %w", s.acc.Name, err)
+	}
+	s.key = key.PrivateKey
+	return s.key, nil
+}
+
+// Transactor ...
+func (s *keySigner) Transactor(ctx context.Context) (*bind.TransactOpts, error) {
+	key, err := s.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	opts := bind.NewKeyedTransactor(key)
+	opts.Context = ctx
+	return opts, nil
+}
+
+// SignText signs the keccak256 hash of the text message (eip-191)
+func (s *keySigner) SignText(data []byte) ([]byte, error) {
+	key, err := s.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(accounts.TextHash(data), key)
+}
+
+type externalSigner struct {
+	mut      sync.Mutex
+	endpoint string
+	address  common.Address
+	chainID  *big.Int
+	signer   *external.ExternalSigner
+}
+
+// Address ...
+func (s *externalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *externalSigner) connect() (*external.ExternalSigner, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.signer != nil {
+		return s.signer, nil
+	}
+	signer, err := external.NewExternalSigner(s.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("external signer %s: %w", s.endpoint, err)
+	}
+	s.signer = signer
+	return signer, nil
+}
+
+// Unlock checks the external signer manages the node account
+func (s *externalSigner) Unlock() error {
+	signer, err := s.connect()
+	if err != nil {
+		return err
+	}
+	if !signer.Contains(accounts.Account{Address: s.address}) {
+		return fmt.Errorf("external signer %s does not manage account %s", s.endpoint, s.address.Hex())
+	}
+	return nil
+}
+
+// Transactor ...
+func (s *externalSigner) Transactor(ctx context.Context) (*bind.TransactOpts, error) {
+	signer, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	acc := accounts.Account{Address: s.address}
+	return &bind.TransactOpts{
+		From:    s.address,
+		Context: ctx,
+		Signer: func(_ types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.address {
+				return nil, errNotAuthorized
+			}
+			return signer.SignTx(acc, tx, s.chainID)
+		},
+	}, nil
+}
+
+// SignText ...
+func (s *externalSigner) SignText(data []byte) ([]byte, error) {
+	signer, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return signer.SignText(accounts.Account{Address: s.address}, data)
+}
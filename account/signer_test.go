@@ -0,0 +1,76 @@
+package account
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testAccount(t *testing.T, dir string) *Account {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var acc Account
+	if err := acc.loadKey(&act); err != nil {
+		t.Fatal(err)
+	}
+	acc.getName(&act)
+	return &acc
+}
+
+func TestKeySigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accipfs-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	acc := testAccount(t, dir)
+	if err := NewKeySigner(acc, PasswordString("wrong")).Unlock(); err == nil {
+		t.Fatal("unlocked with a wrong password")
+	}
+
+	s := NewKeySigner(acc, PasswordString("secret"))
+	opts, err := s.Transactor(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.From != acc.ETHAddress() {
+		t.Fatalf("transactor of %s, want %s", opts.From.Hex(), acc.ETHAddress().Hex())
+	}
+	signer := types.NewEIP155Signer(big.NewInt(20200))
+	tx := types.NewTransaction(3, common.HexToAddress("0x01"), big.NewInt(0), 21000, big.NewInt(1), nil)
+	signed, err := opts.Signer(signer, opts.From, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil || sender != acc.ETHAddress() {
+		t.Fatalf("sender %s %v", sender.Hex(), err)
+	}
+	if _, err := opts.Signer(signer, common.HexToAddress("0x02"), tx); err == nil {
+		t.Fatal("signed for another account")
+	}
+
+	msg := []byte("pin proof")
+	sig, err := s.SignText(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(msg), sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != acc.ETHAddress() {
+		t.Fatalf("text signed by %v %v", pub, err)
+	}
+}
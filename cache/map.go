@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound ...
+var ErrNotFound = errors.New("cache: key not found")
+
+type mapValue struct {
+	val    []byte
+	expire time.Time
+}
+
+// mapCacher keeps all the values in a map, it is used when the data should not be persisted
+type mapCacher struct {
+	mut    sync.RWMutex
+	values map[string]mapValue
+}
+
+func newMapCacher() *mapCacher {
+	return &mapCacher{values: make(map[string]mapValue)}
+}
+
+func (c *mapCacher) load(key string) ([]byte, bool) {
+	v, b := c.values[key]
+	if !b {
+		return nil, false
+	}
+	if !v.expire.IsZero() && time.Now().After(v.expire) {
+		return nil, false
+	}
+	return v.val, true
+}
+
+// Get ...
+func (c *mapCacher) Get(key string) ([]byte, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	v, b := c.load(key)
+	if !b {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+// GetD ...
+func (c *mapCacher) GetD(key string, v []byte) []byte {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	if val, b := c.load(key); b {
+		return val
+	}
+	return v
+}
+
+// Set ...
+func (c *mapCacher) Set(key string, val []byte) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.values[key] = mapValue{val: val}
+	return nil
+}
+
+// SetWithTTL ...
+func (c *mapCacher) SetWithTTL(key string, val []byte, ttl int64) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.values[key] = mapValue{val: val, expire: time.Now().Add(time.Duration(ttl) * time.Second)}
+	return nil
+}
+
+// Has ...
+func (c *mapCacher) Has(key string) (bool, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	_, b := c.load(key)
+	return b, nil
+}
+
+// Delete ...
+func (c *mapCacher) Delete(key string) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+// Clear ...
+func (c *mapCacher) Clear() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.values = make(map[string]mapValue)
+	return nil
+}
+
+// GetMultiple ...
+func (c *mapCacher) GetMultiple(keys ...string) (map[string][]byte, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if v, b := c.load(key); b {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+// SetMultiple ...
+func (c *mapCacher) SetMultiple(values map[string][]byte) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for key, val := range values {
+		c.values[key] = mapValue{val: val}
+	}
+	return nil
+}
+
+// DeleteMultiple ...
+func (c *mapCacher) DeleteMultiple(keys ...string) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for _, key := range keys {
+		delete(c.values, key)
+	}
+	return nil
+}
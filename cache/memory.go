@@ -6,18 +6,30 @@ import (
 	"github.com/glvd/accipfs/config"
 	"github.com/glvd/accipfs/core"
 	"github.com/gocacher/badger-cache/v2"
-	"github.com/gocacher/cacher"
-	"path/filepath"
 	"sync"
 )
 
+// Cacher ...
+type Cacher interface {
+	Get(key string) ([]byte, error)
+	GetD(key string, v []byte) []byte
+	Set(key string, val []byte) error
+	SetWithTTL(key string, val []byte, ttl int64) error
+	Has(key string) (bool, error)
+	Delete(key string) error
+	Clear() error
+	GetMultiple(keys ...string) (map[string][]byte, error)
+	SetMultiple(values map[string][]byte) error
+	DeleteMultiple(keys ...string) error
+}
+
 // MemoryCache ...
 type MemoryCache struct {
 	path   string
 	loop   ring.Ring
 	memory map[string][]byte
 	mut    sync.RWMutex
-	cache  cacher.Cacher
+	cache  Cacher
 }
 
 func nodePrefix(name string) string {
@@ -28,6 +40,14 @@ func hashPrefix(hash string) string {
 	return "hash_" + hash
 }
 
+func proofPrefix(hash string) string {
+	return "proof_" + hash
+}
+
+func videoPrefix(hash string) string {
+	return "video_" + hash
+}
+
 // Get ...
 func (m *MemoryCache) Get(key string) ([]byte, error) {
 	m.mut.RLock()
@@ -228,12 +248,101 @@ func (m *MemoryCache) AddOrUpdate(hash string, info *core.NodeInfo) error {
 	return nil
 }
 
+// RemoveHashNode drops a node from the providers of a hash
+func (m *MemoryCache) RemoveHashNode(hash string, name string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	get, err := m.cache.Get(hashPrefix(hash))
+	if err != nil {
+		return err
+	}
+	nodes := make(map[string][]byte)
+	err = json.Unmarshal(get, &nodes)
+	if err != nil {
+		return err
+	}
+	if _, b := nodes[name]; !b {
+		return nil
+	}
+	delete(nodes, name)
+	if len(nodes) == 0 {
+		return m.cache.Delete(hashPrefix(hash))
+	}
+	marshal, err := json.Marshal(nodes)
+	if err != nil {
+		return err
+	}
+	return m.cache.Set(hashPrefix(hash), marshal)
+}
+
+// SetPinProof ...
+func (m *MemoryCache) SetPinProof(proof *core.PinProof) error {
+	marshal, err := json.Marshal(proof)
+	if err != nil {
+		return err
+	}
+	return m.Set(proofPrefix(proof.Hash), marshal)
+}
+
+// GetPinProof ...
+func (m *MemoryCache) GetPinProof(hash string) (*core.PinProof, error) {
+	get, err := m.Get(proofPrefix(hash))
+	if err != nil {
+		return nil, err
+	}
+	var proof core.PinProof
+	err = json.Unmarshal(get, &proof)
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// DeletePinProof ...
+func (m *MemoryCache) DeletePinProof(hash string) error {
+	return m.Delete(proofPrefix(hash))
+}
+
+// SetVideoNo ...
+func (m *MemoryCache) SetVideoNo(no string, hashes ...string) error {
+	values := make(map[string][]byte)
+	for _, hash := range hashes {
+		if hash != "" {
+			values[videoPrefix(hash)] = []byte(no)
+		}
+	}
+	return m.SetMultiple(values)
+}
+
+// GetVideoNo returns the video number of a hash
+func (m *MemoryCache) GetVideoNo(hash string) (string, error) {
+	get, err := m.Get(videoPrefix(hash))
+	if err != nil {
+		return "", err
+	}
+	return string(get), nil
+}
+
+// badgerPath guards the path the badger cache reads when it opens
+var badgerPath sync.Mutex
+
 // New ...
 func New(cfg *config.Config) *MemoryCache {
-	cache.DefaultCachePath = filepath.Join(cfg.Path, ".cache")
+	path := cfg.DataDirCache()
+	badgerPath.Lock()
+	defer badgerPath.Unlock()
+	cache.DefaultCachePath = path
 	return &MemoryCache{
-		path:   cache.DefaultCachePath,
+		path:   path,
 		memory: make(map[string][]byte),
 		cache:  cache.New(),
 	}
 }
+
+// NewInMemory returns a cache that is never written to disk
+func NewInMemory() *MemoryCache {
+	return &MemoryCache{
+		memory: make(map[string][]byte),
+		cache:  newMapCacher(),
+	}
+}
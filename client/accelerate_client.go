@@ -1,59 +1,53 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"github.com/glvd/accipfs/core"
-	"github.com/glvd/accipfs/general"
 	"github.com/goextension/log"
-	"strconv"
-	"strings"
 )
 
 // ID ...
 func ID(url string) (*core.NodeInfo, error) {
-	reply := new(core.NodeInfo)
-	if err := general.RPCPost(url, "Accelerate.ID", core.DummyEmpty(), reply); err != nil {
-		return nil, err
-	}
-	return reply, nil
+	return New(url).ID(context.Background())
 }
 
 // Ping ...
 func Ping(info *core.NodeInfo) error {
 	log.Debugw("ping info", "addr", info.RemoteAddr, "port", info.Port)
-	pingAddr := strings.Join([]string{info.RemoteAddr, strconv.Itoa(info.Port)}, ":")
-	url := fmt.Sprintf("http://%s/rpc", pingAddr)
-	result := new(string)
-	if err := general.RPCPost(url, "Accelerate.Ping", core.DummyEmpty(), result); err != nil {
-		return err
-	}
-	if *result != "pong" {
-		return fmt.Errorf("get wrong response data:%s", *result)
-	}
-	return nil
+	return NewNode(info).Ping(context.Background())
 }
 
 // Pins ...
 func Pins(info *core.NodeInfo) ([]string, error) {
 	log.Debugw("pin info", "addr", info.RemoteAddr, "port", info.Port)
-	pingAddr := strings.Join([]string{info.RemoteAddr, strconv.Itoa(info.Port)}, ":")
-	url := fmt.Sprintf("http://%s/rpc", pingAddr)
-	result := new([]string)
-	if err := general.RPCPost(url, "Accelerate.Pins", core.DummyEmpty(), result); err != nil {
-		return nil, err
-	}
-	return *result, nil
+	return NewNode(info).Pins(context.Background())
+}
+
+// Challenge ...
+func Challenge(info *core.NodeInfo, challenge *core.Challenge) (*core.ChallengeResponse, error) {
+	return NewNode(info).Challenge(context.Background(), challenge)
+}
+
+// Bandwidth ...
+func Bandwidth(url string) (*core.Bandwidth, error) {
+	return New(url).Bandwidth(context.Background())
+}
+
+// ReloadConfig ...
+func ReloadConfig(url string) (*core.ConfigChanges, error) {
+	return New(url).ReloadConfig(context.Background())
 }
 
 // PinVideo ...
 func PinVideo(url string, no string) error {
 	log.Debugw("pin hash", "hash", no)
-	b := new(bool)
-	err := general.RPCPost(url, "Accelerate.PinVideo", &no, b)
+	// pinning waits for the whole video
+	b, err := New(url, WithTimeout(0)).PinVideo(context.Background(), no)
 	if err != nil {
 		return err
 	}
-	if *b {
+	if b {
 		fmt.Printf("pin (%s) success\n", no)
 	}
 	return nil
@@ -61,28 +55,30 @@ func PinVideo(url string, no string) error {
 
 // Peers ...
 func Peers(url string, info *core.NodeInfo) ([]*core.NodeInfo, error) {
-	//pingAddr := strings.Join([]string{info.RemoteAddr, strconv.Itoa(info.Port)}, ":")
-	//url := fmt.Sprintf("http://%s/rpc", pingAddr)
-	result := new([]*core.NodeInfo)
-	if err := general.RPCPost(url, "Accelerate.Peers", info, result); err != nil {
+	result, err := New(url).Peers(context.Background())
+	if err != nil {
 		return nil, err
 	}
-	if len(*result) == 0 {
+	if len(result) == 0 {
 		return nil, fmt.Errorf("no data response")
 	}
-	return *result, nil
+	return result, nil
 }
 
 // AddPeer ...
 func AddPeer(url string, info *core.NodeInfo) error {
-	status := new(bool)
-	if err := general.RPCPost(url, "Accelerate.AddPeer", info, status); err != nil {
+	status, err := New(url).AddPeer(context.Background(), info)
+	if err != nil {
 		log.Errorw("remote id error", "error", err.Error())
 		return fmt.Errorf("remote id error: %w", err)
 	}
-
-	if !(*status) {
+	if !status {
 		return fmt.Errorf("connect failed:%s", url)
 	}
 	return nil
 }
+
+// UserRepo ...
+func UserRepo(url string, address string) ([]core.RepoEntry, error) {
+	return New(url).UserRepo(context.Background(), address)
+}
@@ -0,0 +1,343 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+	"github.com/goextension/log"
+)
+
+// DefaultTimeout limits one call of a client made without WithTimeout
+const DefaultTimeout = 30 * time.Second
+
+// defaultTransport is shared by the clients so their connections are reused
+var defaultTransport = &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+	MaxIdleConnsPerHost:   4,
+	IdleConnTimeout:       90 * time.Second,
+	ResponseHeaderTimeout: DefaultTimeout,
+}
+
+// Client calls the Accelerate rpc of one node
+type Client struct {
+	url     string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+	auth    []func(r *http.Request) error
+}
+
+// Option ...
+type Option func(c *Client)
+
+// WithHTTPClient sends the calls with the http client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithTransport sends the calls with the round tripper
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		hc := *c.http
+		hc.Transport = rt
+		c.http = &hc
+	}
+}
+
+// WithTimeout limits every request, 0 leaves only the context
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		hc := *c.http
+		hc.Timeout = d
+		if t, b := hc.Transport.(*http.Transport); b && t == defaultTransport {
+			// the default transport gives up waiting for the answer after the default timeout
+			t = defaultTransport.Clone()
+			t.ResponseHeaderTimeout = d
+			hc.Transport = t
+		}
+		c.http = &hc
+	}
+}
+
+// WithRetries repeats a call that failed in transport or with a 5xx or 429
+// status, the wait before a retry doubles from backoff
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries, c.backoff = n, backoff
+	}
+}
+
+// WithAuth changes every request before it is sent, to add credentials or sign it
+func WithAuth(fn func(r *http.Request) error) Option {
+	return func(c *Client) {
+		c.auth = append(c.auth, fn)
+	}
+}
+
+// WithBearerToken sends the token in the Authorization header
+func WithBearerToken(token string) Option {
+	return WithAuth(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// New returns a client of the rpc url (http://host:port/rpc)
+func New(url string, opts ...Option) *Client {
+	c := &Client{
+		url: url,
+		http: &http.Client{
+			Timeout:   DefaultTimeout,
+			Transport: defaultTransport,
+		},
+		backoff: 500 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewNode returns a client of the rpc of a known node
+func NewNode(info *core.NodeInfo, opts ...Option) *Client {
+	return New(info.Address().URL(), opts...)
+}
+
+// URL ...
+func (c *Client) URL() string {
+	return c.url
+}
+
+// transport ...
+func (c *Client) transport() *general.RPCTransport {
+	return &general.RPCTransport{
+		Client: c.http,
+		Prepare: func(r *http.Request) error {
+			for _, fn := range c.auth {
+				if err := fn(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// retry reports whether a failed request is worth sending again
+func retry(ctx context.Context, err error) bool {
+	var status *general.StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= http.StatusInternalServerError || status.StatusCode == http.StatusTooManyRequests
+	}
+	var transport *general.TransportError
+	return errors.As(err, &transport) && ctx.Err() == nil
+}
+
+// do runs the request until it succeeds, fails for good or the retries are used up
+func (c *Client) do(ctx context.Context, what string, fn func() error) error {
+	wait := c.backoff
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || !retry(ctx, err) || i >= c.retries {
+			return err
+		}
+		log.Debugw("rpc retry", "url", c.url, "call", what, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// Call calls an rpc method, errors of the method are returned as *general.RemoteError
+func (c *Client) Call(ctx context.Context, method string, input, output interface{}) error {
+	t := c.transport()
+	return c.do(ctx, method, func() error {
+		return t.Call(ctx, c.url, method, input, output)
+	})
+}
+
+// Batch sends the calls in one request, see general.RPCTransport.Batch
+func (c *Client) Batch(ctx context.Context, calls ...*general.RPCCall) error {
+	t := c.transport()
+	return c.do(ctx, "batch", func() error {
+		return t.Batch(ctx, c.url, calls)
+	})
+}
+
+// Ping ...
+func (c *Client) Ping(ctx context.Context) error {
+	result := new(string)
+	if err := c.Call(ctx, "Accelerate.Ping", core.DummyEmpty(), result); err != nil {
+		return err
+	}
+	if *result != "pong" {
+		return fmt.Errorf("get wrong response data:%s", *result)
+	}
+	return nil
+}
+
+// ID ...
+func (c *Client) ID(ctx context.Context) (*core.NodeInfo, error) {
+	result := new(core.NodeInfo)
+	if err := c.Call(ctx, "Accelerate.ID", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Version ...
+func (c *Client) Version(ctx context.Context) (*core.BuildInfo, error) {
+	result := new(core.BuildInfo)
+	if err := c.Call(ctx, "Accelerate.Version", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Connected introduces this node to the remote node, the remote answers with its id
+func (c *Client) Connected(ctx context.Context, self *core.NodeInfo) (*core.NodeInfo, error) {
+	result := new(core.NodeInfo)
+	if err := c.Call(ctx, "Accelerate.Connected", self, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ConnectTo makes the node connect to the rpc address (host:port) of another node
+func (c *Client) ConnectTo(ctx context.Context, addr string) (*core.NodeInfo, error) {
+	result := new(core.NodeInfo)
+	if err := c.Call(ctx, "Accelerate.ConnectTo", &addr, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddPeer ...
+func (c *Client) AddPeer(ctx context.Context, info *core.NodeInfo) (bool, error) {
+	result := new(bool)
+	if err := c.Call(ctx, "Accelerate.AddPeer", info, result); err != nil {
+		return false, err
+	}
+	return *result, nil
+}
+
+// Peers ...
+func (c *Client) Peers(ctx context.Context) ([]*core.NodeInfo, error) {
+	result := new([]*core.NodeInfo)
+	if err := c.Call(ctx, "Accelerate.Peers", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// Pins ...
+func (c *Client) Pins(ctx context.Context) ([]string, error) {
+	result := new([]string)
+	if err := c.Call(ctx, "Accelerate.Pins", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// PinVideo pins the hashes of a video number, it returns when they are pinned
+func (c *Client) PinVideo(ctx context.Context, no string) (bool, error) {
+	result := new(bool)
+	if err := c.Call(ctx, "Accelerate.PinVideo", &no, result); err != nil {
+		return false, err
+	}
+	return *result, nil
+}
+
+// TagInfo ...
+func (c *Client) TagInfo(ctx context.Context, tag string) (string, error) {
+	result := new(string)
+	if err := c.Call(ctx, "Accelerate.TagInfo", &tag, result); err != nil {
+		return "", err
+	}
+	return *result, nil
+}
+
+// Info ...
+func (c *Client) Info(ctx context.Context, hash string) (string, error) {
+	result := new(string)
+	if err := c.Call(ctx, "Accelerate.Info", &hash, result); err != nil {
+		return "", err
+	}
+	return *result, nil
+}
+
+// Transactions ...
+func (c *Client) Transactions(ctx context.Context) ([]contract.TxRecord, error) {
+	result := new([]contract.TxRecord)
+	if err := c.Call(ctx, "Accelerate.Transactions", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// Writers ...
+func (c *Client) Writers(ctx context.Context) ([]string, error) {
+	result := new([]string)
+	if err := c.Call(ctx, "Accelerate.Writers", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// UserRepo ...
+func (c *Client) UserRepo(ctx context.Context, address string) ([]core.RepoEntry, error) {
+	result := new([]core.RepoEntry)
+	if err := c.Call(ctx, "Accelerate.UserRepo", &address, result); err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
+// PinProof ...
+func (c *Client) PinProof(ctx context.Context, hash string) (*core.PinProof, error) {
+	result := new(core.PinProof)
+	if err := c.Call(ctx, "Accelerate.PinProof", &hash, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Challenge ...
+func (c *Client) Challenge(ctx context.Context, challenge *core.Challenge) (*core.ChallengeResponse, error) {
+	result := new(core.ChallengeResponse)
+	if err := c.Call(ctx, "Accelerate.Challenge", challenge, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Bandwidth ...
+func (c *Client) Bandwidth(ctx context.Context) (*core.Bandwidth, error) {
+	result := new(core.Bandwidth)
+	if err := c.Call(ctx, "Accelerate.Bandwidth", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReloadConfig ...
+func (c *Client) ReloadConfig(ctx context.Context) (*core.ConfigChanges, error) {
+	result := new(core.ConfigChanges)
+	if err := c.Call(ctx, "Accelerate.ReloadConfig", core.DummyEmpty(), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
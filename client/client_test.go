@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+	"github.com/goextension/log/zap"
+	"github.com/gorilla/rpc/v2"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+func init() {
+	zap.InitZapFileSugar()
+}
+
+// Accelerate answers like the rpc of a node
+type Accelerate struct{}
+
+// Ping ...
+func (a *Accelerate) Ping(r *http.Request, _ *core.Empty, result *string) error {
+	*result = "pong"
+	return nil
+}
+
+// TagInfo ...
+func (a *Accelerate) TagInfo(r *http.Request, tag *string, result *string) error {
+	if *tag == "" {
+		return errors.New("empty tag")
+	}
+	*result = "info of " + *tag
+	return nil
+}
+
+// PinVideo ...
+func (a *Accelerate) PinVideo(r *http.Request, no *string, result *bool) error {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(5 * time.Second):
+	}
+	return nil
+}
+
+func newServer(t *testing.T, wrap func(h http.Handler) http.Handler) *httptest.Server {
+	s := rpc.NewServer()
+	s.RegisterCodec(json2.NewCodec(), "application/json")
+	if err := s.RegisterService(new(Accelerate), ""); err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(wrap(s))
+}
+
+func TestClientCall(t *testing.T) {
+	var token string
+	srv := newServer(t, func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token = r.Header.Get("Authorization")
+			h.ServeHTTP(w, r)
+		})
+	})
+	defer srv.Close()
+
+	c := New(srv.URL, WithBearerToken("secret"))
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if token != "Bearer secret" {
+		t.Fatalf("auth header %q", token)
+	}
+	info, err := c.TagInfo(context.Background(), "abc")
+	if err != nil || info != "info of abc" {
+		t.Fatal(info, err)
+	}
+	_, err = c.TagInfo(context.Background(), "")
+	var rpcErr *general.RemoteError
+	if !errors.As(err, &rpcErr) || rpcErr.Message != "empty tag" {
+		t.Fatalf("rpc error %v", err)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var calls int32
+	srv := newServer(t, func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	})
+	defer srv.Close()
+
+	err := New(srv.URL, WithRetries(1, time.Millisecond)).Ping(context.Background())
+	var status *general.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status error %v", err)
+	}
+	atomic.StoreInt32(&calls, 0)
+	if err := New(srv.URL, WithRetries(2, time.Millisecond)).Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("%d calls", n)
+	}
+}
+
+func TestClientNoRetryOnRPCError(t *testing.T) {
+	var calls int32
+	srv := newServer(t, func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			h.ServeHTTP(w, r)
+		})
+	})
+	defer srv.Close()
+
+	if _, err := New(srv.URL, WithRetries(3, time.Millisecond)).TagInfo(context.Background(), ""); err == nil {
+		t.Fatal("no error")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("%d calls", n)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	srv := newServer(t, func(h http.Handler) http.Handler { return h })
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := New(srv.URL, WithTimeout(0), WithRetries(3, time.Millisecond)).PinVideo(ctx, "abc")
+	if err == nil || time.Since(start) > 2*time.Second {
+		t.Fatalf("call not cancelled: %v after %s", err, time.Since(start))
+	}
+	_, err = New(srv.URL, WithTimeout(50*time.Millisecond)).PinVideo(context.Background(), "abc")
+	if !general.IsTimeout(err) {
+		t.Fatalf("call not timed out: %v", err)
+	}
+}
+
+func TestClientBatchUnsupported(t *testing.T) {
+	srv := newServer(t, func(h http.Handler) http.Handler { return h })
+	defer srv.Close()
+
+	pong := new(string)
+	err := New(srv.URL).Batch(context.Background(), &general.RPCCall{Method: "Accelerate.Ping", Input: core.DummyEmpty(), Output: pong})
+	var status *general.StatusError
+	if !errors.As(err, &status) {
+		t.Fatalf("want a status error, got %v", err)
+	}
+}
+
+func TestCloseGRPC(t *testing.T) {
+	addr := "127.0.0.1:1"
+	cached := func() bool {
+		grpcConns.Lock()
+		defer grpcConns.Unlock()
+		_, b := grpcConns.m[addr]
+		return b
+	}
+	g, err := NewGRPC(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached() {
+		t.Fatal("connection not kept")
+	}
+	if err := CloseGRPC(addr); err != nil {
+		t.Fatal(err)
+	}
+	if cached() {
+		t.Fatal("connection kept after close")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := g.Ping(ctx); err == nil {
+		t.Fatal("ping over a closed connection")
+	}
+	if err := CloseGRPC(addr); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/core/pb"
+	"google.golang.org/grpc"
+)
+
+// grpcConns keeps one connection to every grpc address until CloseGRPC, grpc
+// reconnects it when it breaks
+var grpcConns = struct {
+	sync.Mutex
+	m map[string]*grpc.ClientConn
+}{m: make(map[string]*grpc.ClientConn)}
+
+func grpcConn(addr string) (*grpc.ClientConn, error) {
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	if conn, b := grpcConns.m[addr]; b {
+		return conn, nil
+	}
+	// the dial does not wait for the connection, the first call does
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	grpcConns.m[addr] = conn
+	return conn, nil
+}
+
+// CloseGRPC closes the connection of the address, the next client dials again
+func CloseGRPC(addr string) error {
+	grpcConns.Lock()
+	conn, b := grpcConns.m[addr]
+	delete(grpcConns.m, addr)
+	grpcConns.Unlock()
+	if !b {
+		return nil
+	}
+	return conn.Close()
+}
+
+// GRPC calls the peer protocol of a node over grpc
+type GRPC struct {
+	addr string
+	peer pb.PeerClient
+}
+
+// NewGRPC returns a grpc client of the address (host:port)
+func NewGRPC(addr string) (*GRPC, error) {
+	conn, err := grpcConn(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPC{addr: addr, peer: pb.NewPeerClient(conn)}, nil
+}
+
+// Addr ...
+func (c *GRPC) Addr() string {
+	return c.addr
+}
+
+// Ping ...
+func (c *GRPC) Ping(ctx context.Context) error {
+	pong, err := c.peer.Ping(ctx, &pb.Empty{})
+	if err != nil {
+		return err
+	}
+	if pong.GetMessage() != "pong" {
+		return fmt.Errorf("get wrong response data:%s", pong.GetMessage())
+	}
+	return nil
+}
+
+// Connected introduces this node to the remote node, the remote answers with its id
+func (c *GRPC) Connected(ctx context.Context, self *core.NodeInfo) (*core.NodeInfo, error) {
+	remote, err := c.peer.Connected(ctx, pb.NewNodeInfo(self))
+	if err != nil {
+		return nil, err
+	}
+	return remote.Info(), nil
+}
+
+// Peers ...
+func (c *GRPC) Peers(ctx context.Context) ([]*core.NodeInfo, error) {
+	stream, err := c.peer.Peers(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	var peers []*core.NodeInfo
+	for {
+		info, err := stream.Recv()
+		if err == io.EOF {
+			return peers, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, info.Info())
+	}
+}
+
+// Pins ...
+func (c *GRPC) Pins(ctx context.Context) ([]string, error) {
+	stream, err := c.peer.Pins(ctx, &pb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	var pins []string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return pins, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		pins = append(pins, chunk.GetHashes()...)
+	}
+}
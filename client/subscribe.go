@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+	"github.com/gorilla/websocket"
+)
+
+// Subscription receives the notifications of the node until it is closed
+type Subscription struct {
+	ID     string
+	C      <-chan *core.Notification
+	conn   *websocket.Conn
+	once   sync.Once
+	closed int32
+	err    error
+}
+
+// Err returns the error that ended the subscription, after C is closed
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Close ...
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		atomic.StoreInt32(&s.closed, 1)
+		_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// WebSocketURL returns the websocket endpoint of the node of the rpc url
+func WebSocketURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		url = "wss://" + strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "http://"):
+		url = "ws://" + strings.TrimPrefix(url, "http://")
+	}
+	return strings.TrimSuffix(url, "/rpc") + "/ws"
+}
+
+type wsMessage struct {
+	ID     json.RawMessage      `json:"id"`
+	Result json.RawMessage      `json:"result"`
+	Error  *general.RemoteError `json:"error"`
+	Params struct {
+		Subscription string             `json:"subscription"`
+		Result       *core.Notification `json:"result"`
+	} `json:"params"`
+}
+
+// Subscribe opens a websocket to the node and subscribes to the topics, all
+// topics when none is given. The subscription ends with the context.
+func (c *Client) Subscribe(ctx context.Context, topics ...string) (*Subscription, error) {
+	if topics == nil {
+		topics = []string{}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range c.auth {
+		if err := fn(req); err != nil {
+			return nil, err
+		}
+	}
+	url := WebSocketURL(c.url)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, req.Header)
+	if err != nil {
+		return nil, &general.TransportError{URL: url, Err: err}
+	}
+	err = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "subscribe", "params": topics})
+	if err != nil {
+		conn.Close()
+		return nil, &general.TransportError{URL: url, Err: err}
+	}
+	var answer wsMessage
+	if err := conn.ReadJSON(&answer); err != nil {
+		conn.Close()
+		return nil, &general.TransportError{URL: url, Err: err}
+	}
+	if answer.Error != nil {
+		conn.Close()
+		return nil, answer.Error
+	}
+	var id string
+	if err := json.Unmarshal(answer.Result, &id); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("subscribe answer: %w", err)
+	}
+	ch := make(chan *core.Notification, 64)
+	sub := &Subscription{ID: id, C: ch, conn: conn}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			sub.Close()
+		case <-done:
+		}
+	}()
+	go func() {
+		defer close(ch)
+		defer close(done)
+		for {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				if atomic.LoadInt32(&sub.closed) == 0 && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					sub.err = err
+				}
+				return
+			}
+			if msg.Params.Subscription != id || msg.Params.Result == nil {
+				continue
+			}
+			select {
+			case ch <- msg.Params.Result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
@@ -30,8 +30,10 @@ var DefaultTokenContractAddr = "0x9064322CfeE623A447ba5aF0dA6AD3341c073535"
 type IPFSConfig struct {
 	Name string `json:"name" mapstructure:"name"`
 	//Addr    string `json:"addr" mapstructure:"addr"`
-	Port    int `json:"port" mapstructure:"port"`
-	Timeout int `json:"timeout" mapstructure:"timeout"`
+	Port         int  `json:"port" mapstructure:"port"`
+	GatewayPort  int  `json:"gateway_port" mapstructure:"gateway_port"`   //port of the local ipfs http gateway
+	GatewayProxy bool `json:"gateway_proxy" mapstructure:"gateway_proxy"` //serve the gateway read only on /ipfs/ of the rpc port and count it by video
+	Timeout      int  `json:"timeout" mapstructure:"timeout"`
 }
 
 // ETHConfig ...
@@ -43,7 +45,7 @@ type ETHConfig struct {
 	NodeAddr    string `json:"node_addr" mapstructure:"node_addr"`       //node contract address
 	TokenAddr   string `json:"token_addr" mapstructure:"token_addr"`     //token contract address
 	MessageAddr string `json:"message_addr" mapstructure:"message_addr"` //dmessage contract address
-	DTagAddr    string `json:"dtag_addr" json:"dtag_addr"`               //dtag contract address
+	DTagAddr    string `json:"dtag_addr" mapstructure:"dtag_addr"`       //dtag contract address
 }
 
 // AWSConfig ...
@@ -54,6 +56,65 @@ type AWSConfig struct {
 	AwsSecretAccessKey string `json:"aws_secret_access_key" mapstructure:"aws_secret_access_key"`
 }
 
+// DNSConfig ...
+type DNSConfig struct {
+	Provider      string            `json:"provider" mapstructure:"provider"`             //route53, rfc2136 or file
+	RecordName    string            `json:"record_name" mapstructure:"record_name"`       //gateway name, aws.record_name if empty
+	TTL           int64             `json:"ttl" mapstructure:"ttl"`                       //record ttl in seconds
+	HealthPort    int               `json:"health_port" mapstructure:"health_port"`       //port dialed to check a gateway
+	HealthTimeout int               `json:"health_timeout" mapstructure:"health_timeout"` //dial timeout in seconds
+	Server        string            `json:"server" mapstructure:"server"`                 //rfc2136 server address
+	Zone          string            `json:"zone" mapstructure:"zone"`                     //rfc2136 zone
+	Net           string            `json:"net" mapstructure:"net"`                       //rfc2136 transport, udp or tcp
+	File          string            `json:"file" mapstructure:"file"`                     //hosts file of the file provider
+	Routing       string            `json:"routing" mapstructure:"routing"`               //route53 policy: weighted, latency or multivalue
+	AAAA          bool              `json:"aaaa" mapstructure:"aaaa"`                     //publish ipv6 nodes as AAAA records
+	Regions       map[string]string `json:"regions" mapstructure:"regions"`               //cidr to region of the nodes
+	DryRun        bool              `json:"dry_run" mapstructure:"dry_run"`               //only print the planned changes
+	Listen        string            `json:"listen" mapstructure:"listen"`                 //address of the built-in dns server, off if empty
+	ListenTTL     int64             `json:"listen_ttl" mapstructure:"listen_ttl"`         //ttl of the built-in dns answers
+}
+
+// SignerConfig ...
+type SignerConfig struct {
+	External     string `json:"external" mapstructure:"external"`           //external signer(clef) endpoint
+	PasswordFile string `json:"password_file" mapstructure:"password_file"` //file with the keystore password
+	PasswordEnv  string `json:"password_env" mapstructure:"password_env"`   //env variable with the keystore password
+	Prompt       bool   `json:"prompt" mapstructure:"prompt"`               //ask the keystore password on start
+}
+
+// RecordConfig ...
+type RecordConfig struct {
+	Key    string `json:"key" mapstructure:"key"`       //network key to encrypt the node records, plain if empty
+	Legacy bool   `json:"legacy" mapstructure:"legacy"` //read the dhcrypto entries of older versions
+}
+
+// WatchConfig ...
+type WatchConfig struct {
+	Endpoint      string `json:"endpoint" mapstructure:"endpoint"`           //geth ipc or websocket endpoint, the ipc of the data dir if empty
+	Confirmations uint64 `json:"confirmations" mapstructure:"confirmations"` //blocks on top before a block is processed
+	StartBlock    uint64 `json:"start_block" mapstructure:"start_block"`     //first block when no checkpoint is cached
+	Poll          int64  `json:"poll" mapstructure:"poll"`                   //seconds between head checks without a subscription
+}
+
+// PinConfig ...
+type PinConfig struct {
+	Replicas int `json:"replicas" mapstructure:"replicas"` //nodes that pin each paid hash, all when 0
+}
+
+// ChallengeConfig ...
+type ChallengeConfig struct {
+	Interval      int64 `json:"interval" mapstructure:"interval"`             //seconds between two storage challenges, off when 0
+	Timeout       int64 `json:"timeout" mapstructure:"timeout"`               //seconds a peer has to answer
+	MinReputation int   `json:"min_reputation" mapstructure:"min_reputation"` //peers below are dropped
+}
+
+// GRPCConfig ...
+type GRPCConfig struct {
+	Enable bool `json:"enable" mapstructure:"enable"` //serve the peer protocol over grpc and use it with the peers that do
+	Port   int  `json:"port" mapstructure:"port"`
+}
+
 // ETHKeyFile ...
 type ETHKeyFile struct {
 	Name string `json:"name" mapstructure:"name"`
@@ -62,17 +123,35 @@ type ETHKeyFile struct {
 
 // Config ...
 type Config struct {
-	Port       int        `json:"port" mapstructure:"port"`
-	Schema     string     `json:"schema" mapstructure:"schema"`
-	Path       string     `json:"path" mapstructure:"path" `
-	Account    string     `json:"account" mapstructure:"account"`
-	PrivateKey string     `json:"private_key" mapstructure:"private_key"`
-	PublicKey  string     `json:"public_key" mapstructure:"public_key"`
-	ETH        ETHConfig  `json:"eth" mapstructure:"eth"`
-	IPFS       IPFSConfig `json:"ipfs" mapstructure:"ipfs"`
-	AWS        AWSConfig  `json:"aws" mapstructure:"aws"`
-	Interval   int64      `json:"interval" mapstructure:"interval"`
-	Limit      int64      `json:"limit" mapstructure:"limit"`
+	Version      int             `json:"version" mapstructure:"version"` //schema version, older files are migrated on load
+	Port         int             `json:"port" mapstructure:"port"`
+	Schema       string          `json:"schema" mapstructure:"schema"`
+	Path         string          `json:"path" mapstructure:"path" `
+	Account      string          `json:"account" mapstructure:"account"`
+	PrivateKey   string          `json:"private_key" mapstructure:"private_key"`
+	PublicKey    string          `json:"public_key" mapstructure:"public_key"`
+	ETH          ETHConfig       `json:"eth" mapstructure:"eth"`
+	IPFS         IPFSConfig      `json:"ipfs" mapstructure:"ipfs"`
+	AWS          AWSConfig       `json:"aws" mapstructure:"aws"`
+	DNS          DNSConfig       `json:"dns" mapstructure:"dns"`
+	Signer       SignerConfig    `json:"signer" mapstructure:"signer"`
+	Record       RecordConfig    `json:"record" mapstructure:"record"`
+	Watch        WatchConfig     `json:"watch" mapstructure:"watch"`
+	Pin          PinConfig       `json:"pin" mapstructure:"pin"`
+	Challenge    ChallengeConfig `json:"challenge" mapstructure:"challenge"`
+	Secrets      SecretsConfig   `json:"secrets" mapstructure:"secrets"`
+	GRPC         GRPCConfig      `json:"grpc" mapstructure:"grpc"`
+	Interval     int64           `json:"interval" mapstructure:"interval"`
+	Limit        int64           `json:"limit" mapstructure:"limit"`
+	LeaderPeriod int64           `json:"leader_period" mapstructure:"leader_period"` //seconds one elected node keeps the maintenance duties
+	Schedule     string          `json:"schedule" mapstructure:"schedule"`           //cron spec (with seconds) of the peer sync
+	Peers        []string        `json:"peers" mapstructure:"peers"`                 //host:port of the nodes connected on start
+	LogLevel     string          `json:"log_level" mapstructure:"log_level"`         //log level of the daemon, the --log-level flag if empty
+	WSOrigins    []string        `json:"ws_origins" mapstructure:"ws_origins"`       //scheme://host[:port] of the pages that may open the websocket besides the node itself
+	// saved keeps the config values of the secrets overridden by the environment
+	saved map[string]string
+	// dir is where the config was loaded from
+	dir string
 }
 
 // WorkDir ...
@@ -90,38 +169,112 @@ func Initialize() {
 	if err != nil {
 		panic(err)
 	}
-	err = os.Setenv("IPFS_PATH", DataDirIPFS())
-	if err != nil {
-		panic(err)
-	}
 }
 
-// LoadConfig ...
+// ConfigPath returns the config file of the work dir
+func ConfigPath() string {
+	return FilePath(WorkDir)
+}
+
+// FilePath returns the config file of a dir
+func FilePath(dir string) string {
+	return filepath.Join(dir, _configName+_configExt)
+}
+
+// LoadConfig reads the config of the work dir as the global config
 func LoadConfig() error {
-	viper.AddConfigPath(WorkDir)
-	viper.SetConfigName(_configName)
-	err := viper.MergeInConfig()
+	cfg, err := LoadDir(WorkDir)
 	if err != nil {
 		return err
 	}
-	m := extmap.ToMap(viper.AllSettings())
+	_config = cfg
+	return nil
+}
+
+// LoadDir reads the config of a dir, missing values are taken from the
+// defaults and files of older versions are migrated and saved again
+func LoadDir(dir string) (*Config, error) {
+	path := FilePath(dir)
+	v := viper.New()
+	v.SetConfigFile(path)
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+	file := v.AllSettings()
+	from, err := migrate(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m, err := defaultsMap(dir)
+	if err != nil {
+		return nil, err
+	}
+	mergeMap(m, file)
 	var cfg Config
-	err = m.Struct(&cfg)
+	err = extmap.ToMap(m).Struct(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	err = resolveSecrets(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.dir = dir
+	if from < Version {
+		if err := upgradeConfig(dir, from, &cfg); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+// upgradeConfig keeps the file of the old version beside the migrated one
+func upgradeConfig(dir string, from int, cfg *Config) error {
+	path := FilePath(dir)
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	_config = &cfg
-	return nil
+	err = ioutil.WriteFile(fmt.Sprintf("%s.bak-v%d", path, from), data, 0600)
+	if err != nil {
+		return err
+	}
+	return SaveDir(dir, cfg)
 }
 
-// SaveConfig ...
+// SaveConfig writes the config to the work dir and makes it the global config
 func SaveConfig(config *Config) error {
-	by, e := json.MarshalIndent(config, "", " ")
-	if e != nil {
+	if e := SaveDir(WorkDir, config); e != nil {
 		return e
 	}
+	if _config == nil {
+		_config = new(Config)
+	}
 	*_config = *config
-	return ioutil.WriteFile(filepath.Join(WorkDir, _configName+_configExt), by, 0755)
+	return nil
+}
+
+// SaveDir writes the config to a dir
+func SaveDir(dir string, config *Config) error {
+	c := *config
+	if e := protectSecrets(&c); e != nil {
+		return e
+	}
+	by, e := json.MarshalIndent(&c, "", " ")
+	if e != nil {
+		return e
+	}
+	path := FilePath(dir)
+	if e := ioutil.WriteFile(path, by, 0600); e != nil {
+		return e
+	}
+	// files written by older versions were readable by everyone
+	return os.Chmod(path, 0600)
 }
 
 // Global ...
@@ -134,10 +287,20 @@ func Global() Config {
 
 // Default ...
 func Default() *Config {
-	def := &Config{
+	def := DefaultWithPath(WorkDir)
+	if _config == nil {
+		_config = def
+	}
+	return def
+}
+
+// DefaultWithPath returns the default config of a node kept in path
+func DefaultWithPath(path string) *Config {
+	return &Config{
+		Version:    Version,
 		Port:       20304,
 		Schema:     "http",
-		Path:       WorkDir,
+		Path:       path,
 		Account:    "",
 		PrivateKey: "",
 		PublicKey:  "",
@@ -148,30 +311,101 @@ func Default() *Config {
 			TokenAddr: DefaultTokenContractAddr,
 		},
 		IPFS: IPFSConfig{
-			Name:    "ipfs",
-			Port:    5001,
-			Timeout: 30,
+			Name:        "ipfs",
+			Port:        5001,
+			GatewayPort: 8080,
+			Timeout:     30,
 		},
-		AWS:      AWSConfig{},
-		Interval: 30,
-		Limit:    500,
-	}
-	if _config == nil {
-		_config = def
+		AWS: AWSConfig{},
+		DNS: DNSConfig{
+			Provider:      "route53",
+			Routing:       "weighted",
+			TTL:           60,
+			HealthPort:    8545,
+			HealthTimeout: 3,
+			ListenTTL:     10,
+		},
+		Signer: SignerConfig{},
+		Record: RecordConfig{
+			Legacy: true,
+		},
+		Watch: WatchConfig{
+			Confirmations: 3,
+			Poll:          30,
+		},
+		Pin: PinConfig{
+			Replicas: 2,
+		},
+		Challenge: ChallengeConfig{
+			Interval:      300,
+			Timeout:       30,
+			MinReputation: -10,
+		},
+		GRPC: GRPCConfig{
+			Port: 20305,
+		},
+		Interval:     30,
+		Limit:        500,
+		LeaderPeriod: 600,
+		Schedule:     "0 1/3 * * * *",
 	}
-	return def
 }
 
-// Init ...
+// Init creates the data dirs of the config
 func (c *Config) Init() error {
-	err := os.Setenv("IPFS_PATH", filepath.Join(c.Path, _dataDirIPFS))
-	if err != nil {
-		return err
+	for _, dir := range []string{c.DataDirETH(), c.DataDirIPFS(), c.DataDirCache()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (c Config) rpcAddr() *url.URL {
+// Dir returns the dir the config was loaded from, the path of the node if it was not loaded
+func (c *Config) Dir() string {
+	if c.dir != "" {
+		return c.dir
+	}
+	return c.Path
+}
+
+// DataDirETH ...
+func (c *Config) DataDirETH() string {
+	return filepath.Join(c.Path, _dataDirETH)
+}
+
+// DataDirIPFS ...
+func (c *Config) DataDirIPFS() string {
+	return filepath.Join(c.Path, _dataDirIPFS)
+}
+
+// DataDirCache ...
+func (c *Config) DataDirCache() string {
+	return filepath.Join(c.Path, _dataDirCache)
+}
+
+// KeyDir ...
+func (c *Config) KeyDir() string {
+	return filepath.Join(c.Path, _keyDir)
+}
+
+// ETHAddr ...
+func (c *Config) ETHAddr() string {
+	return fmt.Sprintf(_ethGateway, c.ETH.Port)
+}
+
+// IPFSAddr ...
+func (c *Config) IPFSAddr() string {
+	return fmt.Sprintf(_ipfsGateway, c.IPFS.Port)
+}
+
+// IPFSEnv returns the environment of the ipfs commands of the node
+func (c *Config) IPFSEnv() []string {
+	return append(os.Environ(), "IPFS_PATH="+c.DataDirIPFS())
+}
+
+// RPCAddr ...
+func (c *Config) RPCAddr() *url.URL {
 	u := url.URL{
 		Scheme: c.Schema,
 		Path:   fmt.Sprintf("127.0.0.1:%d/rpc", c.Port),
@@ -187,42 +421,63 @@ func currentPath() string {
 	return dir
 }
 
-// DataDirETH ...
+// DataDirETH returns the eth dir of the global config
 func DataDirETH() string {
-	return filepath.Join(Global().Path, _dataDirETH)
+	cfg := Global()
+	return cfg.DataDirETH()
 }
 
-// KeyStoreDirETH ...
+// WatchEndpoint returns the geth endpoint that can subscribe, the ipc of the data dir if none is configured
+func WatchEndpoint(cfg *Config) string {
+	if cfg.Watch.Endpoint != "" {
+		return cfg.Watch.Endpoint
+	}
+	return filepath.Join(cfg.Path, _dataDirETH, "geth.ipc")
+}
+
+// KeyStoreDirETH returns the keystore dir of the global config
 func KeyStoreDirETH() string {
-	return filepath.Join(Global().Path, _dataDirETH, "keystore")
+	cfg := Global()
+	return KeyStoreDir(&cfg)
 }
 
-// DataDirIPFS ...
+// KeyStoreDir returns the keystore dir under the path of the config
+func KeyStoreDir(cfg *Config) string {
+	return filepath.Join(cfg.Path, _dataDirETH, "keystore")
+}
+
+// DataDirIPFS returns the ipfs dir of the global config
 func DataDirIPFS() string {
-	return filepath.Join(Global().Path, _dataDirIPFS)
+	cfg := Global()
+	return cfg.DataDirIPFS()
 }
 
-// DataDirCache ...
+// DataDirCache returns the cache dir of the global config
 func DataDirCache() string {
-	return filepath.Join(Global().Path, _dataDirCache)
+	cfg := Global()
+	return cfg.DataDirCache()
 }
 
-// KeyDir ...
+// KeyDir returns the key dir of the global config
 func KeyDir() string {
-	return filepath.Join(Global().Path, _keyDir)
+	cfg := Global()
+	return cfg.KeyDir()
 }
 
-// ETHAddr ...
+// ETHAddr returns the geth rpc address of the global config
 func ETHAddr() string {
-	return fmt.Sprintf(_ethGateway, Global().ETH.Port)
+	cfg := Global()
+	return cfg.ETHAddr()
 }
 
-// IPFSAddr ...
+// IPFSAddr returns the ipfs api address of the global config
 func IPFSAddr() string {
-	return fmt.Sprintf(_ipfsGateway, Global().IPFS.Port)
+	cfg := Global()
+	return cfg.IPFSAddr()
 }
 
-// RPCAddr ...
+// RPCAddr returns the rpc address of the global config
 func RPCAddr() *url.URL {
-	return Global().rpcAddr()
+	cfg := Global()
+	return cfg.RPCAddr()
 }
@@ -1,18 +1,184 @@
 package config
 
-import "testing"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tempWorkDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := WorkDir
+	WorkDir = dir
+	return func() {
+		WorkDir = old
+		os.RemoveAll(dir)
+	}
+}
 
 func TestLoadConfig(t *testing.T) {
-	t.Log(SaveConfig(&Config{
-		Path: "test",
-		ETH:  ETHConfig{},
-		IPFS: IPFSConfig{},
-	}))
+	defer tempWorkDir(t)()
+	cfg := Default()
+	cfg.Path = "test"
+	cfg.Port = 20305
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	v := Global()
+	if v.Path != "test" || v.Port != 20305 || v.Version != Version {
+		t.Fatalf("%+v", v)
+	}
+}
 
-	v, err := LoadConfig()
+func TestLoadConfigMigrate(t *testing.T) {
+	defer tempWorkDir(t)()
+	old := `{"port": 20306, "aws": {"record_name": "gw.example.com"}, "eth": {"dtag_addr": "0x9064322CfeE623A447ba5aF0dA6AD3341c073535"}}`
+	if err := ioutil.WriteFile(ConfigPath(), []byte(old), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	v := Global()
+	if v.Port != 20306 || v.DNS.RecordName != "gw.example.com" || v.ETH.DTagAddr == "" {
+		t.Fatalf("not migrated: %+v", v)
+	}
+	// defaults fill what the old file did not have
+	if v.ETH.NodeAddr != DefaultNodeContractAddr || v.Limit != 500 {
+		t.Fatalf("no defaults: %+v", v)
+	}
+	bak, err := ioutil.ReadFile(ConfigPath() + ".bak-v0")
+	if err != nil || string(bak) != old {
+		t.Fatalf("backup: %s %v", bak, err)
+	}
+	data, err := ioutil.ReadFile(ConfigPath())
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Version != Version {
+		t.Fatalf("saved version %d %v", saved.Version, err)
+	}
+}
+
+func TestLoadConfigNewer(t *testing.T) {
+	defer tempWorkDir(t)()
+	if err := ioutil.WriteFile(ConfigPath(), []byte(`{"version": 99}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("newer version loaded: %v", err)
 	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, provider := range []string{"file", "hosts"} {
+		c := Default()
+		c.DNS.Provider = provider
+		c.DNS.File = "hosts.txt"
+		if err := c.Validate(); err != nil {
+			t.Fatal(provider, err)
+		}
+	}
+	c := Default()
+	c.Port = 70000
+	c.ETH.TokenAddr = "0x1234"
+	c.Schema = "ftp"
+	c.DNS.Listen = "53"
+	c.Interval = 0
+	c.WSOrigins = []string{"https://viewer.example.com", "viewer.example.com"}
+	err := c.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 6 {
+		t.Fatalf("%v", err)
+	}
+	for _, key := range []string{"port:", "eth.token_addr:", "schema:", "dns.listen:", "interval:", "ws_origins:"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("%s not reported", key)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	c := Default()
+	for key, value := range map[string]string{
+		"watch.confirmations": "12",
+		"dns.provider":        "file",
+		"record.legacy":       "false",
+		"dns.regions":         `{"10.0.0.0/8": "eu"}`,
+	} {
+		if err := c.Set(key, value); err != nil {
+			t.Fatal(key, err)
+		}
+	}
+	if c.Watch.Confirmations != 12 || c.DNS.Provider != "file" || c.Record.Legacy || c.DNS.Regions["10.0.0.0/8"] != "eu" {
+		t.Fatalf("%+v", c)
+	}
+	if err := c.Set("watch.nothing", "1"); err == nil {
+		t.Fatal("unknown key set")
+	}
+	if err := c.Set("port", "many"); err == nil {
+		t.Fatal("text set as port")
+	}
+}
+
+func TestChanges(t *testing.T) {
+	old := Default()
+	next := Default()
+	next.Interval = 10
+	next.DNS.TTL = 30
+	next.DNS.Listen = ":53"
+	next.ETH.Port = 8546
+	live, restart := Changes(old, next)
+	if strings.Join(live, ",") != "dns.ttl,interval" || strings.Join(restart, ",") != "dns.listen,eth.port" {
+		t.Fatalf("live %v restart %v", live, restart)
+	}
+	c := Live(old, next)
+	if c.Interval != 10 || c.DNS.TTL != 30 || c.DNS.Listen != "" || c.ETH.Port != 8545 {
+		t.Fatalf("%+v", c)
+	}
+}
 
-	t.Logf("%+v", v)
+func TestLoadDir(t *testing.T) {
+	defer tempWorkDir(t)()
+	var dirs []string
+	for i := 0; i < 2; i++ {
+		dir := filepath.Join(WorkDir, fmt.Sprintf("node%d", i))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		cfg := DefaultWithPath(dir)
+		cfg.Port = 20310 + i
+		if err := SaveDir(dir, cfg); err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	for i, dir := range dirs {
+		cfg, err := LoadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Port != 20310+i || cfg.Dir() != dir || cfg.DataDirIPFS() != filepath.Join(dir, ".ipfs") {
+			t.Fatalf("%s: %+v", dir, cfg)
+		}
+	}
+	if _, err := os.Stat(ConfigPath()); !os.IsNotExist(err) {
+		t.Fatal("config written to the work dir")
+	}
 }
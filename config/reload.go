@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Live returns old with the values of next that a running daemon can apply
+func Live(old, next *Config) *Config {
+	c := *old
+	c.Interval = next.Interval
+	c.Limit = next.Limit
+	c.Schedule = next.Schedule
+	c.Peers = next.Peers
+	c.LogLevel = next.LogLevel
+	c.WSOrigins = next.WSOrigins
+	c.Pin = next.Pin
+	c.Challenge.Timeout = next.Challenge.Timeout
+	c.Challenge.MinReputation = next.Challenge.MinReputation
+	c.AWS = next.AWS
+	// the built-in dns server keeps its socket
+	listen, ttl := c.DNS.Listen, c.DNS.ListenTTL
+	c.DNS = next.DNS
+	c.DNS.Listen, c.DNS.ListenTTL = listen, ttl
+	return &c
+}
+
+// Changes returns the dotted keys that differ between the configs, split into
+// the ones Live applies and the ones that need a restart
+func Changes(old, next *Config) (live []string, restart []string) {
+	all := diffKeys(old, next)
+	still := make(map[string]bool)
+	for _, key := range diffKeys(Live(old, next), next) {
+		still[key] = true
+		restart = append(restart, key)
+	}
+	for _, key := range all {
+		if !still[key] {
+			live = append(live, key)
+		}
+	}
+	return live, restart
+}
+
+func diffKeys(a, b *Config) []string {
+	ma, mb := flatten(a), flatten(b)
+	var keys []string
+	for k, v := range ma {
+		if w, found := mb[k]; !found || !reflect.DeepEqual(v, w) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range mb {
+		if _, found := ma[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flatten returns the json values of the config by dotted key, lists and
+// maps below the sections are kept whole
+func flatten(c *Config) map[string]interface{} {
+	m := make(map[string]interface{})
+	data, err := json.Marshal(c)
+	if err != nil {
+		return m
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return m
+	}
+	for k, v := range raw {
+		section, b := v.(map[string]interface{})
+		if !b {
+			m[k] = v
+			continue
+		}
+		for sk, sv := range section {
+			m[k+"."+sk] = sv
+		}
+	}
+	return m
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/glvd/accipfs/secret"
+	"github.com/robfig/cron/v3"
+)
+
+// Version is the schema version of the config written by this build
+const Version = 1
+
+// migrations[v] turns a config of version v into version v+1
+var migrations = []func(m map[string]interface{}){
+	// 0: files without a version, the gateway name moved from aws to dns
+	func(m map[string]interface{}) {
+		aws, _ := m["aws"].(map[string]interface{})
+		dns, _ := m["dns"].(map[string]interface{})
+		name, _ := aws["record_name"].(string)
+		if name == "" {
+			return
+		}
+		if dns == nil {
+			dns = make(map[string]interface{})
+			m["dns"] = dns
+		}
+		if v, _ := dns["record_name"].(string); v == "" {
+			dns["record_name"] = name
+		}
+	},
+}
+
+// schemaVersion returns the version of a raw config
+func schemaVersion(m map[string]interface{}) (int, error) {
+	switch v := m["version"].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		return strconv.Atoi(v)
+	}
+	return 0, fmt.Errorf("config version %v is not a number", m["version"])
+}
+
+// migrate upgrades a raw config to the current version, it returns the version it was read with
+func migrate(m map[string]interface{}) (int, error) {
+	from, err := schemaVersion(m)
+	if err != nil {
+		return 0, err
+	}
+	if from > Version {
+		return from, fmt.Errorf("config version %d is newer than this build (%d)", from, Version)
+	}
+	for v := from; v < Version; v++ {
+		migrations[v](m)
+	}
+	m["version"] = Version
+	return from, nil
+}
+
+// defaultsMap returns the default config of a dir as a raw map
+func defaultsMap(dir string) (map[string]interface{}, error) {
+	data, err := json.Marshal(DefaultWithPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	return m, json.Unmarshal(data, &m)
+}
+
+// mergeMap sets the values of src on dst, nested maps are merged
+func mergeMap(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sv, b1 := v.(map[string]interface{})
+		dv, b2 := dst[k].(map[string]interface{})
+		if b1 && b2 {
+			mergeMap(dv, sv)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// ValidationError lists every problem of a config
+type ValidationError struct {
+	Problems []string
+}
+
+// Error ...
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) port(key string, port int) {
+	v.check(port > 0 && port < 65536, "%s: port %d is not between 1 and 65535", key, port)
+}
+
+func (v *validator) address(key string, addr string, required bool) {
+	if addr == "" {
+		v.check(!required, "%s: contract address is required", key)
+		return
+	}
+	_, err := hex.DecodeString(strings.TrimPrefix(addr, "0x"))
+	v.check(strings.HasPrefix(addr, "0x") && len(addr) == 42 && err == nil, "%s: %q is not a hex address (0x and 40 hex digits)", key, addr)
+}
+
+func (v *validator) positive(key string, n int64) {
+	v.check(n > 0, "%s: %d must be greater than 0", key, n)
+}
+
+func (v *validator) oneOf(key string, value string, values ...string) {
+	for _, s := range values {
+		if value == s {
+			return
+		}
+	}
+	v.check(false, "%s: %q is not one of %s", key, value, strings.Join(values, ", "))
+}
+
+// Validate checks the values of the config, all problems are reported at once
+func (c *Config) Validate() error {
+	v := &validator{}
+	v.check(c.Version <= Version, "version: %d is newer than this build (%d)", c.Version, Version)
+	v.port("port", c.Port)
+	v.oneOf("schema", c.Schema, "http", "https")
+	v.port("eth.port", c.ETH.Port)
+	v.address("eth.node_addr", c.ETH.NodeAddr, true)
+	v.address("eth.token_addr", c.ETH.TokenAddr, true)
+	v.address("eth.message_addr", c.ETH.MessageAddr, false)
+	v.address("eth.dtag_addr", c.ETH.DTagAddr, false)
+	v.port("ipfs.port", c.IPFS.Port)
+	v.port("ipfs.gateway_port", c.IPFS.GatewayPort)
+	v.positive("ipfs.timeout", int64(c.IPFS.Timeout))
+	v.oneOf("dns.provider", c.DNS.Provider, "", "route53", "rfc2136", "file", "hosts")
+	v.oneOf("dns.routing", c.DNS.Routing, "", "weighted", "latency", "multivalue")
+	v.oneOf("dns.net", c.DNS.Net, "", "udp", "tcp")
+	if c.DNS.Provider == "rfc2136" {
+		v.check(c.DNS.Server != "", "dns.server: required by the rfc2136 provider")
+	}
+	if c.DNS.Provider == "file" || c.DNS.Provider == "hosts" {
+		v.check(c.DNS.File != "", "dns.file: required by the file provider")
+	}
+	if c.DNS.HealthPort != 0 {
+		v.port("dns.health_port", c.DNS.HealthPort)
+	}
+	if c.GRPC.Enable {
+		v.port("grpc.port", c.GRPC.Port)
+		v.check(c.GRPC.Port != c.Port, "grpc.port: %d is the rpc port", c.GRPC.Port)
+	}
+	if c.DNS.Listen != "" {
+		_, _, err := net.SplitHostPort(c.DNS.Listen)
+		v.check(err == nil, "dns.listen: %q is not host:port", c.DNS.Listen)
+	}
+	for cidr := range c.DNS.Regions {
+		_, _, err := net.ParseCIDR(cidr)
+		v.check(err == nil, "dns.regions: %q is not a cidr", cidr)
+	}
+	v.positive("watch.poll", c.Watch.Poll)
+	v.check(c.Pin.Replicas >= 0, "pin.replicas: %d must not be negative", c.Pin.Replicas)
+	v.check(c.Challenge.Interval >= 0, "challenge.interval: %d must not be negative", c.Challenge.Interval)
+	if c.Challenge.Interval > 0 {
+		v.positive("challenge.timeout", c.Challenge.Timeout)
+	}
+	v.oneOf("secrets.backend", c.Secrets.Backend, "", "file", "dir")
+	v.positive("interval", c.Interval)
+	v.positive("limit", c.Limit)
+	v.positive("leader_period", c.LeaderPeriod)
+	_, err := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor).Parse(c.Schedule)
+	v.check(err == nil, "schedule: %q is not a cron spec with seconds", c.Schedule)
+	for _, peer := range c.Peers {
+		_, _, err := net.SplitHostPort(peer)
+		v.check(err == nil, "peers: %q is not host:port", peer)
+	}
+	for _, origin := range c.WSOrigins {
+		u, err := url.Parse(origin)
+		v.check(err == nil && u.Scheme != "" && u.Host != "" && strings.TrimSuffix(u.Path, "/") == "", "ws_origins: %q is not scheme://host[:port]", origin)
+	}
+	v.oneOf("log_level", c.LogLevel, "", "debug", "info", "warn", "error", "dpanic", "panic", "fatal")
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// Set changes the value of a dotted json key (watch.confirmations), the
+// value is parsed as the type of the current value
+func (c *Config) Set(key string, value string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	path := strings.Split(key, ".")
+	parent := m
+	for _, k := range path[:len(path)-1] {
+		next, b := parent[k].(map[string]interface{})
+		if !b {
+			return fmt.Errorf("unknown config key %s", key)
+		}
+		parent = next
+	}
+	last := path[len(path)-1]
+	old, b := parent[last]
+	if !b {
+		return fmt.Errorf("unknown config key %s", key)
+	}
+	switch old.(type) {
+	case string:
+		parent[last] = value
+	case float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", key, value)
+		}
+		parent[last] = n
+	case bool:
+		bv, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		parent[last] = bv
+	default:
+		var raw interface{}
+		if err := json.Unmarshal([]byte(value), &raw); err != nil {
+			return fmt.Errorf("%s: %q is not json", key, value)
+		}
+		parent[last] = raw
+	}
+	data, err = json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var updated Config
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	updated.saved, updated.dir = c.saved, c.dir
+	*c = updated
+	return nil
+}
+
+// Masked returns a copy of the config without the secret values
+func (c Config) Masked() Config {
+	for _, field := range SecretFields(&c) {
+		if _, b := secret.ParseRef(*field); *field != "" && !b {
+			*field = "******"
+		}
+	}
+	return c
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/glvd/accipfs/secret"
+)
+
+const _secretsFile = "secrets.json"
+const _secretsDir = "secrets"
+
+// DefaultPassphraseEnv holds the master passphrase of the secrets file when no env is configured
+const DefaultPassphraseEnv = "ACCIPFS_SECRETS_PASSPHRASE"
+
+// ErrNoSecretsBackend ...
+var ErrNoSecretsBackend = errors.New("no secrets backend is set")
+
+// PromptPassphrase asks for the master passphrase when neither the file nor the env has it,
+// it is set by the commands that run on a terminal
+var PromptPassphrase func() (string, error)
+
+// SecretsConfig ...
+type SecretsConfig struct {
+	Backend        string `json:"backend" mapstructure:"backend"`                 //file (encrypted) or dir, secrets stay in the config if empty
+	Path           string `json:"path" mapstructure:"path"`                       //secrets file or dir, secrets.json or secrets of the work path if empty
+	PassphraseFile string `json:"passphrase_file" mapstructure:"passphrase_file"` //file with the master passphrase of the secrets file
+	PassphraseEnv  string `json:"passphrase_env" mapstructure:"passphrase_env"`   //env variable with the master passphrase
+}
+
+var secretStores = struct {
+	sync.Mutex
+	m map[string]secret.Store
+}{m: make(map[string]secret.Store)}
+
+// SecretFields returns the config values that are kept in the secrets store by their id
+func SecretFields(c *Config) map[string]*string {
+	return map[string]*string{
+		"account":               &c.Account,
+		"private_key":           &c.PrivateKey,
+		"public_key":            &c.PublicKey,
+		"aws_access_key_id":     &c.AWS.AwsAccessKeyID,
+		"aws_secret_access_key": &c.AWS.AwsSecretAccessKey,
+		"record_key":            &c.Record.Key,
+	}
+}
+
+func (c *Config) passphrase() (string, error) {
+	if c.Secrets.PassphraseFile != "" {
+		data, err := ioutil.ReadFile(c.Secrets.PassphraseFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+	env := c.Secrets.PassphraseEnv
+	if env == "" {
+		env = DefaultPassphraseEnv
+	}
+	if pass, b := os.LookupEnv(env); b {
+		return pass, nil
+	}
+	if PromptPassphrase != nil {
+		return PromptPassphrase()
+	}
+	return "", fmt.Errorf("no secrets passphrase, set %s or secrets.passphrase_file", env)
+}
+
+// SecretStore opens the secrets store of the config, it is nil when secrets are kept in the config
+func SecretStore(c *Config) (secret.Store, error) {
+	path := c.Secrets.Path
+	switch c.Secrets.Backend {
+	case "":
+		return nil, nil
+	case "file":
+		if path == "" {
+			path = filepath.Join(c.Path, _secretsFile)
+		}
+	case "dir":
+		if path == "" {
+			path = filepath.Join(c.Path, _secretsDir)
+		}
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q", c.Secrets.Backend)
+	}
+	secretStores.Lock()
+	defer secretStores.Unlock()
+	key := c.Secrets.Backend + ":" + path
+	if store, b := secretStores.m[key]; b {
+		return store, nil
+	}
+	var store secret.Store
+	if c.Secrets.Backend == "file" {
+		store = secret.NewFileStore(path, c.passphrase)
+	} else {
+		store = secret.DirStore(path)
+	}
+	secretStores.m[key] = store
+	return store, nil
+}
+
+// resolveSecrets replaces the secret references with their values, an
+// environment override wins over the config and the store
+func resolveSecrets(c *Config) error {
+	var store secret.Store
+	for id, field := range SecretFields(c) {
+		if v, b := secret.Env(id); b {
+			if c.saved == nil {
+				c.saved = make(map[string]string)
+			}
+			// the config keeps what it had, the override is not saved
+			c.saved[id] = *field
+			*field = v
+			continue
+		}
+		ref, b := secret.ParseRef(*field)
+		if !b {
+			continue
+		}
+		if store == nil {
+			var err error
+			if store, err = SecretStore(c); err != nil {
+				return err
+			}
+			if store == nil {
+				return fmt.Errorf("config refers to secret %s: %w", ref, ErrNoSecretsBackend)
+			}
+		}
+		v, err := store.Get(ref)
+		if err != nil {
+			return fmt.Errorf("load secret %s: %w", ref, err)
+		}
+		*field = v
+	}
+	return nil
+}
+
+// protectSecrets moves the secret values into the store and leaves references in the config
+func protectSecrets(c *Config) error {
+	store, err := SecretStore(c)
+	if err != nil {
+		return err
+	}
+	for id, field := range SecretFields(c) {
+		if v, b := c.saved[id]; b {
+			*field = v
+			continue
+		}
+		if store == nil || *field == "" {
+			continue
+		}
+		if _, b := secret.ParseRef(*field); b {
+			continue
+		}
+		if err := store.Set(id, *field); err != nil {
+			return fmt.Errorf("save secret %s: %w", id, err)
+		}
+		*field = secret.Ref(id)
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/glvd/accipfs/account"
 	"github.com/glvd/accipfs/config"
+	"github.com/goextension/tool"
 	"github.com/spf13/cobra"
 	"io/ioutil"
 )
@@ -15,7 +17,8 @@ func accountCmd() *cobra.Command {
 		Short: "Account info",
 		Long:  "Account show the information with your account",
 	}
-	cmd.AddCommand(accountInfoCmd(), accountSaveCmd())
+	cmd.AddCommand(accountInfoCmd(), accountExportCmd(), accountImportCmd(), accountBalanceCmd(), accountTransferCmd(),
+		accountApproveCmd(), accountAllowanceCmd(), accountHistoryCmd())
 	return cmd
 }
 
@@ -41,29 +44,114 @@ func accountInfoCmd() *cobra.Command {
 	}
 }
 
-func accountSaveCmd() *cobra.Command {
-	var path string
+// readPassphrase reads the passphrase from the file or asks for it on the terminal
+func readPassphrase(file string, name string, confirm bool) (string, error) {
+	if file != "" {
+		return account.PasswordFile(file).Password()
+	}
+	pass, err := account.PasswordPrompt(name).Password()
+	if err != nil || !confirm {
+		return pass, err
+	}
+	again, err := account.PasswordPrompt(name + " (again)").Password()
+	if err != nil {
+		return "", err
+	}
+	if pass != again {
+		return "", errors.New("passwords do not match")
+	}
+	return pass, nil
+}
+
+func accountExportCmd() *cobra.Command {
+	var path, passphraseFile string
 	cmd := &cobra.Command{
-		Use:   "save",
-		Short: "save account to file",
-		Long:  "save your account to a file for backup your account info",
+		Use:     "export",
+		Aliases: []string{"save"},
+		Short:   "export the account to an encrypted backup",
+		Long:    "export writes the account and config to a backup file encrypted with a passphrase, init --restore rebuilds the node from it",
 		Run: func(cmd *cobra.Command, args []string) {
 			config.Initialize()
 			cfg := config.Global()
 			loadAccount, err := account.LoadAccount(&cfg)
 			if err != nil {
-				panic(err)
+				fmt.Println("export error:", err)
+				return
 			}
-			indent, err := json.MarshalIndent(loadAccount, "", " ")
+			pass, err := readPassphrase(passphraseFile, "the backup", true)
 			if err != nil {
-				panic(err)
+				fmt.Println("export error:", err)
+				return
+			}
+			data, err := account.ExportBackup(&cfg, loadAccount, pass)
+			if err != nil {
+				fmt.Println("export error:", err)
+				return
+			}
+			err = ioutil.WriteFile(path, data, 0600)
+			if err != nil {
+				fmt.Println("export error:", err)
+				return
+			}
+			fmt.Println("account", loadAccount.Name, "exported to", path)
+		},
+	}
+	cmd.Flags().StringVar(&path, "path", "account.backup", "backup file")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "read the backup passphrase from a file instead of the terminal")
+	return cmd
+}
+
+func accountImportCmd() *cobra.Command {
+	var key bool
+	var passwordFile string
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "import an account",
+		Long:  "import replaces the node account with a backup made by export, a geth keystore file or a raw hex private key (--key)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			cfg := config.Global()
+			data, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				fmt.Println("import error:", err)
+				return
+			}
+			var acc *account.Account
+			switch {
+			case account.IsBackup(data):
+				var pass string
+				if pass, err = readPassphrase(passwordFile, "the backup", false); err == nil {
+					// only the account is taken, the config of this node is kept
+					acc, _, err = account.ImportBackup(data, pass)
+				}
+			case key:
+				// like a new account the key is encrypted with a random password
+				pass := tool.GenerateRandomString(8)
+				if passwordFile != "" {
+					pass, err = account.PasswordFile(passwordFile).Password()
+				}
+				if err == nil {
+					acc, err = account.ImportKey(&cfg, string(data), pass)
+				}
+			default:
+				var pass string
+				if pass, err = readPassphrase(passwordFile, "the keystore", false); err == nil {
+					acc, err = account.ImportKeyStore(&cfg, data, pass)
+				}
 			}
-			err = ioutil.WriteFile(path, indent, 0755)
 			if err != nil {
+				fmt.Println("import error:", err)
+				return
+			}
+			if err := acc.Save(&cfg); err != nil {
+				fmt.Println("import error:", err)
 				return
 			}
+			fmt.Println("account", acc.Name, "imported")
 		},
 	}
-	cmd.Flags().StringVar(&path, "path", "account.json", "save account for backup")
+	cmd.Flags().BoolVar(&key, "key", false, "the file holds a hex private key")
+	cmd.Flags().StringVar(&passwordFile, "password-file", "", "read the keystore password or backup passphrase from a file instead of the terminal")
 	return cmd
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/config"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "check and change config.json",
+		Long:  "config validates, shows and changes the config of the work path",
+	}
+	cmd.AddCommand(configValidateCmd(), configShowCmd(), configSetCmd(), configReloadCmd())
+	return cmd
+}
+
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "check the values of config.json",
+		Long:  "validate loads config.json, migrates it to the current version and reports every invalid value",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := config.LoadConfig(); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s is valid (version %d)\n", config.ConfigPath(), config.Version)
+		},
+	}
+}
+
+func configShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "print the config with the defaults filled in",
+		Long:  "show prints the config as it is used by the node, secret values are masked",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := config.LoadConfig(); err != nil {
+				fmt.Println("show error:", err)
+				return
+			}
+			cfg := config.Global().Masked()
+			data, err := json.MarshalIndent(&cfg, "", " ")
+			if err != nil {
+				fmt.Println("show error:", err)
+				return
+			}
+			fmt.Println(string(data))
+		},
+	}
+}
+
+func configSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "change one value of config.json",
+		Long:  "set changes the value of a dotted key like watch.confirmations, the config is validated before it is saved",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := config.LoadConfig(); err != nil {
+				fmt.Println("set error:", err)
+				return
+			}
+			cfg := config.Global()
+			if err := cfg.Set(args[0], args[1]); err != nil {
+				fmt.Println("set error:", err)
+				return
+			}
+			if err := cfg.Validate(); err != nil {
+				fmt.Println("set error:", err)
+				return
+			}
+			if err := config.SaveConfig(&cfg); err != nil {
+				fmt.Println("set error:", err)
+				return
+			}
+			fmt.Println(args[0], "=", args[1])
+		},
+	}
+}
+
+func configReloadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reload",
+		Short: "make the running daemon read config.json again",
+		Long:  "reload applies the changed values the daemon can change live and lists the ones that need a restart",
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			changes, err := client.ReloadConfig(config.RPCAddr().String())
+			if err != nil {
+				fmt.Println("reload error:", err)
+				return
+			}
+			fmt.Println("applied:", changes.Applied)
+			if len(changes.Restart) > 0 {
+				fmt.Println("restart the daemon to apply:", changes.Restart)
+			}
+		},
+	}
+}
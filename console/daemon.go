@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/glvd/accipfs"
 	"github.com/glvd/accipfs/config"
 	"github.com/glvd/accipfs/service"
 	"github.com/spf13/cobra"
@@ -14,10 +15,18 @@ func daemonCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			config.Initialize()
 			cfg := config.Global()
+			if cfg.LogLevel != "" {
+				accipfs.SetLogLevel(cfg.LogLevel)
+			}
 			s, e := service.NewRPCServer(&cfg)
 			if e != nil {
 				panic(e)
 			}
+			s.OnReload(func(cfg *config.Config) {
+				if cfg.LogLevel != "" {
+					accipfs.SetLogLevel(cfg.LogLevel)
+				}
+			})
 			defer func() {
 				if err := s.Stop(); err != nil {
 					panic(err)
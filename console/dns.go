@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/dns"
+	"github.com/spf13/cobra"
+)
+
+func dnsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "dns gateway records",
+		Long:  "dns shows how the gateway records are published",
+	}
+	cmd.AddCommand(dnsPlanCmd())
+	return cmd
+}
+
+func dnsPlanCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "plan [enode...]",
+		Short: "print the planned record changes",
+		Long:  "plan measures the given nodes and prints the record changes without applying them",
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			cfg := config.Global()
+			syncer, err := dns.NewSyncer(&cfg)
+			if err != nil {
+				fmt.Println("dns error:", err)
+				return
+			}
+			var nodes []dns.Node
+			for _, arg := range args {
+				node, err := dns.NodeFromEnode(arg)
+				if err != nil {
+					fmt.Println("skip:", err)
+					continue
+				}
+				nodes = append(nodes, node)
+			}
+			changes, err := syncer.Plan(context.Background(), nodes)
+			if err != nil {
+				fmt.Println("plan error:", err)
+				return
+			}
+			fmt.Printf("%s %s (dry run)\n", syncer.Provider.Name(), syncer.Name)
+			fmt.Println(changes)
+		},
+	}
+}
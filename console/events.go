@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/core"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func eventsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events [topic...]",
+		Short: "follow the events of the daemon",
+		Long:  "events prints the events of the running daemon as they happen, the topics are " + strings.Join(core.Topics, ", "),
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			go func() {
+				<-interrupt
+				cancel()
+			}()
+			sub, err := client.New(config.RPCAddr().String()).Subscribe(ctx, args...)
+			if err != nil {
+				fmt.Println("events error:", err)
+				return
+			}
+			for n := range sub.C {
+				fmt.Println(time.Unix(n.Time, 0).Format(time.RFC3339), n.Topic, string(n.Data))
+			}
+			if err := sub.Err(); err != nil {
+				fmt.Println("events error:", err)
+			}
+		},
+	}
+	return cmd
+}
@@ -5,16 +5,34 @@ import (
 	"github.com/glvd/accipfs/config"
 	"github.com/glvd/accipfs/service"
 	"github.com/spf13/cobra"
+	"io/ioutil"
 )
 
 func initCmd() *cobra.Command {
-	var restore string
+	var restore, passphraseFile string
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "init run",
 		Long:  "init will create the config file with a default settings",
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg := config.Default()
+			var acc *account.Account
+			if restore != "" {
+				data, err := ioutil.ReadFile(restore)
+				if err != nil {
+					panic(err)
+				}
+				pass, err := readPassphrase(passphraseFile, "the backup", false)
+				if err != nil {
+					panic(err)
+				}
+				restored, restoredCfg, err := account.ImportBackup(data, pass)
+				if err != nil {
+					panic(err)
+				}
+				restoredCfg.Path = cfg.Path
+				cfg, acc = restoredCfg, restored
+			}
 			if err := cfg.Init(); err != nil {
 				panic(err)
 			}
@@ -26,17 +44,21 @@ func initCmd() *cobra.Command {
 			if err := eth.Init(); err != nil {
 				panic(err)
 			}
-			acc, err := account.NewAccount(cfg)
-			if err != nil {
-				panic(err)
+			if acc == nil {
+				var err error
+				acc, err = account.NewAccount(cfg)
+				if err != nil {
+					panic(err)
+				}
 			}
-			err = acc.Save(cfg)
+			err := acc.Save(cfg)
 			if err != nil {
 				panic(err)
 			}
 
 		},
 	}
-	cmd.Flags().StringVar(&restore, "restore", "", "init from a account file")
+	cmd.Flags().StringVar(&restore, "restore", "", "init from a backup made by account export")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "read the backup passphrase from a file instead of the terminal")
 	return cmd
 }
@@ -2,20 +2,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/glvd/accipfs"
+	"github.com/glvd/accipfs/account"
+	"github.com/glvd/accipfs/client"
 	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/core"
 	"github.com/glvd/accipfs/general"
 	"github.com/spf13/cobra"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // APP ...
 const APP = "accipfs"
 
-// Version ...
-const Version = "0.0.1"
-
 var rootCmd = &cobra.Command{
 	Use:   APP,
 	Short: "accipfs is a very fast ipfs client",
@@ -30,8 +33,9 @@ func main() {
 		path = general.CurrentDir()
 	}
 	config.WorkDir = path
+	config.PromptPassphrase = account.PasswordPrompt("the secrets store").Password
 
-	rootCmd.AddCommand(initCmd(), daemonCmd(), idCmd(), nodeCmd(), versionCmd(), tagCmd(), pinCmd(), addCmd(), accountCmd())
+	rootCmd.AddCommand(initCmd(), daemonCmd(), idCmd(), nodeCmd(), versionCmd(), tagCmd(), pinCmd(), addCmd(), accountCmd(), dnsCmd(), repoCmd(), statsCmd(), secretsCmd(), configCmd(), eventsCmd())
 	rootCmd.PersistentFlags().StringVar(&accipfs.DefaultPath, "path", ".", "set work path")
 
 	rootCmd.PersistentFlags().StringVar(&accipfs.LogOutput, "log-output", "stderr", "set the output log name")
@@ -45,13 +49,36 @@ func main() {
 }
 
 func versionCmd() *cobra.Command {
+	var remote string
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print the version number of " + APP,
 		Long:  `All software has versions. This is ` + APP + `'s`,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(APP, "version:", Version)
+			fmt.Println(APP, "version:", core.Build())
+			if remote == "" {
+				return
+			}
+			url := remote
+			if !strings.Contains(url, "://") {
+				url = fmt.Sprintf("http://%s/rpc", remote)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			info, err := client.New(url).Version(ctx)
+			if err != nil {
+				fmt.Println("remote version error:", err)
+				return
+			}
+			fmt.Println(remote, "version:", info)
+			if len(info.Capabilities) > 0 {
+				fmt.Println(remote, "capabilities:", strings.Join(info.Capabilities, ", "))
+			}
+			if err := core.Compatible(&core.NodeInfo{Name: remote, Protocol: info.ProtocolVersion, MinProtocol: info.MinProtocolVersion}); err != nil {
+				fmt.Println("incompatible:", err)
+			}
 		},
 	}
+	cmd.Flags().StringVar(&remote, "remote", "", "also print the version of the node at this rpc address (host:port or url)")
 	return cmd
 }
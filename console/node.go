@@ -1,11 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/glvd/accipfs/client"
 	"github.com/glvd/accipfs/config"
-	"github.com/glvd/accipfs/core"
-	"github.com/glvd/accipfs/general"
 	"github.com/spf13/cobra"
 )
 
@@ -28,12 +27,13 @@ func nodeConnectCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			config.Initialize()
 			url := config.RPCAddr()
+			c := client.New(url.String())
 
 			for _, addr := range args {
 				fmt.Printf("connect to [%s]\n", url.String())
 
-				remoteNodeInfo := new(core.NodeInfo)
-				if err := general.RPCPost(url.String(), "Accelerate.ConnectTo", addr, remoteNodeInfo); err != nil {
+				remoteNodeInfo, err := c.ConnectTo(context.Background(), addr)
+				if err != nil {
 					fmt.Println("connect error:", err)
 					return
 				}
@@ -59,11 +59,11 @@ func nodePeerCmd() *cobra.Command {
 			config.Initialize()
 			cfg := config.Global()
 			url := fmt.Sprintf("http://localhost:%d/rpc", cfg.Port)
-			reply := new([]*core.NodeInfo)
-			if err := general.RPCPost(url, "Accelerate.Peers", &core.Empty{}, reply); err != nil {
+			reply, err := client.New(url).Peers(context.Background())
+			if err != nil {
 				fmt.Println("peers error:", err.Error())
 			}
-			for _, info := range *reply {
+			for _, info := range reply {
 				fmt.Println("Peer:", info.Name)
 			}
 			return
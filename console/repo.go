@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/config"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+func repoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repo <address>",
+		Short: "list the repo of a user",
+		Long:  "repo lists the hashes a user paid to pin and whether this node serves them",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			entries, err := client.UserRepo(config.RPCAddr().String(), args[0])
+			if err != nil {
+				fmt.Println("repo error:", err)
+				return
+			}
+			fmt.Printf("%s: %d hashes\n", args[0], len(entries))
+			for _, entry := range entries {
+				if entry.Proof == nil {
+					fmt.Println(entry.Hash, "-")
+					continue
+				}
+				fmt.Println(entry.Hash, "pinned", time.Unix(entry.Proof.PinnedAt, 0).Format(time.RFC3339), "block", entry.Proof.Block)
+			}
+		},
+	}
+}
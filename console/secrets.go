@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/secret"
+	"github.com/spf13/cobra"
+	"sort"
+)
+
+func secretsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "manage the secrets store",
+		Long:  "secrets keeps the account, keys and credentials out of config.json",
+	}
+	cmd.AddCommand(secretsMigrateCmd(), secretsListCmd())
+	return cmd
+}
+
+func secretsMigrateCmd() *cobra.Command {
+	var backend string
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "move the secrets of config.json into the secrets store",
+		Long:  "migrate moves the secrets kept in config.json into the secrets store and leaves references in the config",
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			cfg := config.Global()
+			if cfg.Secrets.Backend == "" {
+				cfg.Secrets.Backend = backend
+			}
+			var moved []string
+			for id, field := range config.SecretFields(&cfg) {
+				if _, b := secret.Env(id); b {
+					continue
+				}
+				if *field != "" {
+					moved = append(moved, id)
+				}
+			}
+			sort.Strings(moved)
+			if err := config.SaveConfig(&cfg); err != nil {
+				fmt.Println("migrate error:", err)
+				return
+			}
+			fmt.Println("secrets kept in the", cfg.Secrets.Backend, "store:", moved)
+		},
+	}
+	cmd.Flags().StringVar(&backend, "backend", "file", "store used when none is configured: file (encrypted) or dir")
+	return cmd
+}
+
+func secretsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the ids in the secrets store",
+		Long:  "list shows the ids in the secrets store and the environment variables that override them",
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			cfg := config.Global()
+			store, err := config.SecretStore(&cfg)
+			if err != nil {
+				fmt.Println("list error:", err)
+				return
+			}
+			if store == nil {
+				fmt.Println("list error:", config.ErrNoSecretsBackend)
+				return
+			}
+			ids, err := store.List()
+			if err != nil {
+				fmt.Println("list error:", err)
+				return
+			}
+			for _, id := range ids {
+				fmt.Println(id, secret.EnvName(id))
+			}
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/core"
+	"github.com/spf13/cobra"
+	"sort"
+)
+
+func statsCmd() *cobra.Command {
+	var top int
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "show the bandwidth of the node",
+		Long:  "stats shows the bytes the node served and fetched by peer and by video number",
+		Run: func(cmd *cobra.Command, args []string) {
+			config.Initialize()
+			bw, err := client.Bandwidth(config.RPCAddr().String())
+			if err != nil {
+				fmt.Println("stats error:", err)
+				return
+			}
+			fmt.Println("Peers:")
+			printTraffic(bw.Peers, top)
+			fmt.Println("Videos:")
+			printTraffic(bw.Videos, top)
+		},
+	}
+	cmd.Flags().IntVar(&top, "top", 20, "entries shown in each list, all when 0")
+	return cmd
+}
+
+// printTraffic lists the counters with the most bytes served first
+func printTraffic(counters map[string]*core.TrafficCounter, top int) {
+	var keys []string
+	for key := range counters {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return counters[keys[i]].Total.Out > counters[keys[j]].Total.Out
+	})
+	if top > 0 && len(keys) > top {
+		keys = keys[:top]
+	}
+	for _, key := range keys {
+		c := counters[key]
+		recent := c.Recent()
+		fmt.Printf("  %s served %s fetched %s (last %d days: served %s fetched %s)\n", key,
+			formatBytes(c.Total.Out), formatBytes(c.Total.In), len(c.Days), formatBytes(recent.Out), formatBytes(recent.In))
+	}
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
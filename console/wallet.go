@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/glvd/accipfs/account"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/general"
+	"github.com/spf13/cobra"
+)
+
+// walletAccount loads the wallet of the node account
+func walletAccount() (*config.Config, account.Signer, *contract.Wallet, error) {
+	config.Initialize()
+	cfg := config.Global()
+	signer, err := account.NewSigner(&cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &cfg, signer, contract.NewWallet(&cfg, contract.Loader(&cfg, contract.NewTxManager(signer))), nil
+}
+
+func parseAddress(s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address: %s", s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+func accountBalanceCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "balance [address]",
+		Short: "show the token balance",
+		Long:  "balance shows the token balance of an address, the node account by default",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			_, signer, wallet, err := walletAccount()
+			if err != nil {
+				fmt.Println("balance error:", err)
+				return
+			}
+			owner := signer.Address()
+			if len(args) > 0 {
+				if owner, err = parseAddress(args[0]); err != nil {
+					fmt.Println("balance error:", err)
+					return
+				}
+			}
+			ctx := context.Background()
+			info, err := wallet.Info(ctx)
+			if err != nil {
+				fmt.Println("balance error:", err)
+				return
+			}
+			balance, err := wallet.Balance(ctx, owner)
+			if err != nil {
+				fmt.Println("balance error:", err)
+				return
+			}
+			fmt.Println(owner.Hex(), general.FormatAmount(balance, info.Decimals), info.Symbol)
+		},
+	}
+}
+
+func accountTransferCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "transfer <to> <amount>",
+		Short: "send tokens from the node account",
+		Long:  "transfer sends an amount of tokens from the node account, the amount uses the token decimals",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			to, err := parseAddress(args[0])
+			if err != nil {
+				fmt.Println("transfer error:", err)
+				return
+			}
+			_, _, wallet, err := walletAccount()
+			if err != nil {
+				fmt.Println("transfer error:", err)
+				return
+			}
+			info, err := wallet.Info(context.Background())
+			if err != nil {
+				fmt.Println("transfer error:", err)
+				return
+			}
+			amount, err := general.ParseAmount(args[1], info.Decimals)
+			if err != nil {
+				fmt.Println("transfer error:", err)
+				return
+			}
+			receipt, err := wallet.Transfer(to, amount)
+			if err != nil {
+				fmt.Println("transfer error:", err)
+				return
+			}
+			fmt.Println("transferred", general.FormatAmount(amount, info.Decimals), info.Symbol, "to", to.Hex(), "tx", receipt.TxHash.Hex())
+		},
+	}
+}
+
+func accountApproveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "approve <spender> <amount>",
+		Short: "allow a spender to move tokens of the node account",
+		Long:  "approve sets the amount of tokens the spender may move from the node account",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			spender, err := parseAddress(args[0])
+			if err != nil {
+				fmt.Println("approve error:", err)
+				return
+			}
+			_, _, wallet, err := walletAccount()
+			if err != nil {
+				fmt.Println("approve error:", err)
+				return
+			}
+			info, err := wallet.Info(context.Background())
+			if err != nil {
+				fmt.Println("approve error:", err)
+				return
+			}
+			amount, err := general.ParseAmount(args[1], info.Decimals)
+			if err != nil {
+				fmt.Println("approve error:", err)
+				return
+			}
+			receipt, err := wallet.Approve(spender, amount)
+			if err != nil {
+				fmt.Println("approve error:", err)
+				return
+			}
+			fmt.Println("approved", general.FormatAmount(amount, info.Decimals), info.Symbol, "for", spender.Hex(), "tx", receipt.TxHash.Hex())
+		},
+	}
+}
+
+func accountAllowanceCmd() *cobra.Command {
+	var owner string
+	cmd := &cobra.Command{
+		Use:   "allowance <spender>",
+		Short: "show the tokens a spender may move",
+		Long:  "allowance shows the amount of tokens the spender may move from the owner, the node account by default",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			spender, err := parseAddress(args[0])
+			if err != nil {
+				fmt.Println("allowance error:", err)
+				return
+			}
+			_, signer, wallet, err := walletAccount()
+			if err != nil {
+				fmt.Println("allowance error:", err)
+				return
+			}
+			from := signer.Address()
+			if owner != "" {
+				if from, err = parseAddress(owner); err != nil {
+					fmt.Println("allowance error:", err)
+					return
+				}
+			}
+			ctx := context.Background()
+			info, err := wallet.Info(ctx)
+			if err != nil {
+				fmt.Println("allowance error:", err)
+				return
+			}
+			allowance, err := wallet.Allowance(ctx, from, spender)
+			if err != nil {
+				fmt.Println("allowance error:", err)
+				return
+			}
+			fmt.Println(from.Hex(), "->", spender.Hex(), general.FormatAmount(allowance, info.Decimals), info.Symbol)
+		},
+	}
+	cmd.Flags().StringVar(&owner, "owner", "", "owner of the tokens, the node account if empty")
+	return cmd
+}
+
+func accountHistoryCmd() *cobra.Command {
+	var fromBlock uint64
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "list the token transfers of the node account",
+		Long:  "history lists the token transfers from and to the node account, follow keeps printing new transfers",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, signer, wallet, err := walletAccount()
+			if err != nil {
+				fmt.Println("history error:", err)
+				return
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			info, err := wallet.Info(ctx)
+			if err != nil {
+				fmt.Println("history error:", err)
+				return
+			}
+			self := signer.Address()
+			show := func(t contract.Transfer) {
+				direction, peer := "out", t.To
+				if t.From != self {
+					direction, peer = "in", t.From
+				}
+				fmt.Println(t.Block, t.Tx, direction, peer.Hex(), general.FormatAmount(t.Value, info.Decimals), info.Symbol)
+			}
+			transfers, err := wallet.History(ctx, self, fromBlock)
+			if err != nil {
+				fmt.Println("history error:", err)
+				return
+			}
+			for _, t := range transfers {
+				show(t)
+			}
+			if !follow {
+				return
+			}
+			client, err := ethclient.DialContext(ctx, config.WatchEndpoint(cfg))
+			if err != nil {
+				fmt.Println("history error:", err)
+				return
+			}
+			defer client.Close()
+			go func() {
+				interrupt := make(chan os.Signal, 1)
+				signal.Notify(interrupt, os.Interrupt)
+				<-interrupt
+				cancel()
+			}()
+			if err := wallet.Follow(ctx, client, self, show); err != nil && ctx.Err() == nil {
+				fmt.Println("history error:", err)
+			}
+		},
+	}
+	cmd.Flags().Uint64Var(&fromBlock, "from-block", 0, "first block to list")
+	cmd.Flags().BoolVar(&follow, "follow", false, "keep printing new transfers")
+	return cmd
+}
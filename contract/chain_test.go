@@ -0,0 +1,624 @@
+package contract
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/glvd/accipfs/contract/node"
+)
+
+// nodeListCode implements the node lists of the AccelerateNode abi: the entries
+// are kept in order, a delete shifts every entry after the index and a delete or
+// replace of an index out of range reverts. An entry is stored as its abi
+// encoding, the number of words at base(list, idx) and the words after it.
+//
+// memory: 0x00 list, 0x20 count, 0x40 i, 0x60 j, 0x80 words, 0xa0 source,
+// 0xc0 base, 0xe0 pointer, 0x100 on the returned list or the number of new entries
+const nodeListCode = `
+get:
+	push 0x00
+	mstore
+	pop
+	push 0x00
+	mload
+	sload
+	push 0x20
+	mstore
+	push 0x20
+	push 0x100
+	mstore
+	push 0x20
+	mload
+	push 0x120
+	mstore
+	push 0x20
+	mload
+	push 0x20
+	mul
+	push 0x140
+	add
+	push 0xe0
+	mstore
+	push 0
+	push 0x40
+	mstore
+get_loop:
+	push 0x20
+	mload
+	push 0x40
+	mload
+	lt
+	iszero
+	jumpi @get_done
+	;; offset of entry i
+	push 0x140
+	push 0xe0
+	mload
+	sub
+	push 0x40
+	mload
+	push 0x20
+	mul
+	push 0x140
+	add
+	mstore
+	push @get_base
+	push 0x40
+	mload
+	jump @base
+get_base:
+	push 0xc0
+	mstore
+	push 0xc0
+	mload
+	sload
+	push 0x80
+	mstore
+	push 0
+	push 0x60
+	mstore
+get_copy:
+	push 0x80
+	mload
+	push 0x60
+	mload
+	lt
+	iszero
+	jumpi @get_next
+	push 0x60
+	mload
+	push 0xc0
+	mload
+	add
+	push 1
+	add
+	sload
+	push 0x60
+	mload
+	push 0x20
+	mul
+	push 0xe0
+	mload
+	add
+	mstore
+	push 0x60
+	mload
+	push 1
+	add
+	push 0x60
+	mstore
+	jump @get_copy
+get_next:
+	push 0x80
+	mload
+	push 0x20
+	mul
+	push 0xe0
+	mload
+	add
+	push 0xe0
+	mstore
+	push 0x40
+	mload
+	push 1
+	add
+	push 0x40
+	mstore
+	jump @get_loop
+get_done:
+	push 0x100
+	push 0xe0
+	mload
+	sub
+	push 0x100
+	return
+
+add:
+	push 0x00
+	mstore
+	pop
+	push 0x04
+	calldataload
+	push 0x04
+	add
+	push 0xe0
+	mstore
+	push 0xe0
+	mload
+	calldataload
+	push 0x100
+	mstore
+	push 0x00
+	mload
+	sload
+	push 0x20
+	mstore
+	push 0
+	push 0x40
+	mstore
+add_loop:
+	push 0x100
+	mload
+	push 0x40
+	mload
+	lt
+	iszero
+	jumpi @add_done
+	;; the string of entry i starts at its offset after the array length
+	push 0x40
+	mload
+	push 0x20
+	mul
+	push 0xe0
+	mload
+	add
+	push 0x20
+	add
+	calldataload
+	push 0xe0
+	mload
+	add
+	push 0x20
+	add
+	push 0xa0
+	mstore
+	push @add_base
+	push 0x40
+	mload
+	push 0x20
+	mload
+	add
+	jump @base
+add_base:
+	push 0xc0
+	mstore
+	push @add_next
+	jump @copyin
+add_next:
+	push 0x40
+	mload
+	push 1
+	add
+	push 0x40
+	mstore
+	jump @add_loop
+add_done:
+	push 0x100
+	mload
+	push 0x20
+	mload
+	add
+	push 0x00
+	mload
+	sstore
+	stop
+
+delete:
+	push 0x00
+	mstore
+	pop
+	push 0x00
+	mload
+	sload
+	push 0x20
+	mstore
+	push 0x04
+	calldataload
+	push 0x40
+	mstore
+	push 0x20
+	mload
+	push 0x40
+	mload
+	lt
+	jumpi @delete_loop
+	push 0
+	dup1
+	revert
+delete_loop:
+	push 0x20
+	mload
+	push 0x40
+	mload
+	push 1
+	add
+	lt
+	iszero
+	jumpi @delete_done
+	push @delete_source
+	push 0x40
+	mload
+	push 1
+	add
+	jump @base
+delete_source:
+	push 0xa0
+	mstore
+	push @delete_base
+	push 0x40
+	mload
+	jump @base
+delete_base:
+	push 0xc0
+	mstore
+	push 0xa0
+	mload
+	sload
+	dup1
+	push 0x80
+	mstore
+	push 0xc0
+	mload
+	sstore
+	push 0
+	push 0x60
+	mstore
+delete_copy:
+	push 0x80
+	mload
+	push 0x60
+	mload
+	lt
+	iszero
+	jumpi @delete_next
+	push 0x60
+	mload
+	push 0xa0
+	mload
+	add
+	push 1
+	add
+	sload
+	push 0x60
+	mload
+	push 0xc0
+	mload
+	add
+	push 1
+	add
+	sstore
+	push 0x60
+	mload
+	push 1
+	add
+	push 0x60
+	mstore
+	jump @delete_copy
+delete_next:
+	push 0x40
+	mload
+	push 1
+	add
+	push 0x40
+	mstore
+	jump @delete_loop
+delete_done:
+	push 1
+	push 0x20
+	mload
+	sub
+	push 0x00
+	mload
+	sstore
+	stop
+
+replace:
+	push 0x00
+	mstore
+	pop
+	push 0x00
+	mload
+	sload
+	push 0x24
+	calldataload
+	lt
+	jumpi @replace_ok
+	push 0
+	dup1
+	revert
+replace_ok:
+	push 0x04
+	calldataload
+	push 0x04
+	add
+	push 0xa0
+	mstore
+	push @replace_base
+	push 0x24
+	calldataload
+	jump @base
+replace_base:
+	push 0xc0
+	mstore
+	push @replace_done
+	jump @copyin
+replace_done:
+	stop
+
+;; [ret idx] -> [base]
+base:
+	push 0x10000000000
+	mul
+	push 0x00
+	mload
+	push 1
+	add
+	push 0x100000000000000000000
+	mul
+	add
+	swap1
+	jump
+
+;; [ret] -> [], copies the abi string at source to base
+copyin:
+	push 0xa0
+	mload
+	calldataload
+	push 31
+	add
+	push 0x20
+	swap1
+	div
+	push 1
+	add
+	dup1
+	push 0x80
+	mstore
+	push 0xc0
+	mload
+	sstore
+	push 0
+	push 0x60
+	mstore
+copyin_loop:
+	push 0x80
+	mload
+	push 0x60
+	mload
+	lt
+	iszero
+	jumpi @copyin_done
+	push 0x60
+	mload
+	push 0x20
+	mul
+	push 0xa0
+	mload
+	add
+	calldataload
+	push 0x60
+	mload
+	push 0xc0
+	mload
+	add
+	push 1
+	add
+	sstore
+	push 0x60
+	mload
+	push 1
+	add
+	push 0x60
+	mstore
+	jump @copyin_loop
+copyin_done:
+	jump
+`
+
+// nodeListMethods are the entry points of nodeListCode with the list they use
+var nodeListMethods = []struct {
+	method string
+	label  string
+	list   int
+}{
+	{"getEthNodes", "get", 0},
+	{"getPublicIpfsNodes", "get", 1},
+	{"addEthNodes", "add", 0},
+	{"addPublicIpfsNodes", "add", 1},
+	{"deleteEthNodes", "delete", 0},
+	{"deletePublicIpfsNodes", "delete", 1},
+	{"replaceNode", "replace", 0},
+}
+
+// nodeListBin compiles the node list contract with the selectors of the abi
+func nodeListBin(t *testing.T) []byte {
+	parsed, err := abi.JSON(strings.NewReader(node.AccelerateNodeABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "\tpush 0\n\tcalldataload\n\tpush 0xe0\n\tshr\n"
+	for i, m := range nodeListMethods {
+		src += fmt.Sprintf("\tdup1\n\tpush 0x%x\n\teq\n\tjumpi @entry%d\n", parsed.Methods[m.method].ID(), i)
+	}
+	src += "\tpush 0\n\tdup1\n\trevert\n"
+	for i, m := range nodeListMethods {
+		src += fmt.Sprintf("entry%d:\n\tpush %d\n\tjump @%s\n", i, m.list, m.label)
+	}
+	c := asm.NewCompiler(false)
+	c.Feed(asm.Lex([]byte(src+nodeListCode), false))
+	code, errs := c.Compile()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	runtime := common.FromHex(code)
+	// the constructor returns the runtime code after it
+	init := []byte{0x61, byte(len(runtime) >> 8), byte(len(runtime)), 0x80, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(init, runtime...)
+}
+
+// testSigner signs with a key in memory
+type testSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{key: key}
+}
+
+// Address ...
+func (s *testSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+// Unlock ...
+func (s *testSigner) Unlock() error {
+	return nil
+}
+
+// Transactor ...
+func (s *testSigner) Transactor(ctx context.Context) (*bind.TransactOpts, error) {
+	opts := bind.NewKeyedTransactor(s.key)
+	opts.Context = ctx
+	return opts, nil
+}
+
+// SignText ...
+func (s *testSigner) SignText(data []byte) ([]byte, error) {
+	return crypto.Sign(accounts.TextHash(data), s.key)
+}
+
+// simChain is a simulated chain behind a small transaction pool. A transaction
+// is mined as soon as it is next in line for its account, unless its gas price
+// is below minePrice: then it waits in the pool until it is replaced.
+type simChain struct {
+	*backends.SimulatedBackend
+	mut       sync.Mutex
+	minPrice  *big.Int // transactions below it are rejected
+	minePrice *big.Int // transactions below it are not mined
+	pool      map[common.Address]map[uint64]*types.Transaction
+	signers   []*testSigner
+	nodes     *node.AccelerateNode
+}
+
+// newSimChain funds the signers and deploys the node list contract from another account
+func newSimChain(t *testing.T, signers int) *simChain {
+	alloc := make(core.GenesisAlloc)
+	chain := &simChain{
+		minPrice:  big.NewInt(1),
+		minePrice: big.NewInt(1),
+		pool:      make(map[common.Address]map[uint64]*types.Transaction),
+	}
+	for i := 0; i < signers; i++ {
+		chain.signers = append(chain.signers, newTestSigner(t))
+	}
+	deployer := newTestSigner(t)
+	for _, s := range append(chain.signers, deployer) {
+		alloc[s.Address()] = core.GenesisAccount{Balance: new(big.Int).Lsh(big.NewInt(1), 100)}
+	}
+	chain.SimulatedBackend = backends.NewSimulatedBackend(alloc, 100000000)
+	opts := bind.NewKeyedTransactor(deployer.key)
+	addr, _, _, err := bind.DeployContract(opts, abi.ABI{}, nodeListBin(t), chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.nodes, err = node.NewAccelerateNode(addr, chain); err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+// SendTransaction ...
+func (c *simChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	from, err := types.Sender(types.NewEIP155Signer(params.AllEthashProtocolChanges.ChainID), tx)
+	if err != nil {
+		return err
+	}
+	if tx.GasPrice().Cmp(c.minPrice) < 0 {
+		return errors.New("transaction underpriced")
+	}
+	next, err := c.SimulatedBackend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < next {
+		return errors.New("nonce too low")
+	}
+	queued := c.pool[from]
+	if queued == nil {
+		queued = make(map[uint64]*types.Transaction)
+		c.pool[from] = queued
+	}
+	if old, b := queued[tx.Nonce()]; b {
+		if old.Hash() == tx.Hash() {
+			return errors.New("already known")
+		}
+		// a replacement needs 10% more gas price
+		if new(big.Int).Mul(tx.GasPrice(), big.NewInt(100)).Cmp(new(big.Int).Mul(old.GasPrice(), big.NewInt(110))) < 0 {
+			return errors.New("replacement transaction underpriced")
+		}
+	}
+	queued[tx.Nonce()] = tx
+	mined := false
+	for {
+		tx, b := queued[next]
+		if !b || tx.GasPrice().Cmp(c.minePrice) < 0 {
+			break
+		}
+		if err := c.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+			return err
+		}
+		delete(queued, next)
+		next++
+		mined = true
+	}
+	if mined {
+		c.Commit()
+	}
+	return nil
+}
+
+// transactor sends through a new TxManager of the signer
+func (c *simChain) transactor(signer int) *Transactor {
+	return &Transactor{
+		ctx:     context.Background(),
+		manager: NewTxManager(c.signers[signer]),
+		backend: c,
+	}
+}
+
+// list returns a node list of the contract written by the signer
+func (c *simChain) list(signer int, kind NodeListKind) NodeList {
+	return ContractNodeList(c.nodes, c.transactor(signer), kind)
+}
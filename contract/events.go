@@ -0,0 +1,128 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract/dmessage"
+	"github.com/glvd/accipfs/contract/token"
+)
+
+// Events handed out by the watcher
+const (
+	EventPinSuccess          = "PinSuccess"
+	EventWritershipIncreased = "WritershipIncreased"
+	EventWritershipDecreased = "WritershipDecreased"
+	// EventNodeList a transaction was sent to the node contract, it has no events
+	EventNodeList = "NodeList"
+	// EventMessage a transaction was sent to the message contract, videos are published with addMessage
+	EventMessage = "Message"
+)
+
+func eventID(abiJSON string, name string) (common.Hash, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return parsed.Events[name].ID(), nil
+}
+
+// Watches returns the contract events the daemon follows
+func Watches(cfg *config.Config, filterer bind.ContractFilterer) ([]Watch, error) {
+	tokenAddr := common.HexToAddress(cfg.ETH.TokenAddr)
+	tokenFilterer, err := token.NewDhTokenFilterer(tokenAddr, filterer)
+	if err != nil {
+		return nil, err
+	}
+	pinSuccess, err := eventID(token.DhTokenABI, EventPinSuccess)
+	if err != nil {
+		return nil, err
+	}
+	watches := []Watch{
+		{
+			Name:    EventPinSuccess,
+			Address: tokenAddr,
+			Topic:   pinSuccess,
+			Parse: func(log types.Log) (interface{}, error) {
+				return tokenFilterer.ParsePinSuccess(log)
+			},
+		},
+		{
+			Name:    EventNodeList,
+			Address: common.HexToAddress(cfg.ETH.NodeAddr),
+		},
+	}
+	if cfg.ETH.MessageAddr == "" {
+		return watches, nil
+	}
+	messageAddr := common.HexToAddress(cfg.ETH.MessageAddr)
+	messageFilterer, err := dmessage.NewDMessageFilterer(messageAddr, filterer)
+	if err != nil {
+		return nil, err
+	}
+	increased, err := eventID(dmessage.DMessageABI, EventWritershipIncreased)
+	if err != nil {
+		return nil, err
+	}
+	decreased, err := eventID(dmessage.DMessageABI, EventWritershipDecreased)
+	if err != nil {
+		return nil, err
+	}
+	return append(watches,
+		Watch{
+			Name:    EventWritershipIncreased,
+			Address: messageAddr,
+			Topic:   increased,
+			Parse: func(log types.Log) (interface{}, error) {
+				return messageFilterer.ParseWritershipIncreased(log)
+			},
+		},
+		Watch{
+			Name:    EventWritershipDecreased,
+			Address: messageAddr,
+			Topic:   decreased,
+			Parse: func(log types.Log) (interface{}, error) {
+				return messageFilterer.ParseWritershipDecreased(log)
+			},
+		},
+		Watch{
+			Name:    EventMessage,
+			Address: messageAddr,
+		},
+	), nil
+}
+
+// AddedMessage returns the id and message of an addMessage transaction
+func AddedMessage(tx *types.Transaction) (id string, message string, err error) {
+	parsed, err := abi.JSON(strings.NewReader(dmessage.DMessageABI))
+	if err != nil {
+		return "", "", err
+	}
+	data := tx.Data()
+	if len(data) < 4 {
+		return "", "", errors.New("no method in transaction")
+	}
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return "", "", err
+	}
+	if method.Name != "addMessage" {
+		return "", "", fmt.Errorf("transaction calls %s", method.Name)
+	}
+	args, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return "", "", err
+	}
+	if len(args) != 2 {
+		return "", "", fmt.Errorf("addMessage has %d arguments", len(args))
+	}
+	id, _ = args[0].(string)
+	message, _ = args[1].(string)
+	return id, message, nil
+}
@@ -0,0 +1,36 @@
+package contract
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glvd/accipfs/contract/dmessage"
+)
+
+func TestAddedMessage(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(dmessage.DMessageABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := func(method string, args ...interface{}) *types.Transaction {
+		data, err := parsed.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), data)
+	}
+	id, message, err := AddedMessage(tx("addMessage", "abc-001", `{"no":"abc-001"}`))
+	if err != nil || id != "abc-001" || message != `{"no":"abc-001"}` {
+		t.Errorf("addMessage: %q %q %v", id, message, err)
+	}
+	if _, _, err := AddedMessage(tx("delMessage", "abc-001")); err == nil {
+		t.Error("delMessage read as a new message")
+	}
+	if _, _, err := AddedMessage(types.NewTransaction(0, common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)); err == nil {
+		t.Error("transfer read as a new message")
+	}
+}
@@ -0,0 +1,287 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glvd/accipfs/contract/node"
+	"github.com/goextension/log"
+)
+
+// ErrReplaceUnsupported ...
+var ErrReplaceUnsupported = errors.New("node list does not support replace")
+
+// ErrNotConverged ...
+var ErrNotConverged = errors.New("node list did not converge")
+
+// NodeList is one of the node lists stored on chain.
+// Every write returns after the transaction is mined.
+type NodeList interface {
+	Name() string
+	List(ctx context.Context) ([]string, error)
+	Add(ctx context.Context, entries []string) error
+	Delete(ctx context.Context, idx uint32) error
+	Replace(ctx context.Context, idx uint32, entry string) error
+}
+
+// NodeListKind ...
+type NodeListKind int
+
+// NodeListKind list
+const (
+	EthNodes NodeListKind = iota
+	PublicIPFSNodes
+)
+
+type contractNodeList struct {
+	kind NodeListKind
+	node *node.AccelerateNode
+	tx   *Transactor
+}
+
+// ContractNodeList ...
+func ContractNodeList(node *node.AccelerateNode, tx *Transactor, kind NodeListKind) NodeList {
+	return &contractNodeList{
+		kind: kind,
+		node: node,
+		tx:   tx,
+	}
+}
+
+// Name ...
+func (l *contractNodeList) Name() string {
+	if l.kind == EthNodes {
+		return "EthNodes"
+	}
+	return "PublicIpfsNodes"
+}
+
+// List ...
+func (l *contractNodeList) List(ctx context.Context) ([]string, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	if l.kind == EthNodes {
+		return l.node.GetEthNodes(opts)
+	}
+	return l.node.GetPublicIpfsNodes(opts)
+}
+
+// Add ...
+func (l *contractNodeList) Add(ctx context.Context, entries []string) error {
+	_, err := l.tx.Transact("Add"+l.Name(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if l.kind == EthNodes {
+			return l.node.AddEthNodes(opts, entries)
+		}
+		return l.node.AddPublicIpfsNodes(opts, entries)
+	})
+	return err
+}
+
+// Delete ...
+func (l *contractNodeList) Delete(ctx context.Context, idx uint32) error {
+	_, err := l.tx.Transact("Delete"+l.Name(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if l.kind == EthNodes {
+			return l.node.DeleteEthNodes(opts, idx)
+		}
+		return l.node.DeletePublicIpfsNodes(opts, idx)
+	})
+	return err
+}
+
+// Replace ...
+func (l *contractNodeList) Replace(ctx context.Context, idx uint32, entry string) error {
+	if l.kind != EthNodes {
+		return ErrReplaceUnsupported
+	}
+	_, err := l.tx.Transact("ReplaceNode", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return l.node.ReplaceNode(opts, entry, idx)
+	})
+	return err
+}
+
+// DesiredNodes describes the node list a node wants to see on chain
+type DesiredNodes struct {
+	// Add holds the entries to publish keyed by their identity
+	Add map[string]string
+	// Keep reports whether an entry already on chain is still valid
+	Keep func(entry string) bool
+}
+
+// ReconcileResult ...
+type ReconcileResult struct {
+	Added     int
+	Removed   int
+	Replaced  int
+	Restored  int
+	Conflicts int
+}
+
+// Reconciler brings a node list to the desired state. Entries are matched by
+// identity instead of index, the list is read again before and verified after
+// every mined transaction, and a valid entry seen during the run that goes
+// missing is published again, so other nodes changing the same list at the
+// same time can not make it remove the wrong entry for good.
+type Reconciler struct {
+	List     NodeList
+	Identity func(entry string) string
+	MaxSteps int
+}
+
+// NewReconciler ...
+func NewReconciler(list NodeList, identity func(entry string) string) *Reconciler {
+	return &Reconciler{
+		List:     list,
+		Identity: identity,
+		MaxSteps: 64,
+	}
+}
+
+type nodeDiff struct {
+	remove []int
+	add    []string
+	ids    map[string]bool
+}
+
+func (r *Reconciler) diff(entries []string, want DesiredNodes) *nodeDiff {
+	d := &nodeDiff{ids: make(map[string]bool)}
+	for idx, entry := range entries {
+		id := r.Identity(entry)
+		if id == "" {
+			// entries we can not read belong to somebody else
+			continue
+		}
+		if d.ids[id] || !want.keep(entry) {
+			d.remove = append(d.remove, idx)
+			continue
+		}
+		d.ids[id] = true
+	}
+	var ids []string
+	for id := range want.Add {
+		if !d.ids[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		d.add = append(d.add, want.Add[id])
+	}
+	return d
+}
+
+func (r *Reconciler) identities(entries []string) map[string]int {
+	ids := make(map[string]int)
+	for _, entry := range entries {
+		if id := r.Identity(entry); id != "" {
+			ids[id]++
+		}
+	}
+	return ids
+}
+
+// Reconcile ...
+func (r *Reconciler) Reconcile(ctx context.Context, want DesiredNodes) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+	known := make(map[string]string)
+	add := make(map[string]string, len(want.Add))
+	for id, entry := range want.Add {
+		add[id] = entry
+	}
+	want.Add = add
+	for step := 0; step < r.MaxSteps; step++ {
+		entries, err := r.List.List(ctx)
+		if err != nil {
+			return result, err
+		}
+		before := r.identities(entries)
+		// a valid entry vanished: a delete with a shifted index removed it
+		for id, entry := range known {
+			if _, b := want.Add[id]; b || before[id] > 0 || !want.keep(entry) {
+				continue
+			}
+			want.Add[id] = entry
+			result.Restored++
+		}
+		d := r.diff(entries, want)
+		if len(d.remove) == 0 && len(d.add) == 0 {
+			return result, nil
+		}
+		for _, entry := range entries {
+			if id := r.Identity(entry); id != "" {
+				known[id] = entry
+			}
+		}
+		var target, added string
+		switch {
+		case len(d.remove) > 0:
+			idx := d.remove[len(d.remove)-1]
+			target = r.Identity(entries[idx])
+			if len(d.add) > 0 {
+				added = d.add[0]
+				err = r.List.Replace(ctx, uint32(idx), added)
+				if err == nil {
+					result.Replaced++
+					break
+				}
+				if !errors.Is(err, ErrReplaceUnsupported) {
+					break
+				}
+				added = ""
+			}
+			err = r.List.Delete(ctx, uint32(idx))
+			if err == nil {
+				result.Removed++
+			}
+		default:
+			err = r.List.Add(ctx, d.add)
+			if err == nil {
+				result.Added += len(d.add)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, ErrTxReverted) {
+				return result, err
+			}
+			// the index is out of range, the list changed before the transaction was mined
+			result.Conflicts++
+			log.Infow("node list write reverted", "list", r.List.Name(), "step", step, "error", err)
+			continue
+		}
+
+		// verify the list after the transaction was mined
+		entries, err = r.List.List(ctx)
+		if err != nil {
+			return result, err
+		}
+		after := r.identities(entries)
+		conflict := target != "" && after[target] >= before[target]
+		if added != "" && after[r.Identity(added)] == 0 {
+			conflict = true
+		}
+		if len(d.remove) == 0 {
+			for _, entry := range d.add {
+				if after[r.Identity(entry)] == 0 {
+					conflict = true
+				}
+			}
+		}
+		for id := range before {
+			if id != target && after[id] == 0 && want.keep(known[id]) {
+				conflict = true
+			}
+		}
+		if conflict {
+			result.Conflicts++
+			log.Infow("node list changed while reconciling", "list", r.List.Name(), "step", step, "target", target)
+		}
+	}
+	return result, fmt.Errorf("%s after %d steps: %w", r.List.Name(), r.MaxSteps, ErrNotConverged)
+}
+
+// keep reports whether an entry on chain is still valid
+func (want DesiredNodes) keep(entry string) bool {
+	return want.Keep == nil || want.Keep(entry)
+}
@@ -0,0 +1,262 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goextension/log/zap"
+)
+
+func init() {
+	zap.InitZapFileSugar()
+}
+
+// racingList runs another node's write right before each of its own writes,
+// the other transaction is mined first
+type racingList struct {
+	NodeList
+	other func() error
+}
+
+func (l *racingList) race() error {
+	if l.other == nil {
+		return nil
+	}
+	return l.other()
+}
+
+func (l *racingList) Add(ctx context.Context, entries []string) error {
+	if err := l.race(); err != nil {
+		return err
+	}
+	return l.NodeList.Add(ctx, entries)
+}
+
+func (l *racingList) Delete(ctx context.Context, idx uint32) error {
+	if err := l.race(); err != nil {
+		return err
+	}
+	return l.NodeList.Delete(ctx, idx)
+}
+
+func (l *racingList) Replace(ctx context.Context, idx uint32, entry string) error {
+	if err := l.race(); err != nil {
+		return err
+	}
+	return l.NodeList.Replace(ctx, idx, entry)
+}
+
+// newTestList publishes the entries from the last signer and returns the list written by the first
+func newTestList(t *testing.T, kind NodeListKind, signers int, ids ...string) (*simChain, NodeList) {
+	chain := newSimChain(t, signers)
+	if len(ids) > 0 {
+		if err := chain.list(signers-1, kind).Add(context.Background(), testEntries(ids...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return chain, chain.list(0, kind)
+}
+
+// entries look like enode://<id>@<ip>:30303
+func testIdentity(entry string) string {
+	entry = strings.TrimPrefix(entry, "enode://")
+	if i := strings.Index(entry, "@"); i > 0 {
+		return entry[:i]
+	}
+	return ""
+}
+
+func testEntry(id string) string {
+	return fmt.Sprintf("enode://%s@127.0.0.1:30303", id)
+}
+
+func testEntries(ids ...string) []string {
+	var entries []string
+	for _, id := range ids {
+		entries = append(entries, testEntry(id))
+	}
+	return entries
+}
+
+func testDesired(dead []string, add ...string) DesiredNodes {
+	want := DesiredNodes{Add: make(map[string]string)}
+	for _, id := range add {
+		want.Add[id] = testEntry(id)
+	}
+	want.Keep = func(entry string) bool {
+		id := testIdentity(entry)
+		for _, d := range dead {
+			if d == id {
+				return false
+			}
+		}
+		return true
+	}
+	return want
+}
+
+func checkList(t *testing.T, l NodeList, ids ...string) {
+	t.Helper()
+	entries, err := l.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, testIdentity(entry))
+	}
+	sort.Strings(got)
+	sort.Strings(ids)
+	if strings.Join(got, ",") != strings.Join(ids, ",") {
+		t.Fatalf("list is %v, want %v", got, ids)
+	}
+}
+
+func TestReconcileDeleteMany(t *testing.T) {
+	var ids, dead, alive []string
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("n%02d", i)
+		ids = append(ids, id)
+		if i%3 == 0 {
+			dead = append(dead, id)
+		} else {
+			alive = append(alive, id)
+		}
+	}
+	// the public ipfs list has no replace
+	_, l := newTestList(t, PublicIPFSNodes, 2, ids...)
+	r := NewReconciler(l, testIdentity)
+	result, err := r.Reconcile(context.Background(), testDesired(dead, "new1", "new2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != len(dead) || result.Added != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	checkList(t, l, append(alive, "new1", "new2")...)
+}
+
+func TestReconcileReplace(t *testing.T) {
+	_, l := newTestList(t, EthNodes, 2, "a", "b", "c", "d")
+	r := NewReconciler(l, testIdentity)
+	result, err := r.Reconcile(context.Background(), testDesired([]string{"a", "c"}, "e", "f", "g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Replaced != 2 || result.Added != 1 || result.Removed != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	checkList(t, l, "b", "d", "e", "f", "g")
+}
+
+func TestReconcileDuplicates(t *testing.T) {
+	chain, l := newTestList(t, PublicIPFSNodes, 2, "a", "b", "a", "b", "a")
+	if err := chain.list(1, PublicIPFSNodes).Add(context.Background(), []string{"garbage"}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewReconciler(l, testIdentity)
+	if _, err := r.Reconcile(context.Background(), testDesired(nil)); err != nil {
+		t.Fatal(err)
+	}
+	checkList(t, l, "", "a", "b")
+}
+
+// another node deletes the first entry right before our delete is sent,
+// so our index points at the next entry, which is still valid, when it is mined
+func TestReconcileShiftedIndex(t *testing.T) {
+	chain, l := newTestList(t, PublicIPFSNodes, 2, "a", "b", "c", "d", "e")
+	other := chain.list(1, PublicIPFSNodes)
+	once := false
+	racing := &racingList{NodeList: l, other: func() error {
+		if once {
+			return nil
+		}
+		once = true
+		return other.Delete(context.Background(), 0)
+	}}
+	r := NewReconciler(racing, testIdentity)
+	result, err := r.Reconcile(context.Background(), testDesired([]string{"a", "c"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Restored == 0 || result.Conflicts == 0 {
+		t.Errorf("the shifted delete was not detected: %+v", result)
+	}
+	checkList(t, l, "b", "d", "e")
+}
+
+// another node shortens the list before our delete is sent, the delete would revert
+func TestReconcileOutOfRange(t *testing.T) {
+	chain, l := newTestList(t, PublicIPFSNodes, 2, "a", "b", "c")
+	other := chain.list(1, PublicIPFSNodes)
+	once := false
+	racing := &racingList{NodeList: l, other: func() error {
+		if once {
+			return nil
+		}
+		once = true
+		return other.Delete(context.Background(), 2)
+	}}
+	r := NewReconciler(racing, testIdentity)
+	result, err := r.Reconcile(context.Background(), testDesired([]string{"c"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Conflicts == 0 || result.Removed != 0 {
+		t.Errorf("the reverted delete was not detected: %+v", result)
+	}
+	checkList(t, l, "a", "b")
+}
+
+func TestReconcileConcurrent(t *testing.T) {
+	var ids, dead, alive []string
+	for i := 0; i < 16; i++ {
+		id := fmt.Sprintf("n%02d", i)
+		ids = append(ids, id)
+		if i%2 == 0 {
+			dead = append(dead, id)
+		} else {
+			alive = append(alive, id)
+		}
+	}
+	chain, l := newTestList(t, PublicIPFSNodes, 5, ids...)
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := NewReconciler(chain.list(i, PublicIPFSNodes), testIdentity)
+			r.MaxSteps = 256
+			_, err := r.Reconcile(context.Background(), testDesired(dead, "x", "y"))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkList(t, l, append(alive, "x", "y")...)
+}
+
+func TestReconcileNotConverged(t *testing.T) {
+	chain, l := newTestList(t, PublicIPFSNodes, 2, "a", "b")
+	other := chain.list(1, PublicIPFSNodes)
+	racing := &racingList{NodeList: l, other: func() error {
+		return other.Add(context.Background(), testEntries("a"))
+	}}
+	r := NewReconciler(racing, testIdentity)
+	r.MaxSteps = 5
+	_, err := r.Reconcile(context.Background(), testDesired([]string{"a"}))
+	if !errors.Is(err, ErrNotConverged) {
+		t.Fatalf("expected %v, got %v", ErrNotConverged, err)
+	}
+}
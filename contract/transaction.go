@@ -0,0 +1,303 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glvd/accipfs/account"
+	"github.com/goextension/log"
+)
+
+// ErrTxReverted ...
+var ErrTxReverted = errors.New("transaction reverted")
+
+// ErrTxNotMined ...
+var ErrTxNotMined = errors.New("transaction not mined")
+
+// DefaultTxTimeout is how long one attempt waits for its receipt
+var DefaultTxTimeout = 2 * time.Minute
+
+// DefaultTxRetries is how many times a transaction is sent again with a higher gas price
+var DefaultTxRetries = 3
+
+// DefaultGasBump is the gas price increase in percent for a replacement transaction
+var DefaultGasBump int64 = 20
+
+const maxTxHistory = 256
+
+// Backend ...
+type Backend interface {
+	bind.ContractBackend
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// TransactFunc sends one contract transaction with the given options
+type TransactFunc func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// TxStatus ...
+type TxStatus string
+
+// TxStatus list
+const (
+	TxPending  TxStatus = "pending"
+	TxMined    TxStatus = "mined"
+	TxReverted TxStatus = "reverted"
+	TxFailed   TxStatus = "failed"
+)
+
+// TxRecord ...
+type TxRecord struct {
+	Method   string    `json:"method"`
+	From     string    `json:"from"`
+	Hash     string    `json:"hash"`
+	Nonce    uint64    `json:"nonce"`
+	GasPrice string    `json:"gas_price"`
+	Attempts int       `json:"attempts"`
+	Status   TxStatus  `json:"status"`
+	Block    uint64    `json:"block"`
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// TxManager serializes the nonces of one account, waits for the receipts
+// and replaces the transactions that are underpriced or dropped.
+type TxManager struct {
+	Timeout time.Duration
+	Retries int
+	GasBump int64
+
+	signer  account.Signer
+	mut     sync.Mutex
+	nonce   uint64
+	synced  bool
+	hisMut  sync.RWMutex
+	history []*TxRecord
+}
+
+// NewTxManager ...
+func NewTxManager(signer account.Signer) *TxManager {
+	return &TxManager{
+		Timeout: DefaultTxTimeout,
+		Retries: DefaultTxRetries,
+		GasBump: DefaultGasBump,
+		signer:  signer,
+	}
+}
+
+// History returns the latest transactions, newest first
+func (m *TxManager) History() []TxRecord {
+	m.hisMut.RLock()
+	defer m.hisMut.RUnlock()
+	records := make([]TxRecord, 0, len(m.history))
+	for i := len(m.history) - 1; i >= 0; i-- {
+		records = append(records, *m.history[i])
+	}
+	return records
+}
+
+func (m *TxManager) record(method string, from common.Address) *TxRecord {
+	m.hisMut.Lock()
+	defer m.hisMut.Unlock()
+	now := time.Now()
+	r := &TxRecord{
+		Method:  method,
+		From:    from.Hex(),
+		Status:  TxPending,
+		Created: now,
+		Updated: now,
+	}
+	m.history = append(m.history, r)
+	if len(m.history) > maxTxHistory {
+		m.history = m.history[len(m.history)-maxTxHistory:]
+	}
+	return r
+}
+
+func (m *TxManager) update(r *TxRecord, f func(r *TxRecord)) {
+	m.hisMut.Lock()
+	defer m.hisMut.Unlock()
+	f(r)
+	r.Updated = time.Now()
+}
+
+// nextNonce must be called with the lock held
+func (m *TxManager) nextNonce(ctx context.Context, backend Backend, from common.Address) (uint64, error) {
+	pending, err := backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+	if !m.synced || pending > m.nonce {
+		m.nonce = pending
+		m.synced = true
+	}
+	nonce := m.nonce
+	m.nonce++
+	return nonce, nil
+}
+
+// reset makes the next transaction read the nonce from the chain again
+func (m *TxManager) reset() {
+	m.mut.Lock()
+	m.synced = false
+	m.mut.Unlock()
+}
+
+func (m *TxManager) bump(price *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(100+m.GasBump))
+	bumped.Div(bumped, big.NewInt(100))
+	return bumped.Add(bumped, common.Big1)
+}
+
+// Transact sends the transaction and waits until it is mined
+func (m *TxManager) Transact(ctx context.Context, backend Backend, method string, fn TransactFunc) (*types.Receipt, error) {
+	opts, err := m.signer.Transactor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := m.record(method, opts.From)
+	receipt, err := m.transact(ctx, backend, opts, r, fn)
+	m.update(r, func(r *TxRecord) {
+		switch {
+		case err == nil:
+			r.Status = TxMined
+		case errors.Is(err, ErrTxReverted):
+			r.Status = TxReverted
+		default:
+			r.Status = TxFailed
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		if receipt != nil {
+			r.Hash = receipt.TxHash.Hex()
+			if receipt.BlockNumber != nil {
+				r.Block = receipt.BlockNumber.Uint64()
+			}
+		}
+	})
+	if err != nil {
+		log.Errorw("transaction failed", "method", method, "nonce", r.Nonce, "error", err)
+	}
+	return receipt, err
+}
+
+func (m *TxManager) transact(ctx context.Context, backend Backend, opts *bind.TransactOpts, r *TxRecord, fn TransactFunc) (*types.Receipt, error) {
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mut.Lock()
+	nonce, err := m.nextNonce(ctx, backend, opts.From)
+	m.mut.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	m.update(r, func(r *TxRecord) {
+		r.Nonce = nonce
+	})
+
+	var sent []*types.Transaction
+	for attempt := 0; attempt <= m.Retries; attempt++ {
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		opts.GasPrice = gasPrice
+		m.update(r, func(r *TxRecord) {
+			r.Attempts = attempt + 1
+			r.GasPrice = gasPrice.String()
+		})
+		tx, err := fn(opts)
+		if err != nil {
+			switch {
+			case isUnderpriced(err):
+				gasPrice = m.bump(gasPrice)
+				continue
+			case isNonceUsed(err) && len(sent) > 0:
+				// one of the earlier attempts has been mined in the meantime
+				return m.minedReceipt(ctx, backend, sent)
+			case isReverted(err) && len(sent) == 0:
+				// the gas estimation failed, the transaction would revert on chain
+				m.reset()
+				return nil, fmt.Errorf("%v: %w", err, ErrTxReverted)
+			}
+			m.reset()
+			return nil, err
+		}
+		sent = append(sent, tx)
+		m.update(r, func(r *TxRecord) {
+			r.Hash = tx.Hash().Hex()
+		})
+
+		waitCtx, cancel := context.WithTimeout(ctx, m.Timeout)
+		receipt, err := bind.WaitMined(waitCtx, backend, tx)
+		cancel()
+		if err == nil {
+			if receipt.Status == types.ReceiptStatusFailed {
+				return receipt, fmt.Errorf("%s: %w", tx.Hash().Hex(), ErrTxReverted)
+			}
+			return receipt, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// not mined in time, the transaction was dropped or the gas price is too low
+		gasPrice = m.bump(gasPrice)
+	}
+	if receipt, err := m.minedReceipt(ctx, backend, sent); err == nil {
+		return receipt, nil
+	}
+	m.reset()
+	return nil, fmt.Errorf("nonce %d after %d attempts: %w", nonce, m.Retries+1, ErrTxNotMined)
+}
+
+func (m *TxManager) minedReceipt(ctx context.Context, backend Backend, sent []*types.Transaction) (*types.Receipt, error) {
+	for _, tx := range sent {
+		receipt, err := backend.TransactionReceipt(ctx, tx.Hash())
+		if err == nil && receipt != nil {
+			if receipt.Status == types.ReceiptStatusFailed {
+				return receipt, fmt.Errorf("%s: %w", tx.Hash().Hex(), ErrTxReverted)
+			}
+			return receipt, nil
+		}
+	}
+	return nil, ErrTxNotMined
+}
+
+func isUnderpriced(err error) bool {
+	return strings.Contains(err.Error(), "underpriced")
+}
+
+func isReverted(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "always failing transaction") || strings.Contains(msg, "execution reverted")
+}
+
+func isNonceUsed(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "known transaction") ||
+		strings.Contains(msg, "already known")
+}
+
+// Transactor sends the contract transactions of one call through the TxManager
+type Transactor struct {
+	ctx     context.Context
+	manager *TxManager
+	backend Backend
+}
+
+// From ...
+func (t *Transactor) From() common.Address {
+	return t.manager.signer.Address()
+}
+
+// Transact ...
+func (t *Transactor) Transact(method string, fn TransactFunc) (*types.Receipt, error) {
+	return t.manager.Transact(t.ctx, t.backend, method, fn)
+}
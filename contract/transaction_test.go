@@ -0,0 +1,124 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func addEntry(chain *simChain, entry string) TransactFunc {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return chain.nodes.AddEthNodes(opts, []string{entry})
+	}
+}
+
+func TestTxManagerConcurrentNonces(t *testing.T) {
+	chain := newSimChain(t, 1)
+	m := NewTxManager(chain.signers[0])
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := m.Transact(context.Background(), chain, "AddEthNodes", addEntry(chain, testEntry(fmt.Sprint("n", i))))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	nonces := make(map[uint64]bool)
+	for _, r := range m.History() {
+		if r.Status != TxMined || r.Attempts != 1 {
+			t.Errorf("transaction %+v", r)
+		}
+		nonces[r.Nonce] = true
+	}
+	for i := uint64(0); i < 8; i++ {
+		if !nonces[i] {
+			t.Errorf("nonce %d was not used: %v", i, nonces)
+		}
+	}
+	entries, err := chain.list(0, EthNodes).List(context.Background())
+	if err != nil || len(entries) != 8 {
+		t.Fatalf("list %v %v", entries, err)
+	}
+}
+
+func TestTxManagerBump(t *testing.T) {
+	chain := newSimChain(t, 1)
+	m := NewTxManager(chain.signers[0])
+	m.Timeout = 100 * time.Millisecond
+
+	// the suggested price of 1 is refused by the pool
+	chain.minPrice = big.NewInt(2)
+	if _, err := m.Transact(context.Background(), chain, "AddEthNodes", addEntry(chain, testEntry("a"))); err != nil {
+		t.Fatal(err)
+	}
+	if r := m.History()[0]; r.Attempts != 2 || r.GasPrice != "2" || r.Status != TxMined {
+		t.Errorf("underpriced transaction %+v", r)
+	}
+
+	// the pool takes it but it is not mined until it is replaced with a higher price
+	chain.minPrice = big.NewInt(1)
+	chain.minePrice = big.NewInt(3)
+	if _, err := m.Transact(context.Background(), chain, "AddEthNodes", addEntry(chain, testEntry("b"))); err != nil {
+		t.Fatal(err)
+	}
+	if r := m.History()[0]; r.Nonce != 1 || r.Attempts != 3 || r.GasPrice != "3" || r.Status != TxMined {
+		t.Errorf("replaced transaction %+v", r)
+	}
+
+	// every replacement stays below the price that is mined
+	chain.minePrice = big.NewInt(1000)
+	m.Retries = 1
+	_, err := m.Transact(context.Background(), chain, "AddEthNodes", addEntry(chain, testEntry("c")))
+	if !errors.Is(err, ErrTxNotMined) {
+		t.Fatalf("expected %v, got %v", ErrTxNotMined, err)
+	}
+	if r := m.History()[0]; r.Status != TxFailed {
+		t.Errorf("dropped transaction %+v", r)
+	}
+	entries, err := chain.list(0, EthNodes).List(context.Background())
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("list %v %v", entries, err)
+	}
+}
+
+func TestTxManagerReverted(t *testing.T) {
+	chain := newSimChain(t, 1)
+	m := NewTxManager(chain.signers[0])
+	receipt, err := m.Transact(context.Background(), chain, "DeleteEthNodes", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		// a gas limit skips the estimation, so the transaction is mined and reverted
+		opts.GasLimit = 200000
+		return chain.nodes.DeleteEthNodes(opts, 3)
+	})
+	if !errors.Is(err, ErrTxReverted) {
+		t.Fatalf("expected %v, got %v", ErrTxReverted, err)
+	}
+	if receipt == nil || receipt.Status != types.ReceiptStatusFailed {
+		t.Fatalf("receipt %+v", receipt)
+	}
+	if r := m.History()[0]; r.Status != TxReverted || r.Hash != receipt.TxHash.Hex() {
+		t.Errorf("reverted transaction %+v", r)
+	}
+	// the nonce of the reverted transaction is used
+	if _, err := m.Transact(context.Background(), chain, "AddEthNodes", addEntry(chain, testEntry("a"))); err != nil {
+		t.Fatal(err)
+	}
+	if r := m.History()[0]; r.Nonce != 1 {
+		t.Errorf("transaction after the revert %+v", r)
+	}
+}
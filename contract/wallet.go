@@ -0,0 +1,186 @@
+package contract
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract/token"
+)
+
+// TokenInfo ...
+type TokenInfo struct {
+	Symbol   string
+	Decimals uint8
+}
+
+// Transfer is one token transfer of an account
+type Transfer struct {
+	Block uint64
+	Tx    string
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+// Wallet moves the DhToken of the node account
+type Wallet struct {
+	contractor Contractor
+	tokenAddr  common.Address
+}
+
+// NewWallet ...
+func NewWallet(cfg *config.Config, contractor Contractor) *Wallet {
+	return &Wallet{
+		contractor: contractor,
+		tokenAddr:  common.HexToAddress(cfg.ETH.TokenAddr),
+	}
+}
+
+// Info ...
+func (w *Wallet) Info(ctx context.Context) (*TokenInfo, error) {
+	info := &TokenInfo{}
+	err := w.contractor.Token(func(token *token.DhToken, tx *Transactor) error {
+		var err error
+		opts := &bind.CallOpts{Context: ctx}
+		if info.Decimals, err = token.Decimals(opts); err != nil {
+			return err
+		}
+		info.Symbol, err = token.Symbol(opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Balance ...
+func (w *Wallet) Balance(ctx context.Context, owner common.Address) (*big.Int, error) {
+	var balance *big.Int
+	err := w.contractor.Token(func(token *token.DhToken, tx *Transactor) error {
+		var err error
+		balance, err = token.BalanceOf(&bind.CallOpts{Context: ctx}, owner)
+		return err
+	})
+	return balance, err
+}
+
+// Allowance ...
+func (w *Wallet) Allowance(ctx context.Context, owner, spender common.Address) (*big.Int, error) {
+	var allowance *big.Int
+	err := w.contractor.Token(func(token *token.DhToken, tx *Transactor) error {
+		var err error
+		allowance, err = token.Allowance(&bind.CallOpts{Context: ctx}, owner, spender)
+		return err
+	})
+	return allowance, err
+}
+
+// Transfer sends the amount from the node account
+func (w *Wallet) Transfer(to common.Address, amount *big.Int) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := w.contractor.Token(func(token *token.DhToken, tx *Transactor) error {
+		var err error
+		receipt, err = tx.Transact("Transfer", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return token.Transfer(opts, to, amount)
+		})
+		return err
+	})
+	return receipt, err
+}
+
+// Approve lets the spender move the amount from the node account
+func (w *Wallet) Approve(spender common.Address, amount *big.Int) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	err := w.contractor.Token(func(token *token.DhToken, tx *Transactor) error {
+		var err error
+		receipt, err = tx.Transact("Approve", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return token.Approve(opts, spender, amount)
+		})
+		return err
+	})
+	return receipt, err
+}
+
+// History returns the transfers from and to the account since the block, oldest first
+func (w *Wallet) History(ctx context.Context, account common.Address, start uint64) ([]Transfer, error) {
+	var transfers []Transfer
+	err := w.contractor.Token(func(token *token.DhToken, tx *Transactor) error {
+		opts := &bind.FilterOpts{Start: start, Context: ctx}
+		for _, filter := range [][2][]common.Address{{{account}, nil}, {nil, {account}}} {
+			it, err := token.FilterTransfer(opts, filter[0], filter[1])
+			if err != nil {
+				return err
+			}
+			for it.Next() {
+				// a transfer to self is found by both filters
+				if filter[1] != nil && it.Event.From == account {
+					continue
+				}
+				transfers = append(transfers, newTransfer(it.Event))
+			}
+			err = it.Error()
+			_ = it.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].Block < transfers[j].Block
+	})
+	return transfers, err
+}
+
+// Follow hands the new transfers of the account to the sink until the
+// context is done or the subscription fails, the backend must support
+// subscriptions (ipc or websocket)
+func (w *Wallet) Follow(ctx context.Context, backend bind.ContractFilterer, account common.Address, sink func(t Transfer)) error {
+	filterer, err := token.NewDhTokenFilterer(w.tokenAddr, backend)
+	if err != nil {
+		return err
+	}
+	events := make(chan *token.DhTokenTransfer, 16)
+	opts := &bind.WatchOpts{Context: ctx}
+	out, err := filterer.WatchTransfer(opts, events, []common.Address{account}, nil)
+	if err != nil {
+		return err
+	}
+	defer out.Unsubscribe()
+	in, err := filterer.WatchTransfer(opts, events, nil, []common.Address{account})
+	if err != nil {
+		return err
+	}
+	defer in.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-out.Err():
+			return err
+		case err := <-in.Err():
+			return err
+		case e := <-events:
+			if e.Raw.Removed {
+				continue
+			}
+			sink(newTransfer(e))
+		}
+	}
+}
+
+func newTransfer(e *token.DhTokenTransfer) Transfer {
+	return Transfer{
+		Block: e.Raw.BlockNumber,
+		Tx:    e.Raw.TxHash.Hex(),
+		From:  e.From,
+		To:    e.To,
+		Value: e.Value,
+	}
+}
@@ -0,0 +1,357 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/goextension/log"
+)
+
+// DefaultReorgWindow is how many processed blocks are checked for a reorganization
+var DefaultReorgWindow uint64 = 64
+
+// DefaultBatchSize is the block range of one log query while catching up
+var DefaultBatchSize uint64 = 2000
+
+// errBlockChanged a block changed while its logs were read
+var errBlockChanged = errors.New("block changed while reading logs")
+
+// ChainReader is the part of the eth client the watcher reads from
+type ChainReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// Checkpoint keeps the last processed block
+type Checkpoint interface {
+	Load() (uint64, bool, error)
+	Save(block uint64) error
+}
+
+// Store ...
+type Store interface {
+	Get(key string) ([]byte, error)
+	Set(key string, val []byte) error
+}
+
+type storeCheckpoint struct {
+	store Store
+	key   string
+}
+
+// NewStoreCheckpoint keeps the checkpoint under the key of a cache
+func NewStoreCheckpoint(store Store, key string) Checkpoint {
+	return &storeCheckpoint{store: store, key: key}
+}
+
+// Load ...
+func (c *storeCheckpoint) Load() (uint64, bool, error) {
+	val, err := c.store.Get(c.key)
+	if err != nil || len(val) == 0 {
+		// the cache returns an error for missing keys
+		return 0, false, nil
+	}
+	block, err := strconv.ParseUint(string(val), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("checkpoint %s: %w", c.key, err)
+	}
+	return block, true, nil
+}
+
+// Save ...
+func (c *storeCheckpoint) Save(block uint64) error {
+	return c.store.Set(c.key, []byte(strconv.FormatUint(block, 10)))
+}
+
+// Watch selects the contract logs of one event, or the transactions sent to
+// the contract when the topic is empty, they are used for contracts without
+// events. Reverted transactions are left out.
+type Watch struct {
+	Name    string
+	Address common.Address
+	Topic   common.Hash
+	Parse   func(log types.Log) (interface{}, error)
+}
+
+// Event ...
+type Event struct {
+	Name    string
+	Block   uint64
+	TxHash  common.Hash
+	Removed bool // the block was reorganized away, the event is reverted
+	Data    interface{}
+}
+
+type processedBlock struct {
+	number uint64
+	hash   common.Hash
+	events []Event
+}
+
+// Watcher follows the chain and hands the events of the watched contracts to
+// the handler in chain order. Blocks are processed once they have the
+// configured confirmations, and events of reorganized blocks are handed out
+// again as removed.
+type Watcher struct {
+	Confirmations uint64
+	ReorgWindow   uint64
+	BatchSize     uint64
+	Poll          time.Duration
+	Start         uint64
+
+	mut        sync.Mutex
+	reader     ChainReader
+	checkpoint Checkpoint
+	watches    []Watch
+	handler    func(Event)
+	recent     []processedBlock
+	next       uint64
+	loaded     bool
+}
+
+// NewWatcher ...
+func NewWatcher(reader ChainReader, checkpoint Checkpoint, handler func(Event), watches ...Watch) *Watcher {
+	return &Watcher{
+		Confirmations: 3,
+		ReorgWindow:   DefaultReorgWindow,
+		BatchSize:     DefaultBatchSize,
+		Poll:          30 * time.Second,
+		reader:        reader,
+		checkpoint:    checkpoint,
+		watches:       watches,
+		handler:       handler,
+	}
+}
+
+// Run processes the new blocks until the context is done or the head
+// subscription fails, the caller reconnects and runs again
+func (w *Watcher) Run(ctx context.Context) error {
+	heads := make(chan *types.Header, 16)
+	var subErr <-chan error
+	sub, err := w.reader.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		// http endpoints can not subscribe, the poll interval drives the watcher
+		log.Infow("subscribe new heads", "error", err)
+	} else {
+		defer sub.Unsubscribe()
+		subErr = sub.Err()
+	}
+	ticker := time.NewTicker(w.Poll)
+	defer ticker.Stop()
+	for {
+		if err := w.Step(ctx); err != nil && ctx.Err() == nil {
+			log.Errorw("watch chain", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-heads:
+		case <-ticker.C:
+		case err := <-subErr:
+			return err
+		}
+	}
+}
+
+// Step processes every confirmed block that was not processed yet
+func (w *Watcher) Step(ctx context.Context) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if !w.loaded {
+		block, ok, err := w.checkpoint.Load()
+		if err != nil {
+			return err
+		}
+		w.next = w.Start
+		if ok {
+			w.next = block + 1
+		}
+		w.loaded = true
+	}
+	head, err := w.reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := w.unwind(ctx); err != nil {
+		return err
+	}
+	if head.Number.Uint64() < w.Confirmations {
+		return nil
+	}
+	target := head.Number.Uint64() - w.Confirmations
+	for w.next <= target {
+		to := target
+		if to-w.next >= w.BatchSize {
+			to = w.next + w.BatchSize - 1
+		}
+		if err := w.process(ctx, w.next, to, target); err != nil {
+			return err
+		}
+		w.next = to + 1
+		if err := w.checkpoint.Save(to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unwind reverts the processed blocks that are no longer in the chain
+func (w *Watcher) unwind(ctx context.Context) error {
+	for len(w.recent) > 0 {
+		last := w.recent[len(w.recent)-1]
+		header, err := w.reader.HeaderByNumber(ctx, new(big.Int).SetUint64(last.number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return err
+		}
+		if err == nil && header.Hash() == last.hash {
+			return nil
+		}
+		log.Infow("chain reorganized", "block", last.number, "hash", last.hash.Hex())
+		for i := len(last.events) - 1; i >= 0; i-- {
+			e := last.events[i]
+			e.Removed = true
+			w.handler(e)
+		}
+		w.recent = w.recent[:len(w.recent)-1]
+		w.next = last.number
+		if err := w.checkpoint.Save(last.number - 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process hands out the events of the blocks from..to, the blocks within the
+// reorg window of the target are remembered
+func (w *Watcher) process(ctx context.Context, from, to, target uint64) error {
+	var addresses []common.Address
+	var topics []common.Hash
+	scanTx := false
+	for _, watch := range w.watches {
+		if watch.Topic == (common.Hash{}) {
+			scanTx = true
+			continue
+		}
+		addresses = append(addresses, watch.Address)
+		topics = append(topics, watch.Topic)
+	}
+	var logs []types.Log
+	if len(topics) > 0 {
+		var err error
+		logs, err = w.reader.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: addresses,
+			Topics:    [][]common.Hash{topics},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	window := uint64(0)
+	if target > w.ReorgWindow {
+		window = target - w.ReorgWindow
+	}
+	for number := from; number <= to; number++ {
+		var blockLogs []types.Log
+		for len(logs) > 0 && logs[0].BlockNumber == number {
+			blockLogs = append(blockLogs, logs[0])
+			logs = logs[1:]
+		}
+		if number <= window && !scanTx {
+			w.emit(w.logEvents(blockLogs))
+			continue
+		}
+		block, err := w.reader.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return err
+		}
+		for _, l := range blockLogs {
+			if l.BlockHash != block.Hash() {
+				return errBlockChanged
+			}
+		}
+		txEvents, err := w.txEvents(ctx, block)
+		if err != nil {
+			return err
+		}
+		events := append(w.logEvents(blockLogs), txEvents...)
+		w.emit(events)
+		if number <= window {
+			continue
+		}
+		w.recent = append(w.recent, processedBlock{number: number, hash: block.Hash(), events: events})
+		if uint64(len(w.recent)) > w.ReorgWindow {
+			w.recent = w.recent[1:]
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) emit(events []Event) {
+	for _, e := range events {
+		w.handler(e)
+	}
+}
+
+func (w *Watcher) logEvents(logs []types.Log) []Event {
+	var events []Event
+	for _, l := range logs {
+		if l.Removed || len(l.Topics) == 0 {
+			continue
+		}
+		for _, watch := range w.watches {
+			if watch.Address != l.Address || watch.Topic != l.Topics[0] {
+				continue
+			}
+			e := Event{Name: watch.Name, Block: l.BlockNumber, TxHash: l.TxHash, Data: l}
+			if watch.Parse != nil {
+				data, err := watch.Parse(l)
+				if err != nil {
+					log.Errorw("parse contract log", "event", watch.Name, "tx", l.TxHash.Hex(), "error", err)
+					continue
+				}
+				e.Data = data
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (w *Watcher) txEvents(ctx context.Context, block *types.Block) ([]Event, error) {
+	var events []Event
+	for _, watch := range w.watches {
+		if watch.Topic != (common.Hash{}) {
+			continue
+		}
+		for _, tx := range block.Transactions() {
+			if tx.To() == nil || *tx.To() != watch.Address {
+				continue
+			}
+			receipt, err := w.reader.TransactionReceipt(ctx, tx.Hash())
+			if err != nil {
+				return nil, err
+			}
+			if receipt.BlockHash != block.Hash() {
+				return nil, errBlockChanged
+			}
+			if receipt.Status == types.ReceiptStatusFailed {
+				continue
+			}
+			events = append(events, Event{Name: watch.Name, Block: block.NumberU64(), TxHash: tx.Hash(), Data: tx})
+		}
+	}
+	return events, nil
+}
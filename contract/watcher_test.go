@@ -0,0 +1,246 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	watchedAddr = common.HexToAddress("0x01")
+	nodeAddr    = common.HexToAddress("0x02")
+	watchedID   = common.HexToHash("0xaa")
+)
+
+// fakeChain keeps one canonical chain, a fork replaces the blocks from a number on
+type fakeChain struct {
+	mut      sync.Mutex
+	blocks   []*types.Block
+	logs     map[common.Hash][]types.Log
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newFakeChain() *fakeChain {
+	c := &fakeChain{logs: make(map[common.Hash][]types.Log), receipts: make(map[common.Hash]*types.Receipt)}
+	c.blocks = []*types.Block{types.NewBlock(&types.Header{Number: big.NewInt(0)}, nil, nil, nil)}
+	return c
+}
+
+// add appends a block with a log for every value and a transaction to the node contract when tx is set
+func (c *fakeChain) add(fork string, tx bool, values ...string) {
+	c.addBlock(fork, tx, types.ReceiptStatusSuccessful, values...)
+}
+
+// addReverted appends a block with a log for every value and a reverted transaction to the node contract
+func (c *fakeChain) addReverted(values ...string) {
+	c.addBlock("", true, types.ReceiptStatusFailed, values...)
+}
+
+func (c *fakeChain) addBlock(fork string, tx bool, status uint64, values ...string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	parent := c.blocks[len(c.blocks)-1]
+	header := &types.Header{
+		Number:     big.NewInt(int64(len(c.blocks))),
+		ParentHash: parent.Hash(),
+		Extra:      []byte(fork),
+	}
+	var txs []*types.Transaction
+	if tx {
+		txs = append(txs, types.NewTransaction(uint64(len(c.blocks)), nodeAddr, big.NewInt(0), 21000, big.NewInt(1), nil))
+	}
+	block := types.NewBlock(header, txs, nil, nil)
+	for _, tx := range txs {
+		c.receipts[tx.Hash()] = &types.Receipt{Status: status, TxHash: tx.Hash(), BlockHash: block.Hash(), BlockNumber: block.Number()}
+	}
+	for i, value := range values {
+		c.logs[block.Hash()] = append(c.logs[block.Hash()], types.Log{
+			Address:     watchedAddr,
+			Topics:      []common.Hash{watchedID},
+			Data:        []byte(value),
+			BlockNumber: block.NumberU64(),
+			BlockHash:   block.Hash(),
+			Index:       uint(i),
+		})
+	}
+	c.blocks = append(c.blocks, block)
+}
+
+// rewind drops the blocks from the number on
+func (c *fakeChain) rewind(number int) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.blocks = c.blocks[:number]
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	block, err := c.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (c *fakeChain) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if number == nil {
+		return c.blocks[len(c.blocks)-1], nil
+	}
+	if number.Int64() >= int64(len(c.blocks)) {
+		return nil, ethereum.NotFound
+	}
+	return c.blocks[number.Int64()], nil
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	var logs []types.Log
+	for n := q.FromBlock.Int64(); n <= q.ToBlock.Int64() && n < int64(len(c.blocks)); n++ {
+		logs = append(logs, c.logs[c.blocks[n].Hash()]...)
+	}
+	return logs, nil
+}
+
+func (c *fakeChain) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	receipt, b := c.receipts[txHash]
+	if !b {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (c *fakeChain) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+type memoryStore struct {
+	values map[string][]byte
+}
+
+func (m *memoryStore) Get(key string) ([]byte, error) {
+	v, b := m.values[key]
+	if !b {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (m *memoryStore) Set(key string, val []byte) error {
+	m.values[key] = val
+	return nil
+}
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) handle(e Event) {
+	value := ""
+	switch v := e.Data.(type) {
+	case types.Log:
+		value = string(v.Data)
+	case *types.Transaction:
+		value = "tx"
+	}
+	if e.Removed {
+		value = "-" + value
+	}
+	r.events = append(r.events, fmt.Sprintf("%d:%s", e.Block, value))
+}
+
+func (r *recorder) take() string {
+	out := fmt.Sprint(r.events)
+	r.events = nil
+	return out
+}
+
+func newTestWatcher(chain *fakeChain, checkpoint Checkpoint, r *recorder) *Watcher {
+	w := NewWatcher(chain, checkpoint, r.handle,
+		Watch{Name: "Watched", Address: watchedAddr, Topic: watchedID},
+		Watch{Name: EventNodeList, Address: nodeAddr},
+	)
+	w.Confirmations = 1
+	w.ReorgWindow = 4
+	w.BatchSize = 3
+	return w
+}
+
+func TestWatcherResume(t *testing.T) {
+	chain := newFakeChain()
+	for i := 1; i <= 10; i++ {
+		if i == 7 {
+			chain.addReverted(fmt.Sprint("v", i))
+			continue
+		}
+		chain.add("", i == 5 || i == 9, fmt.Sprint("v", i))
+	}
+	checkpoint := NewStoreCheckpoint(&memoryStore{values: make(map[string][]byte)}, "block")
+	r := &recorder{}
+	w := newTestWatcher(chain, checkpoint, r)
+	w.Start = 4
+	if err := w.Step(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// block 10 waits for a confirmation, transactions are scanned before the reorg
+	// window too and the reverted one is left out
+	if got := r.take(); got != "[4:v4 5:v5 5:tx 6:v6 7:v7 8:v8 9:v9 9:tx]" {
+		t.Fatalf("events %s", got)
+	}
+	if block, _, _ := checkpoint.Load(); block != 9 {
+		t.Fatalf("checkpoint %d", block)
+	}
+
+	// a restarted watcher goes on after the checkpoint
+	chain.add("", false, "v11")
+	w = newTestWatcher(chain, checkpoint, r)
+	if err := w.Step(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.take(); got != "[10:v10]" {
+		t.Fatalf("events after restart %s", got)
+	}
+}
+
+func TestWatcherReorg(t *testing.T) {
+	chain := newFakeChain()
+	for i := 1; i <= 8; i++ {
+		chain.add("", false, fmt.Sprint("v", i))
+	}
+	checkpoint := NewStoreCheckpoint(&memoryStore{values: make(map[string][]byte)}, "block")
+	r := &recorder{}
+	w := newTestWatcher(chain, checkpoint, r)
+	w.Start = 6
+	if err := w.Step(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.take(); got != "[6:v6 7:v7]" {
+		t.Fatalf("events %s", got)
+	}
+
+	// blocks 6 and later are replaced by a longer fork
+	chain.rewind(6)
+	chain.add("fork", true, "f6")
+	chain.add("fork", false)
+	chain.add("fork", false, "f8a", "f8b")
+	chain.add("fork", false)
+	if err := w.Step(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.take(); got != "[7:-v7 6:-v6 6:f6 6:tx 8:f8a 8:f8b]" {
+		t.Fatalf("events after reorg %s", got)
+	}
+	if block, _, _ := checkpoint.Load(); block != 8 {
+		t.Fatalf("checkpoint %d", block)
+	}
+}
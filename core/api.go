@@ -0,0 +1,20 @@
+package core
+
+// PinRequest is the body of POST /api/v1/pins
+type PinRequest struct {
+	No string `json:"no"`
+}
+
+// PinResult ...
+type PinResult struct {
+	No     string `json:"no"`
+	Pinned bool   `json:"pinned"`
+}
+
+// APIError is the body of a failed rest request
+type APIError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
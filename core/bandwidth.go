@@ -0,0 +1,50 @@
+package core
+
+// Traffic ...
+type Traffic struct {
+	In  uint64 `json:"in"`  //bytes fetched
+	Out uint64 `json:"out"` //bytes served
+}
+
+// DayTraffic ...
+type DayTraffic struct {
+	Day string `json:"day"`
+	Traffic
+}
+
+// TrafficCounter keeps the total traffic and the traffic of the recent days
+type TrafficCounter struct {
+	Total Traffic      `json:"total"`
+	Days  []DayTraffic `json:"days"`
+}
+
+// Add counts the traffic for the day, only the last days are kept
+func (c *TrafficCounter) Add(day string, t Traffic, days int) {
+	c.Total.In += t.In
+	c.Total.Out += t.Out
+	if n := len(c.Days); n > 0 && c.Days[n-1].Day == day {
+		c.Days[n-1].In += t.In
+		c.Days[n-1].Out += t.Out
+		return
+	}
+	c.Days = append(c.Days, DayTraffic{Day: day, Traffic: t})
+	if len(c.Days) > days {
+		c.Days = c.Days[len(c.Days)-days:]
+	}
+}
+
+// Recent sums the traffic of the kept days
+func (c *TrafficCounter) Recent() Traffic {
+	var t Traffic
+	for _, d := range c.Days {
+		t.In += d.In
+		t.Out += d.Out
+	}
+	return t
+}
+
+// Bandwidth is the traffic of a node by peer and by video number
+type Bandwidth struct {
+	Peers  map[string]*TrafficCounter `json:"peers"`
+	Videos map[string]*TrafficCounter `json:"videos"`
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Challenge asks a node for the hash of a byte range of a block it pins
+type Challenge struct {
+	Hash   string `json:"hash"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Nonce  string `json:"nonce"`
+}
+
+// ChallengeResponse ...
+type ChallengeResponse struct {
+	Answer string `json:"answer"`
+}
+
+// Answer hashes the nonce and the challenged range of the block
+func (c *Challenge) Answer(block []byte) (string, error) {
+	if c.Offset < 0 || c.Length <= 0 || c.Offset+c.Length > len(block) {
+		return "", fmt.Errorf("challenge range %d+%d out of block size %d", c.Offset, c.Length, len(block))
+	}
+	h := sha256.New()
+	h.Write([]byte(c.Nonce))
+	h.Write(block[c.Offset : c.Offset+c.Length])
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
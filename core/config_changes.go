@@ -0,0 +1,7 @@
+package core
+
+// ConfigChanges lists the config keys a reload applied and the ones that wait for a restart
+type ConfigChanges struct {
+	Applied []string `json:"applied"`
+	Restart []string `json:"restart"`
+}
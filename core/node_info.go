@@ -2,13 +2,27 @@ package core
 
 // NodeInfo ...
 type NodeInfo struct {
-	Name       string
-	Schema     string
-	RemoteAddr string
-	Port       int
-	Contract   ContractNode
-	DataStore  DataStoreNode
-	Version    string
+	Name         string
+	Schema       string
+	RemoteAddr   string
+	Port         int
+	Contract     ContractNode
+	DataStore    DataStoreNode
+	Version      string
+	Protocol     int      `json:",omitempty"` //peer protocol version, 0 for nodes from before the negotiation
+	MinProtocol  int      `json:",omitempty"` //oldest peer protocol the node talks to
+	Capabilities []string `json:",omitempty"`
+	GRPCPort     int      `json:",omitempty"`
+}
+
+// Has reports whether the node announced the capability
+func (n *NodeInfo) Has(capability string) bool {
+	for _, c := range n.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
 }
 
 // Address ...
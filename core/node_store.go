@@ -5,9 +5,6 @@ import (
 	"sync"
 )
 
-// Version ...
-const Version = "0.0.1"
-
 // DataStoreNode ...
 type DataStoreNode struct {
 	ID              string   `json:"ID"`
@@ -78,16 +75,18 @@ type _Node struct {
 
 // nodeStore ...
 type nodeStore struct {
+	// mut orders the changes of the membership, reads go to the map directly
+	mut      sync.Mutex
 	nodes    sync.Map
 	nodeSize *atomic.Int64
 }
 
 // NodeStore ...
 type NodeStore interface {
-	Add(info *NodeInfo)
+	Add(info *NodeInfo) bool
 	Check(key string) bool
 	Get(key string) *NodeInfo
-	Remove(key string)
+	Remove(key string) bool
 	Length() int64
 	Range(func(info *NodeInfo) bool)
 }
@@ -100,18 +99,29 @@ func NewNodeStore() NodeStore {
 	}
 }
 
-// Remove ...
-func (s *nodeStore) Remove(key string) {
-	if s.Check(key) {
-		s.nodeSize.Add(-1)
-		s.nodes.Delete(key)
+// Remove returns false when the node was not in the store
+func (s *nodeStore) Remove(key string) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if !s.Check(key) {
+		return false
 	}
+	s.nodeSize.Add(-1)
+	s.nodes.Delete(key)
+	return true
 }
 
-// Add ...
-func (s *nodeStore) Add(info *NodeInfo) {
+// Add stores the info, it returns false when the node was in the store already
+func (s *nodeStore) Add(info *NodeInfo) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	known := s.Check(info.Name)
 	s.nodes.Store(info.Name, info)
+	if known {
+		return false
+	}
 	s.nodeSize.Add(1)
+	return true
 }
 
 // Check ...
@@ -0,0 +1,66 @@
+package core
+
+import "encoding/json"
+
+// Topics of the notifications a client can subscribe to
+const (
+	TopicPeerJoined     = "peer.joined"
+	TopicPeerLeft       = "peer.left"
+	TopicPinProgress    = "pin.progress"
+	TopicVideoPublished = "video.published"
+	TopicSyncDone       = "sync.done"
+)
+
+// Topics ...
+var Topics = []string{TopicPeerJoined, TopicPeerLeft, TopicPinProgress, TopicVideoPublished, TopicSyncDone}
+
+// States of a pin job
+const (
+	PinQueued  = "queued"
+	PinPinning = "pinning"
+	PinPinned  = "pinned"
+	PinSkipped = "skipped"
+	PinFailed  = "failed"
+	// PinReverted is sent when the pin transaction was reorganized away
+	PinReverted = "reverted"
+)
+
+// Notification is one event of the daemon, Data is one of the event types below
+type Notification struct {
+	Topic string          `json:"topic"`
+	Time  int64           `json:"time"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Decode ...
+func (n *Notification) Decode(v interface{}) error {
+	return json.Unmarshal(n.Data, v)
+}
+
+// PeerEvent is sent when a peer joined or left the node
+type PeerEvent struct {
+	Node *NodeInfo `json:"node"`
+}
+
+// PinProgress is sent when a pin job changes its state
+type PinProgress struct {
+	Hash  string `json:"hash"`
+	No    string `json:"no,omitempty"`
+	User  string `json:"user,omitempty"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// VideoPublished is sent when a video message was added on the chain
+type VideoPublished struct {
+	No      string `json:"no"`
+	Block   uint64 `json:"block"`
+	Tx      string `json:"tx"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+// SyncDone is sent when a sync cycle with the peers finished
+type SyncDone struct {
+	Peers    int64 `json:"peers"`
+	Duration int64 `json:"duration"` // milliseconds
+}
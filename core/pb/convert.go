@@ -0,0 +1,85 @@
+package pb
+
+import (
+	"encoding/json"
+
+	"github.com/glvd/accipfs/core"
+)
+
+// NewNodeInfo ...
+func NewNodeInfo(info *core.NodeInfo) *NodeInfo {
+	protocols, _ := json.Marshal(&info.Contract.Protocols)
+	return &NodeInfo{
+		Name:       info.Name,
+		Schema:     info.Schema,
+		RemoteAddr: info.RemoteAddr,
+		Port:       int32(info.Port),
+		Contract: &ContractNode{
+			Enode:      info.Contract.Enode,
+			Enr:        info.Contract.Enr,
+			Id:         info.Contract.ID,
+			Ip:         info.Contract.IP,
+			ListenAddr: info.Contract.ListenAddr,
+			Name:       info.Contract.Name,
+			Ports: &Ports{
+				Discovery: info.Contract.Ports.Discovery,
+				Listener:  info.Contract.Ports.Listener,
+			},
+			Protocols: protocols,
+		},
+		DataStore: &DataStoreNode{
+			Id:              info.DataStore.ID,
+			PublicKey:       info.DataStore.PublicKey,
+			Addresses:       info.DataStore.Addresses,
+			AgentVersion:    info.DataStore.AgentVersion,
+			ProtocolVersion: info.DataStore.ProtocolVersion,
+		},
+		Version:      info.Version,
+		Capabilities: info.Capabilities,
+		GrpcPort:     int32(info.GRPCPort),
+		Protocol:     int32(info.Protocol),
+		MinProtocol:  int32(info.MinProtocol),
+	}
+}
+
+// Info returns the core node info of the message
+func (m *NodeInfo) Info() *core.NodeInfo {
+	info := &core.NodeInfo{
+		Name:         m.GetName(),
+		Schema:       m.GetSchema(),
+		RemoteAddr:   m.GetRemoteAddr(),
+		Port:         int(m.GetPort()),
+		Version:      m.GetVersion(),
+		Capabilities: m.GetCapabilities(),
+		GRPCPort:     int(m.GetGrpcPort()),
+		Protocol:     int(m.GetProtocol()),
+		MinProtocol:  int(m.GetMinProtocol()),
+	}
+	if c := m.GetContract(); c != nil {
+		info.Contract = core.ContractNode{
+			Enode:      c.GetEnode(),
+			Enr:        c.GetEnr(),
+			ID:         c.GetId(),
+			IP:         c.GetIp(),
+			ListenAddr: c.GetListenAddr(),
+			Name:       c.GetName(),
+			Ports: core.Ports{
+				Discovery: c.GetPorts().GetDiscovery(),
+				Listener:  c.GetPorts().GetListener(),
+			},
+		}
+		if len(c.GetProtocols()) > 0 {
+			_ = json.Unmarshal(c.GetProtocols(), &info.Contract.Protocols)
+		}
+	}
+	if d := m.GetDataStore(); d != nil {
+		info.DataStore = core.DataStoreNode{
+			ID:              d.GetId(),
+			PublicKey:       d.GetPublicKey(),
+			Addresses:       d.GetAddresses(),
+			AgentVersion:    d.GetAgentVersion(),
+			ProtocolVersion: d.GetProtocolVersion(),
+		}
+	}
+	return info
+}
@@ -0,0 +1,4 @@
+// Package pb holds the protobuf messages and the grpc service of the peer protocol.
+package pb
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. peer.proto
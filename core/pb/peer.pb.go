@@ -0,0 +1,763 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: peer.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{0}
+}
+
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+type Pong struct {
+	Message              string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Pong) Reset()         { *m = Pong{} }
+func (m *Pong) String() string { return proto.CompactTextString(m) }
+func (*Pong) ProtoMessage()    {}
+func (*Pong) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{1}
+}
+
+func (m *Pong) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Pong.Unmarshal(m, b)
+}
+func (m *Pong) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Pong.Marshal(b, m, deterministic)
+}
+func (m *Pong) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Pong.Merge(m, src)
+}
+func (m *Pong) XXX_Size() int {
+	return xxx_messageInfo_Pong.Size(m)
+}
+func (m *Pong) XXX_DiscardUnknown() {
+	xxx_messageInfo_Pong.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Pong proto.InternalMessageInfo
+
+func (m *Pong) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type Ports struct {
+	Discovery            int64    `protobuf:"varint,1,opt,name=discovery,proto3" json:"discovery,omitempty"`
+	Listener             int64    `protobuf:"varint,2,opt,name=listener,proto3" json:"listener,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Ports) Reset()         { *m = Ports{} }
+func (m *Ports) String() string { return proto.CompactTextString(m) }
+func (*Ports) ProtoMessage()    {}
+func (*Ports) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{2}
+}
+
+func (m *Ports) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Ports.Unmarshal(m, b)
+}
+func (m *Ports) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Ports.Marshal(b, m, deterministic)
+}
+func (m *Ports) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Ports.Merge(m, src)
+}
+func (m *Ports) XXX_Size() int {
+	return xxx_messageInfo_Ports.Size(m)
+}
+func (m *Ports) XXX_DiscardUnknown() {
+	xxx_messageInfo_Ports.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Ports proto.InternalMessageInfo
+
+func (m *Ports) GetDiscovery() int64 {
+	if m != nil {
+		return m.Discovery
+	}
+	return 0
+}
+
+func (m *Ports) GetListener() int64 {
+	if m != nil {
+		return m.Listener
+	}
+	return 0
+}
+
+type ContractNode struct {
+	Enode                string   `protobuf:"bytes,1,opt,name=enode,proto3" json:"enode,omitempty"`
+	Enr                  string   `protobuf:"bytes,2,opt,name=enr,proto3" json:"enr,omitempty"`
+	Id                   string   `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Ip                   string   `protobuf:"bytes,4,opt,name=ip,proto3" json:"ip,omitempty"`
+	ListenAddr           string   `protobuf:"bytes,5,opt,name=listen_addr,json=listenAddr,proto3" json:"listen_addr,omitempty"`
+	Name                 string   `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	Ports                *Ports   `protobuf:"bytes,7,opt,name=ports,proto3" json:"ports,omitempty"`
+	Protocols            []byte   `protobuf:"bytes,8,opt,name=protocols,proto3" json:"protocols,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ContractNode) Reset()         { *m = ContractNode{} }
+func (m *ContractNode) String() string { return proto.CompactTextString(m) }
+func (*ContractNode) ProtoMessage()    {}
+func (*ContractNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{3}
+}
+
+func (m *ContractNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContractNode.Unmarshal(m, b)
+}
+func (m *ContractNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContractNode.Marshal(b, m, deterministic)
+}
+func (m *ContractNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContractNode.Merge(m, src)
+}
+func (m *ContractNode) XXX_Size() int {
+	return xxx_messageInfo_ContractNode.Size(m)
+}
+func (m *ContractNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContractNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContractNode proto.InternalMessageInfo
+
+func (m *ContractNode) GetEnode() string {
+	if m != nil {
+		return m.Enode
+	}
+	return ""
+}
+
+func (m *ContractNode) GetEnr() string {
+	if m != nil {
+		return m.Enr
+	}
+	return ""
+}
+
+func (m *ContractNode) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *ContractNode) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+func (m *ContractNode) GetListenAddr() string {
+	if m != nil {
+		return m.ListenAddr
+	}
+	return ""
+}
+
+func (m *ContractNode) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ContractNode) GetPorts() *Ports {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+func (m *ContractNode) GetProtocols() []byte {
+	if m != nil {
+		return m.Protocols
+	}
+	return nil
+}
+
+type DataStoreNode struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	PublicKey            string   `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Addresses            []string `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	AgentVersion         string   `protobuf:"bytes,4,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	ProtocolVersion      string   `protobuf:"bytes,5,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DataStoreNode) Reset()         { *m = DataStoreNode{} }
+func (m *DataStoreNode) String() string { return proto.CompactTextString(m) }
+func (*DataStoreNode) ProtoMessage()    {}
+func (*DataStoreNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{4}
+}
+
+func (m *DataStoreNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DataStoreNode.Unmarshal(m, b)
+}
+func (m *DataStoreNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DataStoreNode.Marshal(b, m, deterministic)
+}
+func (m *DataStoreNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DataStoreNode.Merge(m, src)
+}
+func (m *DataStoreNode) XXX_Size() int {
+	return xxx_messageInfo_DataStoreNode.Size(m)
+}
+func (m *DataStoreNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_DataStoreNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DataStoreNode proto.InternalMessageInfo
+
+func (m *DataStoreNode) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *DataStoreNode) GetPublicKey() string {
+	if m != nil {
+		return m.PublicKey
+	}
+	return ""
+}
+
+func (m *DataStoreNode) GetAddresses() []string {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+func (m *DataStoreNode) GetAgentVersion() string {
+	if m != nil {
+		return m.AgentVersion
+	}
+	return ""
+}
+
+func (m *DataStoreNode) GetProtocolVersion() string {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return ""
+}
+
+type NodeInfo struct {
+	Name                 string         `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Schema               string         `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	RemoteAddr           string         `protobuf:"bytes,3,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	Port                 int32          `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
+	Contract             *ContractNode  `protobuf:"bytes,5,opt,name=contract,proto3" json:"contract,omitempty"`
+	DataStore            *DataStoreNode `protobuf:"bytes,6,opt,name=data_store,json=dataStore,proto3" json:"data_store,omitempty"`
+	Version              string         `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"`
+	Capabilities         []string       `protobuf:"bytes,8,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	GrpcPort             int32          `protobuf:"varint,9,opt,name=grpc_port,json=grpcPort,proto3" json:"grpc_port,omitempty"`
+	Protocol             int32          `protobuf:"varint,10,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	MinProtocol          int32          `protobuf:"varint,11,opt,name=min_protocol,json=minProtocol,proto3" json:"min_protocol,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *NodeInfo) Reset()         { *m = NodeInfo{} }
+func (m *NodeInfo) String() string { return proto.CompactTextString(m) }
+func (*NodeInfo) ProtoMessage()    {}
+func (*NodeInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{5}
+}
+
+func (m *NodeInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeInfo.Unmarshal(m, b)
+}
+func (m *NodeInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeInfo.Marshal(b, m, deterministic)
+}
+func (m *NodeInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeInfo.Merge(m, src)
+}
+func (m *NodeInfo) XXX_Size() int {
+	return xxx_messageInfo_NodeInfo.Size(m)
+}
+func (m *NodeInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeInfo proto.InternalMessageInfo
+
+func (m *NodeInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetSchema() string {
+	if m != nil {
+		return m.Schema
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetRemoteAddr() string {
+	if m != nil {
+		return m.RemoteAddr
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetContract() *ContractNode {
+	if m != nil {
+		return m.Contract
+	}
+	return nil
+}
+
+func (m *NodeInfo) GetDataStore() *DataStoreNode {
+	if m != nil {
+		return m.DataStore
+	}
+	return nil
+}
+
+func (m *NodeInfo) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *NodeInfo) GetCapabilities() []string {
+	if m != nil {
+		return m.Capabilities
+	}
+	return nil
+}
+
+func (m *NodeInfo) GetGrpcPort() int32 {
+	if m != nil {
+		return m.GrpcPort
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetProtocol() int32 {
+	if m != nil {
+		return m.Protocol
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetMinProtocol() int32 {
+	if m != nil {
+		return m.MinProtocol
+	}
+	return 0
+}
+
+type PinChunk struct {
+	Hashes               []string `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PinChunk) Reset()         { *m = PinChunk{} }
+func (m *PinChunk) String() string { return proto.CompactTextString(m) }
+func (*PinChunk) ProtoMessage()    {}
+func (*PinChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_055ae5a865fc1c9e, []int{6}
+}
+
+func (m *PinChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PinChunk.Unmarshal(m, b)
+}
+func (m *PinChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PinChunk.Marshal(b, m, deterministic)
+}
+func (m *PinChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PinChunk.Merge(m, src)
+}
+func (m *PinChunk) XXX_Size() int {
+	return xxx_messageInfo_PinChunk.Size(m)
+}
+func (m *PinChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_PinChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PinChunk proto.InternalMessageInfo
+
+func (m *PinChunk) GetHashes() []string {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "pb.Empty")
+	proto.RegisterType((*Pong)(nil), "pb.Pong")
+	proto.RegisterType((*Ports)(nil), "pb.Ports")
+	proto.RegisterType((*ContractNode)(nil), "pb.ContractNode")
+	proto.RegisterType((*DataStoreNode)(nil), "pb.DataStoreNode")
+	proto.RegisterType((*NodeInfo)(nil), "pb.NodeInfo")
+	proto.RegisterType((*PinChunk)(nil), "pb.PinChunk")
+}
+
+func init() { proto.RegisterFile("peer.proto", fileDescriptor_055ae5a865fc1c9e) }
+
+var fileDescriptor_055ae5a865fc1c9e = []byte{
+	// 598 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x53, 0xd1, 0x6e, 0xd3, 0x3c,
+	0x14, 0x56, 0xda, 0x66, 0x6d, 0x4e, 0xbb, 0xff, 0x1f, 0x16, 0x42, 0xd1, 0x06, 0x5a, 0x17, 0x2e,
+	0x18, 0x12, 0x6a, 0xa7, 0xf2, 0x04, 0x63, 0x70, 0x81, 0x90, 0x50, 0x15, 0x24, 0x2e, 0xb8, 0xa9,
+	0x1c, 0xfb, 0x2c, 0xb5, 0xd6, 0xd8, 0x96, 0xed, 0x4d, 0xea, 0x73, 0xf0, 0x1a, 0x3c, 0x09, 0xef,
+	0xc1, 0x7b, 0x20, 0xdb, 0x49, 0xb6, 0x8a, 0x3b, 0x7f, 0xdf, 0x39, 0xb1, 0xbf, 0xef, 0x3b, 0x27,
+	0x00, 0x1a, 0xd1, 0x2c, 0xb4, 0x51, 0x4e, 0x91, 0x81, 0xae, 0x8a, 0x31, 0xa4, 0x9f, 0x1a, 0xed,
+	0xf6, 0xc5, 0x1c, 0x46, 0x6b, 0x25, 0x6b, 0x92, 0xc3, 0xb8, 0x41, 0x6b, 0x69, 0x8d, 0x79, 0x32,
+	0x4f, 0x2e, 0xb3, 0xb2, 0x83, 0xc5, 0x35, 0xa4, 0x6b, 0x65, 0x9c, 0x25, 0x2f, 0x21, 0xe3, 0xc2,
+	0x32, 0xf5, 0x80, 0x66, 0x1f, 0x9a, 0x86, 0xe5, 0x23, 0x41, 0x4e, 0x61, 0xb2, 0x13, 0xd6, 0xa1,
+	0x44, 0x93, 0x0f, 0x42, 0xb1, 0xc7, 0xc5, 0xef, 0x04, 0x66, 0x37, 0x4a, 0x3a, 0x43, 0x99, 0xfb,
+	0xaa, 0x38, 0x92, 0xe7, 0x90, 0xa2, 0x54, 0xbc, 0x7b, 0x2b, 0x02, 0x72, 0x02, 0x43, 0x94, 0xf1,
+	0xeb, 0xac, 0xf4, 0x47, 0xf2, 0x1f, 0x0c, 0x04, 0xcf, 0x87, 0x81, 0x18, 0x08, 0x1e, 0xb0, 0xce,
+	0x47, 0x2d, 0xd6, 0xe4, 0x1c, 0xa6, 0xf1, 0x91, 0x0d, 0xe5, 0xdc, 0xe4, 0x69, 0x28, 0x40, 0xa4,
+	0xae, 0x39, 0x37, 0x84, 0xc0, 0x48, 0xd2, 0x06, 0xf3, 0xa3, 0x50, 0x09, 0x67, 0x72, 0x0e, 0xa9,
+	0xf6, 0x86, 0xf2, 0xf1, 0x3c, 0xb9, 0x9c, 0xae, 0xb2, 0x85, 0xae, 0x16, 0xc1, 0x61, 0x99, 0xea,
+	0xce, 0x68, 0x48, 0x8a, 0xa9, 0x9d, 0xcd, 0x27, 0xf3, 0xe4, 0x72, 0x56, 0x3e, 0x12, 0xc5, 0xaf,
+	0x04, 0x8e, 0x3f, 0x52, 0x47, 0xbf, 0x39, 0x65, 0x30, 0xb8, 0x89, 0x2a, 0x93, 0x5e, 0xe5, 0x2b,
+	0x00, 0x7d, 0x5f, 0xed, 0x04, 0xdb, 0xdc, 0xe1, 0xbe, 0xb5, 0x93, 0x45, 0xe6, 0x0b, 0xee, 0xfd,
+	0xf5, 0x5e, 0x2d, 0x5a, 0x8b, 0x36, 0x1f, 0xce, 0x87, 0xbe, 0xda, 0x13, 0xe4, 0x35, 0x1c, 0xd3,
+	0x1a, 0xa5, 0xdb, 0x3c, 0xa0, 0xb1, 0x42, 0xc9, 0xd6, 0xed, 0x2c, 0x90, 0xdf, 0x23, 0x47, 0xde,
+	0xc2, 0x49, 0x27, 0xa8, 0xef, 0x8b, 0xe6, 0xff, 0xef, 0xf8, 0xb6, 0xb5, 0xf8, 0x33, 0x80, 0x89,
+	0x57, 0xf9, 0x59, 0xde, 0xaa, 0x3e, 0x8e, 0xe4, 0x49, 0x1c, 0x2f, 0xe0, 0xc8, 0xb2, 0x2d, 0x36,
+	0xb4, 0x55, 0xda, 0x22, 0x9f, 0xad, 0xc1, 0x46, 0x39, 0x8c, 0xd9, 0xc6, 0x21, 0x40, 0xa4, 0xba,
+	0x6c, 0x7d, 0x5e, 0x41, 0x60, 0x5a, 0x86, 0x33, 0x79, 0x07, 0x13, 0xd6, 0x0e, 0x3a, 0x08, 0x9a,
+	0xae, 0x4e, 0x7c, 0xbc, 0x4f, 0x87, 0x5f, 0xf6, 0x1d, 0xe4, 0x0a, 0x80, 0x53, 0x47, 0x37, 0xd6,
+	0x47, 0x19, 0x66, 0x34, 0x5d, 0x3d, 0xf3, 0xfd, 0x07, 0xf9, 0x96, 0x19, 0xef, 0xa0, 0x5f, 0xd3,
+	0xce, 0xef, 0x38, 0xae, 0x69, 0x0b, 0x49, 0x01, 0x33, 0x46, 0x35, 0xad, 0xc4, 0x4e, 0x38, 0x81,
+	0x7e, 0x6e, 0x3e, 0xd8, 0x03, 0x8e, 0x9c, 0x41, 0x56, 0x1b, 0xcd, 0x36, 0x41, 0x76, 0x16, 0x64,
+	0x4f, 0x3c, 0xe1, 0xa7, 0xef, 0x17, 0xb8, 0xcb, 0x2e, 0x87, 0x58, 0xeb, 0x30, 0xb9, 0x80, 0x59,
+	0x23, 0xe4, 0xa6, 0xaf, 0x4f, 0x43, 0x7d, 0xda, 0x08, 0xb9, 0x6e, 0xa9, 0xa2, 0x80, 0xc9, 0x5a,
+	0xc8, 0x9b, 0xed, 0xbd, 0xbc, 0xf3, 0x91, 0x6e, 0xa9, 0xdd, 0xa2, 0xcd, 0x93, 0xa0, 0xa2, 0x45,
+	0xab, 0x9f, 0x09, 0x8c, 0xd6, 0x88, 0x86, 0x9c, 0xc1, 0x68, 0x2d, 0x64, 0x4d, 0xc2, 0xee, 0x85,
+	0x1f, 0xf1, 0x74, 0x12, 0xd7, 0x50, 0xd6, 0xe4, 0x0d, 0x64, 0x37, 0x4a, 0x4a, 0x64, 0x0e, 0x39,
+	0x99, 0x79, 0xba, 0x9b, 0xdf, 0xe9, 0x01, 0x22, 0x05, 0xa4, 0xfe, 0x36, 0xfb, 0xf4, 0x9a, 0x83,
+	0x8e, 0xab, 0x84, 0x5c, 0x84, 0x97, 0xfe, 0x6d, 0xe9, 0xb4, 0x5e, 0x25, 0x1f, 0x2e, 0x7e, 0x9c,
+	0xd7, 0xc2, 0x6d, 0xef, 0xab, 0x05, 0x53, 0xcd, 0xb2, 0xde, 0x3d, 0xf0, 0x25, 0x65, 0x4c, 0xe8,
+	0x5b, 0xbb, 0x64, 0xca, 0xe0, 0x52, 0x57, 0xd5, 0x51, 0x70, 0xfe, 0xfe, 0xef, 0x00, 0xea, 0x6f,
+	0x11, 0x7d, 0x47, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// PeerClient is the client API for Peer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PeerClient interface {
+	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Pong, error)
+	Connected(ctx context.Context, in *NodeInfo, opts ...grpc.CallOption) (*NodeInfo, error)
+	Peers(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Peer_PeersClient, error)
+	Pins(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Peer_PinsClient, error)
+}
+
+type peerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPeerClient(cc *grpc.ClientConn) PeerClient {
+	return &peerClient{cc}
+}
+
+func (c *peerClient) Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Pong, error) {
+	out := new(Pong)
+	err := c.cc.Invoke(ctx, "/pb.Peer/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) Connected(ctx context.Context, in *NodeInfo, opts ...grpc.CallOption) (*NodeInfo, error) {
+	out := new(NodeInfo)
+	err := c.cc.Invoke(ctx, "/pb.Peer/Connected", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) Peers(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Peer_PeersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Peer_serviceDesc.Streams[0], "/pb.Peer/Peers", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &peerPeersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Peer_PeersClient interface {
+	Recv() (*NodeInfo, error)
+	grpc.ClientStream
+}
+
+type peerPeersClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerPeersClient) Recv() (*NodeInfo, error) {
+	m := new(NodeInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *peerClient) Pins(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Peer_PinsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Peer_serviceDesc.Streams[1], "/pb.Peer/Pins", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &peerPinsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Peer_PinsClient interface {
+	Recv() (*PinChunk, error)
+	grpc.ClientStream
+}
+
+type peerPinsClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerPinsClient) Recv() (*PinChunk, error) {
+	m := new(PinChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PeerServer is the server API for Peer service.
+type PeerServer interface {
+	Ping(context.Context, *Empty) (*Pong, error)
+	Connected(context.Context, *NodeInfo) (*NodeInfo, error)
+	Peers(*Empty, Peer_PeersServer) error
+	Pins(*Empty, Peer_PinsServer) error
+}
+
+// UnimplementedPeerServer can be embedded to have forward compatible implementations.
+type UnimplementedPeerServer struct {
+}
+
+func (*UnimplementedPeerServer) Ping(ctx context.Context, req *Empty) (*Pong, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (*UnimplementedPeerServer) Connected(ctx context.Context, req *NodeInfo) (*NodeInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Connected not implemented")
+}
+func (*UnimplementedPeerServer) Peers(req *Empty, srv Peer_PeersServer) error {
+	return status.Errorf(codes.Unimplemented, "method Peers not implemented")
+}
+func (*UnimplementedPeerServer) Pins(req *Empty, srv Peer_PinsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Pins not implemented")
+}
+
+func RegisterPeerServer(s *grpc.Server, srv PeerServer) {
+	s.RegisterService(&_Peer_serviceDesc, srv)
+}
+
+func _Peer_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Peer/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).Ping(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_Connected_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).Connected(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Peer/Connected",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).Connected(ctx, req.(*NodeInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_Peers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerServer).Peers(m, &peerPeersServer{stream})
+}
+
+type Peer_PeersServer interface {
+	Send(*NodeInfo) error
+	grpc.ServerStream
+}
+
+type peerPeersServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerPeersServer) Send(m *NodeInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Peer_Pins_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerServer).Pins(m, &peerPinsServer{stream})
+}
+
+type Peer_PinsServer interface {
+	Send(*PinChunk) error
+	grpc.ServerStream
+}
+
+type peerPinsServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerPinsServer) Send(m *PinChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Peer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Peer",
+	HandlerType: (*PeerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Peer_Ping_Handler,
+		},
+		{
+			MethodName: "Connected",
+			Handler:    _Peer_Connected_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Peers",
+			Handler:       _Peer_Peers_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Pins",
+			Handler:       _Peer_Pins_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "peer.proto",
+}
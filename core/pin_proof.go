@@ -0,0 +1,28 @@
+package core
+
+import "encoding/json"
+
+// PinProof is kept by a node for every hash it pins for a pin transaction of a user
+type PinProof struct {
+	Hash      string `json:"hash"`
+	User      string `json:"user"`
+	Date      string `json:"date"`
+	Block     uint64 `json:"block"`
+	Tx        string `json:"tx"`
+	Node      string `json:"node"`
+	Account   string `json:"account"`
+	PinnedAt  int64  `json:"pinned_at"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Message returns the signed bytes of the proof
+func (p PinProof) Message() ([]byte, error) {
+	p.Signature = ""
+	return json.Marshal(p)
+}
+
+// RepoEntry is one hash of a user repo, the proof is set when this node serves it
+type RepoEntry struct {
+	Hash  string    `json:"hash"`
+	Proof *PinProof `json:"proof,omitempty"`
+}
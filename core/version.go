@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Version is the release of the node, Commit and BuildDate are set when it is built:
+//
+//	go build -ldflags "-X github.com/glvd/accipfs/core.Commit=$(git rev-parse --short HEAD) -X github.com/glvd/accipfs/core.BuildDate=$(date -u +%FT%TZ)"
+var (
+	Version   = "0.0.1"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// ProtocolVersion is the version of the peer protocol of this build, it grows
+// with every change a peer has to know about
+const ProtocolVersion = 1
+
+// MinProtocolVersion is the oldest peer protocol this build still talks to,
+// peers that announce no protocol are legacy nodes and kept with no capabilities
+const MinProtocolVersion = 1
+
+// Capabilities of a node, a peer only uses the ones both nodes announced
+const (
+	// CapabilityBatch the node answers json-rpc 2.0 batches
+	CapabilityBatch = "rpc.batch"
+	// CapabilityEvents the node serves the websocket subscriptions
+	CapabilityEvents = "events"
+	// CapabilityREST the node serves the rest api
+	CapabilityREST = "rest"
+	// CapabilityGRPC the node serves the peer protocol over grpc on GRPCPort
+	CapabilityGRPC = "grpc"
+)
+
+// BuildInfo ...
+type BuildInfo struct {
+	Version            string   `json:"version"`
+	Commit             string   `json:"commit,omitempty"`
+	BuildDate          string   `json:"build_date,omitempty"`
+	GoVersion          string   `json:"go_version"`
+	ProtocolVersion    int      `json:"protocol_version"`
+	MinProtocolVersion int      `json:"min_protocol_version"`
+	Capabilities       []string `json:"capabilities,omitempty"`
+}
+
+// Build returns the version of this build
+func Build() *BuildInfo {
+	return &BuildInfo{
+		Version:            Version,
+		Commit:             Commit,
+		BuildDate:          BuildDate,
+		GoVersion:          runtime.Version(),
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+	}
+}
+
+// String ...
+func (b *BuildInfo) String() string {
+	s := b.Version
+	if b.Commit != "" {
+		s += " (" + b.Commit
+		if b.BuildDate != "" {
+			s += " " + b.BuildDate
+		}
+		s += ")"
+	}
+	return fmt.Sprintf("%s protocol %d (min %d) %s", s, b.ProtocolVersion, b.MinProtocolVersion, b.GoVersion)
+}
+
+// IncompatibleError is returned for a peer whose protocol can not be spoken
+type IncompatibleError struct {
+	Name     string
+	Protocol int
+	Min      int
+}
+
+// Error ...
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("node %s speaks protocol %d (min %d), this node speaks %d (min %d)",
+		e.Name, e.Protocol, e.Min, ProtocolVersion, MinProtocolVersion)
+}
+
+// Compatible checks the protocol of a peer. Legacy peers that announce no
+// protocol are accepted, peers that are too old or require a newer protocol
+// are refused.
+func Compatible(remote *NodeInfo) error {
+	if remote.Protocol == 0 {
+		return nil
+	}
+	if remote.Protocol < MinProtocolVersion || remote.MinProtocol > ProtocolVersion {
+		return &IncompatibleError{Name: remote.Name, Protocol: remote.Protocol, Min: remote.MinProtocol}
+	}
+	return nil
+}
+
+// Common reports whether both nodes announced the capability, legacy peers have none
+func Common(local, remote *NodeInfo, capability string) bool {
+	return remote.Protocol > 0 && local.Has(capability) && remote.Has(capability)
+}
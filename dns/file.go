@@ -0,0 +1,173 @@
+package dns
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// file keeps the records in a hosts file, for dnsmasq or a local resolver
+type file struct {
+	mut  sync.Mutex
+	path string
+}
+
+// NewFile ...
+func NewFile(path string) Provider {
+	return &file{path: path}
+}
+
+// Name ...
+func (f *file) Name() string {
+	return "file"
+}
+
+type hostsLine struct {
+	text  string
+	ip    string
+	names []string
+}
+
+func (f *file) read() ([]hostsLine, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var lines []hostsLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := hostsLine{text: scanner.Text()}
+		content := line.text
+		if i := strings.Index(content, "#"); i >= 0 {
+			content = content[:i]
+		}
+		if fields := strings.Fields(content); len(fields) > 1 {
+			line.ip = fields[0]
+			line.names = fields[1:]
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func (f *file) write(lines []hostsLine) error {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line.text)
+		buf.WriteByte('\n')
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), ".hosts")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (l hostsLine) has(name string) bool {
+	for _, n := range l.names {
+		if sameName(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// without removes the name, the other names of the line stay
+func (l hostsLine) without(name string) hostsLine {
+	var names []string
+	for _, n := range l.names {
+		if !sameName(n, name) {
+			names = append(names, n)
+		}
+	}
+	return hostsLine{
+		text:  strings.Join(append([]string{l.ip}, names...), " "),
+		ip:    l.ip,
+		names: names,
+	}
+}
+
+// Normalize hosts files have no ttl either
+func (*file) Normalize(r Record) Record {
+	r.ID, r.Weight, r.Region, r.TTL = "", 0, "", 0
+	return r
+}
+
+// Records ...
+func (f *file) Records(ctx context.Context, name string) ([]Record, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	lines, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, line := range lines {
+		if line.ip == "" || !line.has(name) {
+			continue
+		}
+		records = append(records, Record{
+			Name:  name,
+			Type:  RecordType(line.ip),
+			Value: line.ip,
+		})
+	}
+	return records, nil
+}
+
+// Apply ...
+func (f *file) Apply(ctx context.Context, changes ChangeSet) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	lines, err := f.read()
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		name := strings.TrimSuffix(change.Record.Name, ".")
+		exists := false
+		var kept []hostsLine
+		for _, line := range lines {
+			if line.ip == change.Record.Value && line.has(name) {
+				exists = true
+				if change.Action == ActionDelete {
+					line = line.without(name)
+					if len(line.names) == 0 {
+						continue
+					}
+				}
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+		if change.Action == ActionUpsert && !exists {
+			lines = append(lines, hostsLine{
+				text:  change.Record.Value + " " + name,
+				ip:    change.Record.Value,
+				names: []string{name},
+			})
+		}
+	}
+	return f.write(lines)
+}
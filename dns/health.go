@@ -0,0 +1,78 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/glvd/accipfs/config"
+)
+
+// HealthChecker checks a gateway by dialing its port
+type HealthChecker struct {
+	Port    int
+	Timeout time.Duration
+}
+
+// NewHealthChecker ...
+func NewHealthChecker(cfg *config.Config) *HealthChecker {
+	h := &HealthChecker{
+		Port:    cfg.DNS.HealthPort,
+		Timeout: time.Duration(cfg.DNS.HealthTimeout) * time.Second,
+	}
+	if h.Port == 0 {
+		h.Port = 8545
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = 3 * time.Second
+	}
+	return h
+}
+
+// Measure returns how long it takes to connect to the ip
+func (h *HealthChecker) Measure(ctx context.Context, ip string) (time.Duration, error) {
+	dialer := net.Dialer{Timeout: h.Timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, strconv.Itoa(h.Port)))
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	_ = conn.Close()
+	return latency, nil
+}
+
+// Check ...
+func (h *HealthChecker) Check(ctx context.Context, ip string) bool {
+	_, err := h.Measure(ctx, ip)
+	return err == nil
+}
+
+// MeasureAll dials every ip at the same time, the failed ones are left out
+func (h *HealthChecker) MeasureAll(ctx context.Context, ips []string) map[string]time.Duration {
+	latency := make(map[string]time.Duration)
+	var mut sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]bool)
+	for _, ip := range ips {
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			l, err := h.Measure(ctx, ip)
+			if err != nil {
+				return
+			}
+			mut.Lock()
+			latency[ip] = l
+			mut.Unlock()
+		}(ip)
+	}
+	wg.Wait()
+	return latency
+}
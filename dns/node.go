@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+)
+
+// MaxWeight is the highest weight route53 accepts
+const MaxWeight = 255
+
+// weightStep keeps small latency changes from updating the records every run
+const weightStep = 16
+
+// ReferenceLatency is the latency that gets half of MaxWeight
+var ReferenceLatency = 50 * time.Millisecond
+
+// Node is one gateway behind the record name
+type Node struct {
+	ID string
+	IP string
+	// Capacity is relative to the other nodes, 0 counts as 1
+	Capacity float64
+}
+
+// NodeFromEnode ...
+func NodeFromEnode(enode string) (Node, error) {
+	s := strings.TrimPrefix(enode, "enode://")
+	i := strings.Index(s, "@")
+	if i <= 0 {
+		return Node{}, fmt.Errorf("invalid enode %q", enode)
+	}
+	host := s[i+1:]
+	if j := strings.Index(host, "?"); j >= 0 {
+		host = host[:j]
+	}
+	ip, _, err := net.SplitHostPort(host)
+	if err != nil {
+		ip = host
+	}
+	if net.ParseIP(ip) == nil {
+		return Node{}, fmt.Errorf("invalid enode ip %q", enode)
+	}
+	return Node{ID: s[:i], IP: ip}, nil
+}
+
+// SetCapacity sets the capacity of the nodes to their measure relative to the
+// average of the measured nodes, the nodes without a measure keep the default
+func SetCapacity(nodes []Node, measure func(id string) float64) {
+	var sum float64
+	var measured int
+	values := make([]float64, len(nodes))
+	for i, node := range nodes {
+		values[i] = measure(node.ID)
+		if values[i] > 0 {
+			sum += values[i]
+			measured++
+		}
+	}
+	if measured == 0 {
+		return
+	}
+	avg := sum / float64(measured)
+	for i := range nodes {
+		if values[i] > 0 {
+			nodes[i].Capacity = values[i] / avg
+		}
+	}
+}
+
+// recordID is the stable set identifier of a node, route53 allows 128 characters
+func recordID(id string) string {
+	if len(id) > 32 {
+		return id[:32]
+	}
+	return id
+}
+
+// Weight prefers the nodes that answer fast and have more capacity
+func Weight(latency time.Duration, capacity float64) int64 {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	w := MaxWeight * capacity * float64(ReferenceLatency) / float64(latency+ReferenceLatency)
+	w = math.Ceil(w/weightStep) * weightStep
+	if w > MaxWeight {
+		return MaxWeight
+	}
+	if w < 1 {
+		return 1
+	}
+	return int64(w)
+}
+
+type region struct {
+	name string
+	net  *net.IPNet
+}
+
+// parseRegions reads the cidr to region map of the config
+func parseRegions(regions map[string]string) ([]region, error) {
+	var parsed []region
+	for cidr, name := range regions {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("region %s: %w", name, err)
+		}
+		parsed = append(parsed, region{name: name, net: ipNet})
+	}
+	return parsed, nil
+}
+
+// regionOf returns the region of the most specific network that holds the ip
+func regionOf(regions []region, ip string) string {
+	addr := net.ParseIP(ip)
+	name, best := "", -1
+	for _, r := range regions {
+		ones, _ := r.net.Mask.Size()
+		if r.net.Contains(addr) && ones > best {
+			name, best = r.name, ones
+		}
+	}
+	return name
+}
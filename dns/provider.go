@@ -0,0 +1,131 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/glvd/accipfs/config"
+)
+
+// ErrUnknownProvider ...
+var ErrUnknownProvider = errors.New("unknown dns provider")
+
+// Record types
+const (
+	TypeA    = "A"
+	TypeAAAA = "AAAA"
+)
+
+// Record is one gateway address published under a name
+type Record struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	TTL   int64  `json:"ttl"`
+	// ID tells records of the same name apart, route53 uses it as set identifier
+	ID     string `json:"id,omitempty"`
+	Weight int64  `json:"weight,omitempty"`
+	Region string `json:"region,omitempty"`
+}
+
+// String ...
+func (r Record) String() string {
+	s := fmt.Sprintf("%s %d %s %s", r.Name, r.TTL, r.Type, r.Value)
+	if r.ID != "" {
+		s += " id=" + r.ID
+	}
+	if r.Weight != 0 {
+		s += fmt.Sprintf(" weight=%d", r.Weight)
+	}
+	if r.Region != "" {
+		s += " region=" + r.Region
+	}
+	return s
+}
+
+// Action ...
+type Action string
+
+// Action list
+const (
+	ActionUpsert Action = "UPSERT"
+	ActionDelete Action = "DELETE"
+)
+
+// Change ...
+type Change struct {
+	Action Action `json:"action"`
+	Record Record `json:"record"`
+}
+
+// ChangeSet ...
+type ChangeSet []Change
+
+// String ...
+func (c ChangeSet) String() string {
+	if len(c) == 0 {
+		return "no changes"
+	}
+	var lines []string
+	for _, change := range c {
+		lines = append(lines, fmt.Sprintf("%-6s %s", change.Action, change.Record))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Provider publishes the gateway records
+type Provider interface {
+	Name() string
+	Records(ctx context.Context, name string) ([]Record, error)
+	Apply(ctx context.Context, changes ChangeSet) error
+	// Normalize drops the record fields the provider does not keep
+	Normalize(r Record) Record
+}
+
+// New creates the provider set in the config
+func New(cfg *config.Config) (Provider, error) {
+	switch cfg.DNS.Provider {
+	case "", "route53":
+		return NewRoute53(cfg)
+	case "rfc2136":
+		return NewRFC2136(cfg.DNS.Server, cfg.DNS.Zone, cfg.DNS.Net), nil
+	case "file", "hosts":
+		return NewFile(cfg.DNS.File), nil
+	}
+	return nil, fmt.Errorf("%s: %w", cfg.DNS.Provider, ErrUnknownProvider)
+}
+
+// RecordName returns the gateway name of the config
+func RecordName(cfg *config.Config) string {
+	if cfg.DNS.RecordName != "" {
+		return cfg.DNS.RecordName
+	}
+	return cfg.AWS.RecordName
+}
+
+// RecordType returns the record type that holds the ip
+func RecordType(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+	if addr.To4() != nil {
+		return TypeA
+	}
+	return TypeAAAA
+}
+
+// fqdn ...
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func sameName(a, b string) bool {
+	return strings.EqualFold(fqdn(a), fqdn(b))
+}
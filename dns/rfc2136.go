@@ -0,0 +1,229 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ErrUpdateRefused ...
+var ErrUpdateRefused = errors.New("dns update refused")
+
+// opCodeUpdate is the dynamic update opcode of RFC 2136
+const opCodeUpdate dnsmessage.OpCode = 5
+
+// classNone deletes one record of a set in an update
+const classNone dnsmessage.Class = 254
+
+// rfc2136 sends dynamic updates to an authoritative server.
+// TSIG is not supported, the server has to allow updates from this host.
+type rfc2136 struct {
+	server  string
+	zone    string
+	network string
+	timeout time.Duration
+}
+
+// NewRFC2136 ...
+func NewRFC2136(server, zone, network string) Provider {
+	if network == "" {
+		network = "udp"
+	}
+	return &rfc2136{
+		server:  server,
+		zone:    fqdn(zone),
+		network: network,
+		timeout: 5 * time.Second,
+	}
+}
+
+// Name ...
+func (r *rfc2136) Name() string {
+	return "rfc2136"
+}
+
+// Normalize only the address is kept
+func (*rfc2136) Normalize(r Record) Record {
+	r.ID, r.Weight, r.Region = "", 0, ""
+	return r
+}
+
+// Records ...
+func (r *rfc2136) Records(ctx context.Context, name string) ([]Record, error) {
+	var records []Record
+	for _, typ := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		rs, err := r.query(ctx, name, typ)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rs...)
+	}
+	return records, nil
+}
+
+func (r *rfc2136) query(ctx context.Context, name string, typ dnsmessage.Type) ([]Record, error) {
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, err
+	}
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Uint32())},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: typ, Class: dnsmessage.ClassINET},
+		},
+	}
+	resp, err := r.exchange(ctx, &msg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.RCode == dnsmessage.RCodeNameError {
+		return nil, nil
+	}
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("query %s: %s", name, resp.RCode)
+	}
+	var records []Record
+	for _, answer := range resp.Answers {
+		record := Record{
+			Name: name,
+			TTL:  int64(answer.Header.TTL),
+		}
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			record.Type = TypeA
+			record.Value = net.IP(body.A[:]).String()
+		case *dnsmessage.AAAAResource:
+			record.Type = TypeAAAA
+			record.Value = net.IP(body.AAAA[:]).String()
+		default:
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func updateResource(change Change) (dnsmessage.Resource, error) {
+	name, err := dnsmessage.NewName(fqdn(change.Record.Name))
+	if err != nil {
+		return dnsmessage.Resource{}, err
+	}
+	header := dnsmessage.ResourceHeader{
+		Name:  name,
+		Class: dnsmessage.ClassINET,
+		TTL:   uint32(change.Record.TTL),
+	}
+	if change.Action == ActionDelete {
+		header.Class = classNone
+		header.TTL = 0
+	}
+	ip := net.ParseIP(change.Record.Value)
+	if ip == nil {
+		return dnsmessage.Resource{}, fmt.Errorf("invalid address %q", change.Record.Value)
+	}
+	switch change.Record.Type {
+	case TypeA:
+		var a [4]byte
+		copy(a[:], ip.To4())
+		return dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: a}}, nil
+	case TypeAAAA:
+		var aaaa [16]byte
+		copy(aaaa[:], ip.To16())
+		return dnsmessage.Resource{Header: header, Body: &dnsmessage.AAAAResource{AAAA: aaaa}}, nil
+	}
+	return dnsmessage.Resource{}, fmt.Errorf("unsupported record type %q", change.Record.Type)
+}
+
+// Apply sends all changes in one update message
+func (r *rfc2136) Apply(ctx context.Context, changes ChangeSet) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	zone, err := dnsmessage.NewName(r.zone)
+	if err != nil {
+		return err
+	}
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Uint32()), OpCode: opCodeUpdate},
+		Questions: []dnsmessage.Question{
+			{Name: zone, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET},
+		},
+	}
+	for _, change := range changes {
+		rr, err := updateResource(change)
+		if err != nil {
+			return err
+		}
+		// the update section takes the place of the authority section
+		msg.Authorities = append(msg.Authorities, rr)
+	}
+	resp, err := r.exchange(ctx, &msg)
+	if err != nil {
+		return err
+	}
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return fmt.Errorf("%s: %w", resp.RCode, ErrUpdateRefused)
+	}
+	return nil
+}
+
+func (r *rfc2136) exchange(ctx context.Context, msg *dnsmessage.Message) (*dnsmessage.Message, error) {
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	dialer := net.Dialer{Timeout: r.timeout}
+	conn, err := dialer.DialContext(ctx, r.network, r.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(r.timeout)
+	if d, b := ctx.Deadline(); b && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	var buf []byte
+	if r.network == "tcp" {
+		// messages over tcp are prefixed with their length
+		prefix := make([]byte, 2)
+		binary.BigEndian.PutUint16(prefix, uint16(len(packed)))
+		if _, err := conn.Write(append(prefix, packed...)); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(conn, prefix); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(prefix))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, 65535)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	resp := new(dnsmessage.Message)
+	if err := resp.Unpack(buf); err != nil {
+		return nil, err
+	}
+	if resp.ID != msg.ID {
+		return nil, fmt.Errorf("dns response id %d does not match %d", resp.ID, msg.ID)
+	}
+	return resp, nil
+}
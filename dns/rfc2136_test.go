@@ -0,0 +1,231 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// standIn is a tiny authoritative server that accepts queries and dynamic
+// updates for one zone on loopback
+type standIn struct {
+	t    *testing.T
+	zone string
+	udp  net.PacketConn
+	tcp  net.Listener
+	mut  sync.Mutex
+	rrs  map[string][]dnsmessage.Resource
+}
+
+func newStandIn(t *testing.T, zone string) *standIn {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &standIn{
+		t:    t,
+		zone: zone,
+		udp:  udp,
+		tcp:  tcp,
+		rrs:  make(map[string][]dnsmessage.Resource),
+	}
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+func (s *standIn) addr() string {
+	return s.udp.LocalAddr().String()
+}
+
+func (s *standIn) close() {
+	_ = s.udp.Close()
+	_ = s.tcp.Close()
+}
+
+func (s *standIn) serveUDP() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.handle(buf[:n]); resp != nil {
+			_, _ = s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *standIn) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			prefix := make([]byte, 2)
+			if _, err := io.ReadFull(conn, prefix); err != nil {
+				return
+			}
+			buf := make([]byte, binary.BigEndian.Uint16(prefix))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			resp := s.handle(buf)
+			binary.BigEndian.PutUint16(prefix, uint16(len(resp)))
+			_, _ = conn.Write(append(prefix, resp...))
+		}(conn)
+	}
+}
+
+func sameRData(a, b dnsmessage.Resource) bool {
+	switch x := a.Body.(type) {
+	case *dnsmessage.AResource:
+		y, b := b.Body.(*dnsmessage.AResource)
+		return b && x.A == y.A
+	case *dnsmessage.AAAAResource:
+		y, b := b.Body.(*dnsmessage.AAAAResource)
+		return b && x.AAAA == y.AAAA
+	}
+	return false
+}
+
+func (s *standIn) handle(packet []byte) []byte {
+	var req dnsmessage.Message
+	if err := req.Unpack(packet); err != nil {
+		s.t.Errorf("stand-in: %v", err)
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: req.ID, Response: true, OpCode: req.OpCode, Authoritative: true},
+		Questions: req.Questions,
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	switch {
+	case req.OpCode == opCodeUpdate:
+		if len(req.Questions) != 1 || !strings.EqualFold(req.Questions[0].Name.String(), s.zone) {
+			resp.RCode = dnsmessage.RCodeRefused
+			break
+		}
+		for _, rr := range req.Authorities {
+			name := strings.ToLower(rr.Header.Name.String())
+			var kept []dnsmessage.Resource
+			for _, old := range s.rrs[name] {
+				if old.Header.Type != rr.Header.Type || !sameRData(old, rr) {
+					kept = append(kept, old)
+				}
+			}
+			if rr.Header.Class == dnsmessage.ClassINET {
+				kept = append(kept, rr)
+			}
+			s.rrs[name] = kept
+		}
+	case len(req.Questions) == 1:
+		q := req.Questions[0]
+		rrs, b := s.rrs[strings.ToLower(q.Name.String())]
+		if !b || len(rrs) == 0 {
+			resp.RCode = dnsmessage.RCodeNameError
+			break
+		}
+		for _, rr := range rrs {
+			if rr.Header.Type == q.Type {
+				resp.Answers = append(resp.Answers, rr)
+			}
+		}
+	default:
+		resp.RCode = dnsmessage.RCodeFormatError
+	}
+	packed, err := resp.Pack()
+	if err != nil {
+		s.t.Errorf("stand-in: %v", err)
+		return nil
+	}
+	return packed
+}
+
+func values(records []Record) string {
+	var vs []string
+	for _, r := range records {
+		vs = append(vs, r.Type+" "+r.Value)
+	}
+	sort.Strings(vs)
+	return strings.Join(vs, ",")
+}
+
+func testRFC2136(t *testing.T, network string) {
+	server := newStandIn(t, "example.test.")
+	defer server.close()
+	ctx := context.Background()
+	p := NewRFC2136(server.addr(), "example.test", network)
+
+	records, err := p.Records(ctx, "gate.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("unexpected records %v", records)
+	}
+
+	err = p.Apply(ctx, ChangeSet{
+		{Action: ActionUpsert, Record: Record{Name: "gate.example.test", Type: TypeA, Value: "10.0.0.1", TTL: 60}},
+		{Action: ActionUpsert, Record: Record{Name: "gate.example.test", Type: TypeA, Value: "10.0.0.2", TTL: 60}},
+		{Action: ActionUpsert, Record: Record{Name: "gate.example.test", Type: TypeAAAA, Value: "fd00::1", TTL: 60}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err = p.Records(ctx, "gate.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values(records); got != "A 10.0.0.1,A 10.0.0.2,AAAA fd00::1" {
+		t.Fatalf("records after add: %s", got)
+	}
+
+	err = p.Apply(ctx, ChangeSet{
+		{Action: ActionDelete, Record: Record{Name: "gate.example.test", Type: TypeA, Value: "10.0.0.1"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err = p.Records(ctx, "gate.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values(records); got != "A 10.0.0.2,AAAA fd00::1" {
+		t.Fatalf("records after delete: %s", got)
+	}
+}
+
+func TestRFC2136UDP(t *testing.T) {
+	testRFC2136(t, "udp")
+}
+
+func TestRFC2136TCP(t *testing.T) {
+	testRFC2136(t, "tcp")
+}
+
+func TestRFC2136WrongZone(t *testing.T) {
+	server := newStandIn(t, "example.test.")
+	defer server.close()
+	p := NewRFC2136(server.addr(), "other.test", "udp")
+	err := p.Apply(context.Background(), ChangeSet{
+		{Action: ActionUpsert, Record: Record{Name: "gate.other.test", Type: TypeA, Value: "10.0.0.1", TTL: 60}},
+	})
+	if err == nil || !strings.Contains(err.Error(), ErrUpdateRefused.Error()) {
+		t.Fatalf("expected %v, got %v", ErrUpdateRefused, err)
+	}
+}
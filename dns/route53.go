@@ -0,0 +1,175 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/glvd/accipfs/config"
+)
+
+// Routing policies of route53
+const (
+	RoutingWeighted   = "weighted"
+	RoutingLatency    = "latency"
+	RoutingMultiValue = "multivalue"
+)
+
+type route53Provider struct {
+	zoneID  string
+	routing string
+	client  *route53.Route53
+}
+
+// NewRoute53 ...
+func NewRoute53(cfg *config.Config) (Provider, error) {
+	awsCfg := aws.NewConfig()
+	if cfg.AWS.AwsAccessKeyID != "" && cfg.AWS.AwsSecretAccessKey != "" {
+		awsCfg = awsCfg.WithCredentials(credentials.NewStaticCredentials(cfg.AWS.AwsAccessKeyID, cfg.AWS.AwsSecretAccessKey, ""))
+	}
+	s, err := session.NewSession(awsCfg)
+	if err != nil {
+		return nil, err
+	}
+	routing := cfg.DNS.Routing
+	if routing == "" {
+		routing = RoutingWeighted
+	}
+	return &route53Provider{
+		zoneID:  cfg.AWS.HostedZoneID,
+		routing: routing,
+		client:  route53.New(s),
+	}, nil
+}
+
+// Name ...
+func (r *route53Provider) Name() string {
+	return "route53"
+}
+
+// Normalize a record set has one routing policy, weight and region can not be mixed
+func (r *route53Provider) Normalize(record Record) Record {
+	switch r.routing {
+	case RoutingWeighted:
+		record.Region = ""
+	case RoutingLatency:
+		record.Weight = 0
+	default:
+		record.Weight, record.Region = 0, ""
+	}
+	return record
+}
+
+// Records ...
+func (r *route53Provider) Records(ctx context.Context, name string) ([]Record, error) {
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(r.zoneID),
+		StartRecordName: aws.String(name),
+	}
+	var records []Record
+	err := r.client.ListResourceRecordSetsPagesWithContext(ctx, input, func(output *route53.ListResourceRecordSetsOutput, last bool) bool {
+		for _, set := range output.ResourceRecordSets {
+			if !sameName(aws.StringValue(set.Name), name) {
+				// the sets are sorted by name, the rest belongs to other names
+				return false
+			}
+			typ := aws.StringValue(set.Type)
+			if typ != TypeA && typ != TypeAAAA {
+				continue
+			}
+			for _, rr := range set.ResourceRecords {
+				records = append(records, Record{
+					Name:   name,
+					Type:   typ,
+					Value:  aws.StringValue(rr.Value),
+					TTL:    aws.Int64Value(set.TTL),
+					ID:     aws.StringValue(set.SetIdentifier),
+					Weight: aws.Int64Value(set.Weight),
+					Region: aws.StringValue(set.Region),
+				})
+			}
+		}
+		return !last
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func setKey(action Action, record Record) string {
+	return strings.Join([]string{string(action), fqdn(record.Name), record.Type, record.ID}, "|")
+}
+
+// Apply records with the same set identifier are sent as one record set.
+// An upsert replaces the whole set, a delete of the same set is dropped.
+func (r *route53Provider) Apply(ctx context.Context, changes ChangeSet) error {
+	upserts := make(map[string]bool)
+	for _, change := range changes {
+		if change.Action == ActionUpsert {
+			upserts[setKey(ActionUpsert, change.Record)] = true
+		}
+	}
+	var batch []*route53.Change
+	sets := make(map[string]*route53.ResourceRecordSet)
+	for _, change := range changes {
+		if change.Action == ActionDelete && change.Record.ID != "" && upserts[setKey(ActionUpsert, change.Record)] {
+			continue
+		}
+		key := setKey(change.Action, change.Record)
+		if set, b := sets[key]; b {
+			set.ResourceRecords = append(set.ResourceRecords, &route53.ResourceRecord{Value: aws.String(change.Record.Value)})
+			continue
+		}
+		set, err := r.recordSet(change.Record)
+		if err != nil {
+			return err
+		}
+		sets[key] = set
+		batch = append(batch, &route53.Change{
+			Action:            aws.String(string(change.Action)),
+			ResourceRecordSet: set,
+		})
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	_, err := r.client.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: batch,
+			Comment: aws.String("gateway"),
+		},
+		HostedZoneId: aws.String(r.zoneID),
+	})
+	return err
+}
+
+func (r *route53Provider) recordSet(record Record) (*route53.ResourceRecordSet, error) {
+	set := &route53.ResourceRecordSet{
+		Name: aws.String(record.Name),
+		Type: aws.String(record.Type),
+		ResourceRecords: []*route53.ResourceRecord{
+			{Value: aws.String(record.Value)},
+		},
+		TTL: aws.Int64(record.TTL),
+	}
+	if record.ID == "" {
+		return set, nil
+	}
+	set.SetIdentifier = aws.String(record.ID)
+	switch {
+	case record.Region != "":
+		set.Region = aws.String(record.Region)
+	case record.Weight != 0:
+		set.Weight = aws.Int64(record.Weight)
+	case r.routing == RoutingLatency:
+		return nil, fmt.Errorf("record %s has no region for latency routing", record)
+	default:
+		set.MultiValueAnswer = aws.Bool(true)
+	}
+	return set, nil
+}
@@ -0,0 +1,273 @@
+package dns
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// maxAnswers keeps udp answers below 512 bytes
+const maxAnswers = 8
+
+// Server is an authoritative responder for the gateway name. Every answer is
+// built from the addresses the source returns at that moment.
+type Server struct {
+	Name   string
+	TTL    uint32
+	Source func() []string
+
+	mut  sync.Mutex
+	udp  net.PacketConn
+	tcp  net.Listener
+	wg   sync.WaitGroup
+	done chan struct{}
+}
+
+// NewServer ...
+func NewServer(name string, ttl int64, source func() []string) *Server {
+	if ttl <= 0 {
+		ttl = 10
+	}
+	return &Server{
+		Name:   fqdn(strings.ToLower(name)),
+		TTL:    uint32(ttl),
+		Source: source,
+		done:   make(chan struct{}),
+	}
+}
+
+// Listen opens the udp and tcp sockets on the same address
+func (s *Server) Listen(addr string) error {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		_ = udp.Close()
+		return err
+	}
+	s.mut.Lock()
+	s.udp, s.tcp = udp, tcp
+	s.mut.Unlock()
+	return nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.udp == nil {
+		return ""
+	}
+	return s.udp.LocalAddr().String()
+}
+
+// Serve answers until the server is closed
+func (s *Server) Serve() error {
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.serveTCP()
+	}()
+	defer s.wg.Done()
+	return s.serveUDP()
+}
+
+// ListenAndServe ...
+func (s *Server) ListenAndServe(addr string) error {
+	if err := s.Listen(addr); err != nil {
+		return err
+	}
+	return s.Serve()
+}
+
+// Close ...
+func (s *Server) Close() error {
+	s.mut.Lock()
+	select {
+	case <-s.done:
+		s.mut.Unlock()
+		return nil
+	default:
+		close(s.done)
+	}
+	var err error
+	if s.udp != nil {
+		err = s.udp.Close()
+		_ = s.tcp.Close()
+	}
+	s.mut.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) serveUDP() error {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if s.closed() {
+				return nil
+			}
+			return err
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			_, _ = s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *Server) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	prefix := make([]byte, 2)
+	for {
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+		if _, err := io.ReadFull(conn, prefix); err != nil {
+			return
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(prefix))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		resp := s.answer(buf)
+		if resp == nil {
+			return
+		}
+		binary.BigEndian.PutUint16(prefix, uint16(len(resp)))
+		if _, err := conn.Write(append(prefix, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) soa() dnsmessage.Resource {
+	name := dnsmessage.MustNewName(s.Name)
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: s.TTL},
+		Body: &dnsmessage.SOAResource{
+			NS:      dnsmessage.MustNewName("ns." + s.Name),
+			MBox:    dnsmessage.MustNewName("hostmaster." + s.Name),
+			Serial:  uint32(time.Now().Unix()),
+			Refresh: 3600,
+			Retry:   600,
+			Expire:  86400,
+			MinTTL:  s.TTL,
+		},
+	}
+}
+
+// addresses returns the live addresses of the type in random order
+func (s *Server) addresses(typ dnsmessage.Type) []dnsmessage.Resource {
+	name := dnsmessage.MustNewName(s.Name)
+	header := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: s.TTL}
+	var rrs []dnsmessage.Resource
+	seen := make(map[string]bool)
+	for _, addr := range s.Source() {
+		ip := net.ParseIP(addr)
+		if ip == nil || ip.IsUnspecified() || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		switch {
+		case typ == dnsmessage.TypeA && ip.To4() != nil:
+			var a [4]byte
+			copy(a[:], ip.To4())
+			rrs = append(rrs, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: a}})
+		case typ == dnsmessage.TypeAAAA && ip.To4() == nil:
+			var aaaa [16]byte
+			copy(aaaa[:], ip.To16())
+			rrs = append(rrs, dnsmessage.Resource{Header: header, Body: &dnsmessage.AAAAResource{AAAA: aaaa}})
+		}
+	}
+	rand.Shuffle(len(rrs), func(i, j int) {
+		rrs[i], rrs[j] = rrs[j], rrs[i]
+	})
+	if len(rrs) > maxAnswers {
+		rrs = rrs[:maxAnswers]
+	}
+	return rrs
+}
+
+func (s *Server) answer(packet []byte) []byte {
+	var p dnsmessage.Parser
+	header, err := p.Start(packet)
+	if err != nil || header.Response {
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               header.ID,
+			Response:         true,
+			OpCode:           header.OpCode,
+			RecursionDesired: header.RecursionDesired,
+		},
+	}
+	q, err := p.Question()
+	switch {
+	case err != nil:
+		resp.RCode = dnsmessage.RCodeFormatError
+	case header.OpCode != 0:
+		resp.RCode = dnsmessage.RCodeNotImplemented
+	default:
+		resp.Questions = []dnsmessage.Question{q}
+		s.resolve(&resp, q)
+	}
+	packed, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+	return packed
+}
+
+func (s *Server) resolve(resp *dnsmessage.Message, q dnsmessage.Question) {
+	name := strings.ToLower(q.Name.String())
+	switch {
+	case name == s.Name:
+	case strings.HasSuffix(name, "."+s.Name):
+		// nothing below the gateway name
+		resp.Authoritative = true
+		resp.RCode = dnsmessage.RCodeNameError
+		resp.Authorities = []dnsmessage.Resource{s.soa()}
+		return
+	default:
+		resp.RCode = dnsmessage.RCodeRefused
+		return
+	}
+	resp.Authoritative = true
+	switch q.Type {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		resp.Answers = s.addresses(q.Type)
+	case dnsmessage.TypeSOA:
+		resp.Answers = []dnsmessage.Resource{s.soa()}
+	}
+	if len(resp.Answers) == 0 {
+		// no data for the type
+		resp.Authorities = []dnsmessage.Resource{s.soa()}
+	}
+}
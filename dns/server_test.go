@@ -0,0 +1,119 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func startServer(t *testing.T, source func() []string) *Server {
+	s := NewServer("gate.example.test", 5, source)
+	if err := s.Listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := s.Serve(); err != nil {
+			t.Error(err)
+		}
+	}()
+	return s
+}
+
+func TestServerAnswers(t *testing.T) {
+	var mut sync.Mutex
+	live := []string{"10.0.0.1", "10.0.0.2", "10.0.0.2", "fd00::1", "0.0.0.0", "bad"}
+	s := startServer(t, func() []string {
+		mut.Lock()
+		defer mut.Unlock()
+		return live
+	})
+	defer s.Close()
+
+	for _, network := range []string{"udp", "tcp"} {
+		client := NewRFC2136(s.Addr(), "gate.example.test", network)
+		records, err := client.Records(context.Background(), "GATE.example.test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := values(records); got != "A 10.0.0.1,A 10.0.0.2,AAAA fd00::1" {
+			t.Fatalf("%s answers: %s", network, got)
+		}
+		for _, r := range records {
+			if r.TTL != 5 {
+				t.Fatalf("ttl %d, want 5", r.TTL)
+			}
+		}
+	}
+
+	// the answers follow the live nodes
+	mut.Lock()
+	live = []string{"10.0.0.3"}
+	mut.Unlock()
+	records, err := NewRFC2136(s.Addr(), "", "udp").Records(context.Background(), "gate.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values(records); got != "A 10.0.0.3" {
+		t.Fatalf("answers after update: %s", got)
+	}
+}
+
+func TestServerLimitsAnswers(t *testing.T) {
+	var live []string
+	for i := 0; i < 40; i++ {
+		live = append(live, fmt.Sprintf("10.0.1.%d", i))
+	}
+	s := startServer(t, func() []string {
+		return live
+	})
+	defer s.Close()
+	records, err := NewRFC2136(s.Addr(), "", "udp").Records(context.Background(), "gate.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != maxAnswers {
+		t.Fatalf("%d answers, want %d", len(records), maxAnswers)
+	}
+}
+
+func query(t *testing.T, s *Server, name string) *dnsmessage.Message {
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Uint32())},
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName(name), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+		},
+	}
+	resp, err := NewRFC2136(s.Addr(), "", "udp").(*rfc2136).exchange(context.Background(), &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestServerOtherNames(t *testing.T) {
+	s := startServer(t, func() []string {
+		return nil
+	})
+	defer s.Close()
+
+	resp := query(t, s, "gate.example.test.")
+	if resp.RCode != dnsmessage.RCodeSuccess || !resp.Authoritative || len(resp.Answers) != 0 || len(resp.Authorities) != 1 {
+		t.Fatalf("no data answer: %+v", resp)
+	}
+	resp = query(t, s, "www.gate.example.test.")
+	if resp.RCode != dnsmessage.RCodeNameError || !resp.Authoritative {
+		t.Fatalf("name below the gateway: %+v", resp)
+	}
+	resp = query(t, s, "example.org.")
+	if resp.RCode != dnsmessage.RCodeRefused || resp.Authoritative {
+		t.Fatalf("other zone: %+v", resp)
+	}
+	if err := s.Close(); err != nil && !strings.Contains(err.Error(), "closed") {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/glvd/accipfs/config"
+)
+
+// Syncer keeps the gateway records of one name in line with the reachable nodes
+type Syncer struct {
+	Provider Provider
+	Checker  *HealthChecker
+	Name     string
+	TTL      int64
+	// AAAA publishes the ipv6 nodes as well
+	AAAA    bool
+	regions []region
+}
+
+// NewSyncer ...
+func NewSyncer(cfg *config.Config) (*Syncer, error) {
+	provider, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	regions, err := parseRegions(cfg.DNS.Regions)
+	if err != nil {
+		return nil, err
+	}
+	ttl := cfg.DNS.TTL
+	if ttl <= 0 {
+		ttl = 60
+	}
+	return &Syncer{
+		Provider: provider,
+		Checker:  NewHealthChecker(cfg),
+		Name:     RecordName(cfg),
+		TTL:      ttl,
+		AAAA:     cfg.DNS.AAAA,
+		regions:  regions,
+	}, nil
+}
+
+func recordKey(r Record) string {
+	if r.ID != "" {
+		return r.Type + "|" + r.ID
+	}
+	return r.Type + "|" + r.Value
+}
+
+// desired builds the records of the healthy nodes
+func (s *Syncer) desired(nodes []Node, latency map[string]time.Duration) map[string]Record {
+	records := make(map[string]Record)
+	for _, node := range nodes {
+		typ := RecordType(node.IP)
+		if typ == "" || (typ == TypeAAAA && !s.AAAA) {
+			continue
+		}
+		l, b := latency[node.IP]
+		if !b {
+			continue
+		}
+		r := s.Provider.Normalize(Record{
+			Name:   s.Name,
+			Type:   typ,
+			Value:  node.IP,
+			TTL:    s.TTL,
+			ID:     recordID(node.ID),
+			Weight: Weight(l, node.Capacity),
+			Region: regionOf(s.regions, node.IP),
+		})
+		records[recordKey(r)] = r
+	}
+	return records
+}
+
+// Plan returns the changes that bring the records in line with the nodes.
+// Records are matched by node identity when the provider keeps it, so a node
+// that changes its weight or address is updated in place.
+func (s *Syncer) Plan(ctx context.Context, nodes []Node) (ChangeSet, error) {
+	remote, err := s.Provider.Records(ctx, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	var ips []string
+	for _, node := range nodes {
+		ips = append(ips, node.IP)
+	}
+	for _, record := range remote {
+		ips = append(ips, record.Value)
+	}
+	latency := s.Checker.MeasureAll(ctx, ips)
+	desired := s.desired(nodes, latency)
+
+	var changes ChangeSet
+	published := make(map[string]bool)
+	for _, record := range remote {
+		key := recordKey(record)
+		want, b := desired[key]
+		switch {
+		case b && want == record:
+			published[key] = true
+		case b && want.Value == record.Value:
+			// the weight or region changed, the upsert below replaces it
+		case b:
+			// the node moved to another address
+			changes = append(changes, Change{Action: ActionDelete, Record: record})
+		default:
+			// not one of our nodes, or published by hand: keep it while it is healthy
+			if _, healthy := latency[record.Value]; !healthy || s.covered(desired, record) {
+				changes = append(changes, Change{Action: ActionDelete, Record: record})
+			}
+		}
+	}
+
+	var keys []string
+	for key := range desired {
+		if !published[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		changes = append(changes, Change{Action: ActionUpsert, Record: desired[key]})
+	}
+	return changes, nil
+}
+
+// covered reports whether a record with another key publishes the same address
+func (s *Syncer) covered(desired map[string]Record, record Record) bool {
+	key := recordKey(record)
+	for k, r := range desired {
+		if k != key && r.Type == record.Type && r.Value == record.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync plans and applies the changes
+func (s *Syncer) Sync(ctx context.Context, nodes []Node) (ChangeSet, error) {
+	changes, err := s.Plan(ctx, nodes)
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+	return changes, s.Provider.Apply(ctx, changes)
+}
@@ -0,0 +1,234 @@
+package dns
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memory keeps every record field like route53 with weighted routing
+type memory struct {
+	mut     sync.Mutex
+	records []Record
+	applied int
+}
+
+func (m *memory) Name() string {
+	return "memory"
+}
+
+func (m *memory) Normalize(r Record) Record {
+	return r
+}
+
+func (m *memory) Records(ctx context.Context, name string) ([]Record, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return append([]Record(nil), m.records...), nil
+}
+
+func (m *memory) Apply(ctx context.Context, changes ChangeSet) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.applied++
+	for _, change := range changes {
+		var kept []Record
+		for _, r := range m.records {
+			if recordKey(r) != recordKey(change.Record) || (change.Action == ActionDelete && r.Value != change.Record.Value) {
+				kept = append(kept, r)
+			}
+		}
+		if change.Action == ActionUpsert {
+			kept = append(kept, change.Record)
+		}
+		m.records = kept
+	}
+	return nil
+}
+
+func listen(t *testing.T, addr string) (net.Listener, int) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, l.Addr().(*net.TCPAddr).Port
+}
+
+func TestSyncFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accipfs-dns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hosts")
+	// 127.0.0.2 has nothing listening on the health port
+	err = ioutil.WriteFile(path, []byte("# local\n127.0.0.1 localhost\n127.0.0.2 gate.example.test other.example.test\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, port := listen(t, "127.0.0.1:0")
+	defer l.Close()
+
+	s := &Syncer{
+		Provider: NewFile(path),
+		Checker:  &HealthChecker{Port: port, Timeout: time.Second},
+		Name:     "gate.example.test",
+		TTL:      60,
+	}
+	ctx := context.Background()
+	nodes := []Node{{ID: "a", IP: "127.0.0.1"}, {ID: "b", IP: "127.0.0.1"}, {ID: "c", IP: "::1"}}
+	plan, err := s.Plan(ctx, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 2 || plan[0].Action != ActionDelete || plan[1].Action != ActionUpsert {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	if _, err := s.Sync(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# local\n127.0.0.1 localhost\n127.0.0.2 other.example.test\n127.0.0.1 gate.example.test\n"
+	if string(data) != want {
+		t.Fatalf("hosts file is\n%s\nwant\n%s", data, want)
+	}
+
+	plan, err = s.Plan(ctx, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 0 || !strings.Contains(plan.String(), "no changes") {
+		t.Fatalf("records did not converge:\n%s", plan)
+	}
+}
+
+func TestSyncIdentity(t *testing.T) {
+	// both loopback addresses answer
+	l, port := listen(t, ":0")
+	defer l.Close()
+	regions, err := parseRegions(map[string]string{"127.0.0.0/8": "local", "127.0.0.1/32": "home", "::1/128": "v6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &memory{records: []Record{
+		// published by an older version with a random set identifier
+		{Name: "gate.example.test", Type: TypeA, Value: "127.0.0.1", TTL: 60, ID: "xkcdq"},
+	}}
+	s := &Syncer{
+		Provider: m,
+		Checker:  &HealthChecker{Port: port, Timeout: time.Second},
+		Name:     "gate.example.test",
+		TTL:      60,
+		regions:  regions,
+	}
+	ctx := context.Background()
+	nodes := []Node{{ID: "node1", IP: "127.0.0.1", Capacity: 2}, {ID: "node6", IP: "::1"}}
+	plan, err := s.Plan(ctx, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := plan.String()
+	if len(plan) != 2 || !strings.Contains(out, "DELETE gate.example.test 60 A 127.0.0.1 id=xkcdq") ||
+		!strings.Contains(out, "UPSERT gate.example.test 60 A 127.0.0.1 id=node1 weight=") ||
+		!strings.Contains(out, "region=home") {
+		t.Fatalf("unexpected plan:\n%s", out)
+	}
+	if _, err := s.Sync(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	// ipv6 nodes are only published with AAAA enabled
+	s.AAAA = true
+	plan, err = s.Plan(ctx, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 1 || plan[0].Record.Type != TypeAAAA || plan[0].Record.ID != "node6" || plan[0].Record.Region != "v6" {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	if _, err := s.Sync(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	// a node that moved is updated under the same identity
+	m.mut.Lock()
+	for i := range m.records {
+		if m.records[i].ID == "node1" {
+			m.records[i].Value = "127.0.0.9"
+		}
+	}
+	m.mut.Unlock()
+	if _, err := s.Sync(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	records, _ := m.Records(ctx, s.Name)
+	if len(records) != 2 {
+		t.Fatalf("unexpected records %v", records)
+	}
+	for _, r := range records {
+		if r.ID == "node1" && r.Value != "127.0.0.1" {
+			t.Fatalf("node1 was not moved back: %v", r)
+		}
+	}
+	applied := m.applied
+	if _, err := s.Sync(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if m.applied != applied {
+		t.Fatal("converged records were changed again")
+	}
+}
+
+func TestWeight(t *testing.T) {
+	fast := Weight(time.Millisecond, 1)
+	slow := Weight(time.Second, 1)
+	if fast <= slow || fast > MaxWeight || slow < 1 {
+		t.Fatalf("fast %d, slow %d", fast, slow)
+	}
+	if Weight(ReferenceLatency, 2) <= Weight(ReferenceLatency, 1) {
+		t.Fatal("capacity does not raise the weight")
+	}
+	if Weight(10*time.Millisecond, 1) != Weight(11*time.Millisecond, 1) {
+		t.Fatal("small latency changes move the weight")
+	}
+}
+
+func TestSetCapacity(t *testing.T) {
+	nodes := []Node{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	SetCapacity(nodes, func(id string) float64 {
+		return map[string]float64{"a": 300, "b": 100}[id]
+	})
+	if nodes[0].Capacity != 1.5 || nodes[1].Capacity != 0.5 || nodes[2].Capacity != 0 {
+		t.Fatalf("capacity %+v", nodes)
+	}
+	if Weight(ReferenceLatency, nodes[0].Capacity) <= Weight(ReferenceLatency, nodes[2].Capacity) {
+		t.Fatal("a measured node does not weigh more")
+	}
+}
+
+func TestNodeFromEnode(t *testing.T) {
+	for enode, ip := range map[string]string{
+		"enode://abc@10.0.0.1:30303":             "10.0.0.1",
+		"enode://abc@[fd00::1]:30303?discport=0": "fd00::1",
+	} {
+		node, err := NodeFromEnode(enode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.ID != "abc" || node.IP != ip {
+			t.Fatalf("%s: %+v", enode, node)
+		}
+	}
+	if _, err := NodeFromEnode("abc@nowhere"); err == nil {
+		t.Fatal("invalid enode accepted")
+	}
+}
@@ -0,0 +1,55 @@
+package general
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FormatAmount formats an amount of the smallest token unit with the token decimals
+func FormatAmount(v *big.Int, decimals uint8) string {
+	if v == nil {
+		return "0"
+	}
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(v).String()
+	if decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(decimals)
+	frac := strings.TrimRight(digits[point:], "0")
+	if frac == "" {
+		return sign + digits[:point]
+	}
+	return sign + digits[:point] + "." + frac
+}
+
+// ParseAmount parses a decimal amount into the smallest token unit
+func ParseAmount(s string, decimals uint8) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 2 || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return nil, fmt.Errorf("invalid amount: %q", s)
+	}
+	whole, frac := parts[0], ""
+	if len(parts) == 2 {
+		frac = strings.TrimRight(parts[1], "0")
+	}
+	if len(frac) > int(decimals) {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", s, decimals)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	v, b := new(big.Int).SetString(whole+frac+strings.Repeat("0", int(decimals)-len(frac)), 10)
+	if !b {
+		return nil, fmt.Errorf("invalid amount: %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,45 @@
+package general
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestAmount(t *testing.T) {
+	for s, want := range map[string]string{
+		"1":          "1000000000000000000",
+		"1.5":        "1500000000000000000",
+		"0.000001":   "1000000000000",
+		".25":        "250000000000000000",
+		"12.3400":    "12340000000000000000",
+		"0":          "0",
+		"1000000000": "1000000000000000000000000000",
+	} {
+		v, err := ParseAmount(s, 18)
+		if err != nil {
+			t.Fatal(s, err)
+		}
+		if v.String() != want {
+			t.Fatalf("%s parsed to %s, want %s", s, v, want)
+		}
+	}
+	for _, s := range []string{"", "1.2.3", "-1", "abc", "0.0000000000000000001"} {
+		if _, err := ParseAmount(s, 18); err == nil {
+			t.Fatalf("%q accepted", s)
+		}
+	}
+
+	for v, want := range map[int64]string{
+		0:                    "0",
+		1:                    "0.000000000000000001",
+		1500000000000000000:  "1.5",
+		-2000000000000000000: "-2",
+	} {
+		if got := FormatAmount(big.NewInt(v), 18); got != want {
+			t.Fatalf("%d formatted to %s, want %s", v, got, want)
+		}
+	}
+	if got := FormatAmount(big.NewInt(42), 0); got != "42" {
+		t.Fatalf("no decimals: %s", got)
+	}
+}
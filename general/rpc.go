@@ -0,0 +1,239 @@
+package general
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goextension/log"
+)
+
+// DefaultRPCTimeout limits a call of RPCPost
+const DefaultRPCTimeout = 30 * time.Second
+
+// maxRPCResponse is the largest rpc response read
+const maxRPCResponse = 64 << 20
+
+// RPCHTTPClient is shared by the rpc calls so the connections to a node are reused
+var RPCHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		MaxIdleConns:          256,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: DefaultRPCTimeout,
+	},
+}
+
+// TransportError is a call that did not get an answer of the node
+type TransportError struct {
+	URL string
+	Err error
+}
+
+// Error ...
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("rpc %s: %v", e.URL, e.Err)
+}
+
+// Unwrap ...
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the call ran out of time
+func (e *TransportError) Timeout() bool {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return true
+	}
+	var t interface{ Timeout() bool }
+	return errors.As(e.Err, &t) && t.Timeout()
+}
+
+// StatusError is an http answer that carries no rpc response
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error ...
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("rpc http status %d: %s", e.StatusCode, e.Body)
+}
+
+// RemoteError is an error returned by the rpc method of the node
+type RemoteError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error ...
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+// IsTimeout reports whether an rpc error is a timeout
+func IsTimeout(err error) bool {
+	var t *TransportError
+	return errors.As(err, &t) && t.Timeout()
+}
+
+// RPCCall is one call of a batch, Err is set when the call failed
+type RPCCall struct {
+	Method string
+	Input  interface{}
+	Output interface{}
+	Err    error
+}
+
+type rpcRequest struct {
+	Version string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	ID      uint64      `json:"id"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RemoteError    `json:"error"`
+}
+
+func (r *rpcResponse) decode(output interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if len(r.Result) == 0 || string(r.Result) == "null" {
+		return &RemoteError{Code: -32603, Message: "result is null"}
+	}
+	return json.Unmarshal(r.Result, output)
+}
+
+// RPCTransport posts json-rpc 2.0 requests
+type RPCTransport struct {
+	// Client sends the requests, RPCHTTPClient if nil
+	Client *http.Client
+	// Prepare changes every request before it is sent
+	Prepare func(r *http.Request) error
+}
+
+func (t *RPCTransport) post(ctx context.Context, url string, message []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.Prepare != nil {
+		if err := t.Prepare(req); err != nil {
+			return nil, err
+		}
+	}
+	hc := t.Client
+	if hc == nil {
+		hc = RPCHTTPClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, &TransportError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRPCResponse))
+	if err != nil {
+		return nil, &TransportError{URL: url, Err: err}
+	}
+	log.Debugw("rpc result", "url", url, "status", resp.StatusCode, "response", string(body))
+	// rpc errors come with 400
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(body))}
+	}
+	return body, nil
+}
+
+func statusBody(body []byte, err error) error {
+	return &StatusError{StatusCode: http.StatusBadRequest, Body: fmt.Sprintf("%s (%v)", bytes.TrimSpace(body), err)}
+}
+
+// Call calls one rpc method
+func (t *RPCTransport) Call(ctx context.Context, url string, method string, input, output interface{}) error {
+	log.Debugw("rpc post", "url", url, "method", method, "input", input)
+	message, err := json.Marshal(&rpcRequest{Version: "2.0", Method: method, Params: input, ID: uint64(rand.Int63())})
+	if err != nil {
+		return err
+	}
+	body, err := t.post(ctx, url, message)
+	if err != nil {
+		return err
+	}
+	var resp rpcResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return statusBody(body, err)
+	}
+	return resp.decode(output)
+}
+
+// Batch sends the calls in one request, the error of every call is set on it.
+// The returned error is the error of the request, the calls are not done then.
+func (t *RPCTransport) Batch(ctx context.Context, url string, calls []*RPCCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	reqs := make([]rpcRequest, len(calls))
+	byID := make(map[uint64]*RPCCall, len(calls))
+	base := uint64(rand.Int63())
+	var methods []string
+	for i, call := range calls {
+		id := base + uint64(i)
+		reqs[i] = rpcRequest{Version: "2.0", Method: call.Method, Params: call.Input, ID: id}
+		byID[id] = call
+		methods = append(methods, call.Method)
+	}
+	log.Debugw("rpc batch", "url", url, "methods", strings.Join(methods, ","))
+	message, err := json.Marshal(reqs)
+	if err != nil {
+		return err
+	}
+	body, err := t.post(ctx, url, message)
+	if err != nil {
+		return err
+	}
+	var resps []rpcResponse
+	if err := json.Unmarshal(body, &resps); err != nil {
+		// a node without batch support answers with one error
+		return statusBody(body, err)
+	}
+	for i := range resps {
+		call, b := byID[resps[i].ID]
+		if !b {
+			continue
+		}
+		call.Err = resps[i].decode(call.Output)
+		delete(byID, resps[i].ID)
+	}
+	for _, call := range byID {
+		call.Err = &RemoteError{Code: -32603, Message: "no response in batch"}
+	}
+	return nil
+}
+
+// RPCPostContext calls one rpc method with the shared client
+func RPCPostContext(ctx context.Context, url string, method string, input, output interface{}) error {
+	return new(RPCTransport).Call(ctx, url, method, input, output)
+}
+
+// RPCPost calls one rpc method, it gives up after DefaultRPCTimeout
+func RPCPost(url string, method string, input, output interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultRPCTimeout)
+	defer cancel()
+	return RPCPostContext(ctx, url, method, input, output)
+}
@@ -9,14 +9,17 @@ require (
 	github.com/elastic/gosigar v0.10.5 // indirect
 	github.com/ethereum/go-ethereum v1.9.11
 	github.com/fatih/color v1.3.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/gocacher/badger-cache/v2 v2.0.1
 	github.com/gocacher/cacher v1.0.5
 	github.com/goextension/extmap v0.0.1
 	github.com/goextension/io v0.0.0-20191016080154-50dbafac3df3
 	github.com/goextension/log v0.0.2
 	github.com/goextension/tool v0.0.2
+	github.com/golang/protobuf v1.3.2
 	github.com/gorilla/mux v1.7.4
 	github.com/gorilla/rpc v1.2.0
+	github.com/gorilla/websocket v1.4.1
 	github.com/ipfs/go-ipfs-http-client v0.0.5
 	github.com/ipfs/interface-go-ipfs-core v0.2.3
 	github.com/libp2p/go-libp2p-core v0.2.3
@@ -27,6 +30,8 @@ require (
 	github.com/spf13/viper v1.3.2
 	go.uber.org/atomic v1.5.0
 	go.uber.org/zap v1.13.0
-	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073 // indirect
+	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073
+	golang.org/x/net v0.0.0-20190628185345-da137c7871d7
 	golang.org/x/sys v0.0.0-20200302150141-5c8b2ff67527 // indirect
+	google.golang.org/grpc v1.27.1
 )
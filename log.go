@@ -5,10 +5,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// logLevel is shared by the loggers of InitLog so SetLogLevel changes them all
+var logLevel = zap.NewAtomicLevel()
+
 // InitLog ...
 func InitLog() {
 	cfg := zap.NewProductionConfig()
-	cfg.Level = logLvToAtomicLv(LogLevel)
+	logLevel.SetLevel(logLvToAtomicLv(LogLevel).Level())
+	cfg.Level = logLevel
 	cfg.OutputPaths = []string{LogOutput}
 	cfg.ErrorOutputPaths = []string{LogOutput}
 	logger, e := cfg.Build(
@@ -22,3 +26,9 @@ func InitLog() {
 
 	log.Debugw("log init", "level", LogLevel, "output", LogOutput)
 }
+
+// SetLogLevel changes the level of the running logger
+func SetLogLevel(lv string) {
+	LogLevel = lv
+	logLevel.SetLevel(logLvToAtomicLv(lv).Level())
+}
@@ -0,0 +1,186 @@
+package record
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Version of the records written by this node
+const Version = 1
+
+const (
+	prefixPlain  = "acc1:"
+	prefixSealed = "acc1s:"
+)
+
+// ErrLegacy the entry is not a node record, it may be written by an older version
+var ErrLegacy = errors.New("not a node record")
+
+// ErrVersion ...
+var ErrVersion = errors.New("unsupported node record version")
+
+// ErrSignature ...
+var ErrSignature = errors.New("invalid node record signature")
+
+// ErrSealed the record is encrypted and no key is configured
+var ErrSealed = errors.New("node record is encrypted")
+
+// Signer signs the records with the publishing account
+type Signer interface {
+	Address() common.Address
+	SignText(data []byte) ([]byte, error)
+}
+
+// Node is the entry stored on chain for a node
+type Node struct {
+	Version   int      `json:"version"`
+	Enode     string   `json:"enode,omitempty"`
+	IPFS      []string `json:"ipfs,omitempty"`
+	RPC       string   `json:"rpc,omitempty"`
+	Account   string   `json:"account"`
+	Timestamp int64    `json:"timestamp"`
+	Signature string   `json:"signature,omitempty"`
+}
+
+// message returns the signed bytes, everything but the signature
+func (n Node) message() ([]byte, error) {
+	n.Signature = ""
+	return json.Marshal(n)
+}
+
+// Sign sets the publishing account and signs the record
+func (n *Node) Sign(signer Signer) error {
+	n.Version = Version
+	n.Account = signer.Address().Hex()
+	if n.Timestamp == 0 {
+		n.Timestamp = time.Now().Unix()
+	}
+	msg, err := n.message()
+	if err != nil {
+		return err
+	}
+	sig, err := signer.SignText(msg)
+	if err != nil {
+		return err
+	}
+	n.Signature = common.Bytes2Hex(sig)
+	return nil
+}
+
+// Verify checks the record is signed by its account
+func (n *Node) Verify() error {
+	if n.Version != Version {
+		return fmt.Errorf("%w: %d", ErrVersion, n.Version)
+	}
+	sig := common.FromHex(n.Signature)
+	if len(sig) != crypto.SignatureLength || !common.IsHexAddress(n.Account) {
+		return ErrSignature
+	}
+	// external signers return the v value as 27/28
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	msg, err := n.message()
+	if err != nil {
+		return err
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(msg), sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(n.Account) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Codec encodes the records stored on chain, they are encrypted when a key is set
+type Codec struct {
+	aead cipher.AEAD
+}
+
+// NewCodec ...
+func NewCodec(key string) (*Codec, error) {
+	if key == "" {
+		return &Codec{}, nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{aead: aead}, nil
+}
+
+// Encode ...
+func (c *Codec) Encode(n *Node) (string, error) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return "", err
+	}
+	if c.aead == nil {
+		return prefixPlain + base64.RawURLEncoding.EncodeToString(data), nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, data, []byte(prefixSealed))
+	return prefixSealed + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode reads and verifies a record
+func (c *Codec) Decode(entry string) (*Node, error) {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(entry, prefixSealed):
+		if c.aead == nil {
+			return nil, ErrSealed
+		}
+		data, err = base64.RawURLEncoding.DecodeString(strings.TrimPrefix(entry, prefixSealed))
+		if err != nil {
+			return nil, err
+		}
+		size := c.aead.NonceSize()
+		if len(data) < size {
+			return nil, ErrSealed
+		}
+		data, err = c.aead.Open(nil, data[:size], data[size:], []byte(prefixSealed))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSealed, err)
+		}
+	case strings.HasPrefix(entry, prefixPlain):
+		data, err = base64.RawURLEncoding.DecodeString(strings.TrimPrefix(entry, prefixPlain))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrLegacy
+	}
+	var n Node
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, err
+	}
+	if err := n.Verify(); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
@@ -0,0 +1,105 @@
+package record
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *keySigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *keySigner) SignText(data []byte) ([]byte, error) {
+	return crypto.Sign(accounts.TextHash(data), s.key)
+}
+
+func newSigner(t *testing.T) *keySigner {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keySigner{key: key}
+}
+
+func signed(t *testing.T, signer Signer) *Node {
+	n := &Node{
+		Enode: "enode://abc@10.0.0.1:30303",
+		IPFS:  []string{"/ip4/10.0.0.1/tcp/4001/ipfs/QmID"},
+		RPC:   "10.0.0.1:16004",
+	}
+	if err := n.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	signer := newSigner(t)
+	for _, key := range []string{"", "network secret"} {
+		codec, err := NewCodec(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := codec.Encode(signed(t, signer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := codec.Decode(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Enode != "enode://abc@10.0.0.1:30303" || n.Account != signer.Address().Hex() || n.Version != Version {
+			t.Fatalf("decoded %+v", n)
+		}
+	}
+}
+
+func TestRecordTampered(t *testing.T) {
+	signer := newSigner(t)
+	n := signed(t, signer)
+	n.Enode = "enode://abc@10.6.6.6:30303"
+	if err := n.Verify(); !errors.Is(err, ErrSignature) {
+		t.Fatalf("tampered record: %v", err)
+	}
+
+	// a record claiming another account
+	n = signed(t, signer)
+	n.Account = newSigner(t).Address().Hex()
+	if err := n.Verify(); !errors.Is(err, ErrSignature) {
+		t.Fatalf("forged account: %v", err)
+	}
+
+	n = signed(t, signer)
+	n.Version = Version + 1
+	if err := n.Verify(); !errors.Is(err, ErrVersion) {
+		t.Fatalf("future version: %v", err)
+	}
+}
+
+func TestRecordSealed(t *testing.T) {
+	sealed, _ := NewCodec("network secret")
+	entry, err := sealed.Encode(signed(t, newSigner(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := NewCodec("")
+	if _, err := plain.Decode(entry); !errors.Is(err, ErrSealed) {
+		t.Fatalf("decoded without key: %v", err)
+	}
+	other, _ := NewCodec("other secret")
+	if _, err := other.Decode(entry); !errors.Is(err, ErrSealed) {
+		t.Fatalf("decoded with the wrong key: %v", err)
+	}
+	if _, err := plain.Decode("c2VjcmV0IG5vZGU"); !errors.Is(err, ErrLegacy) {
+		t.Fatalf("legacy entry: %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// RefPrefix marks a config value that is kept in the secrets store
+const RefPrefix = "secret:"
+
+// ErrNotFound ...
+var ErrNotFound = errors.New("secret not found")
+
+// ErrPassphrase ...
+var ErrPassphrase = errors.New("wrong secrets passphrase or damaged secrets file")
+
+// Store keeps secrets by id
+type Store interface {
+	Get(id string) (string, error)
+	Set(id string, value string) error
+	Delete(id string) error
+	List() ([]string, error)
+}
+
+// Ref returns the config value that refers to a secret
+func Ref(id string) string {
+	return RefPrefix + id
+}
+
+// ParseRef returns the id of a secret reference
+func ParseRef(v string) (string, bool) {
+	if !strings.HasPrefix(v, RefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(v, RefPrefix), true
+}
+
+// EnvName returns the environment variable that overrides a secret
+func EnvName(id string) string {
+	return "ACCIPFS_SECRET_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// Env returns the override of a secret
+func Env(id string) (string, bool) {
+	return os.LookupEnv(EnvName(id))
+}
+
+// writeFile replaces the file so it is never readable by others, not even half written
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// scrypt cost of the secrets file
+var (
+	scryptN = 1 << 18
+	scryptP = 1
+)
+
+const scryptR = 8
+
+type sealedFile struct {
+	Version    int    `json:"version"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	CipherText string `json:"ciphertext"`
+}
+
+// FileStore keeps the secrets in one file encrypted with a master passphrase
+type FileStore struct {
+	mut        sync.Mutex
+	path       string
+	passphrase func() (string, error)
+	aead       cipher.AEAD
+	salt       []byte
+}
+
+// NewFileStore ...
+func NewFileStore(path string, passphrase func() (string, error)) *FileStore {
+	return &FileStore{path: path, passphrase: passphrase}
+}
+
+func (s *FileStore) cipher(salt []byte, n, r, p int) (cipher.AEAD, error) {
+	if s.aead != nil && string(s.salt) == string(salt) {
+		return s.aead, nil
+	}
+	pass, err := s.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(pass), salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s.aead, s.salt = aead, salt
+	return aead, nil
+}
+
+func (s *FileStore) load() (map[string]string, error) {
+	secrets := make(map[string]string)
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f sealedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("secrets file %s: %w", s.path, err)
+	}
+	salt, err := hex.DecodeString(f.Salt)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	aead, err := s.cipher(salt, f.N, f.R, f.P)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(f.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, ErrPassphrase
+	}
+	sealed, err := hex.DecodeString(f.CipherText)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (s *FileStore) save(secrets map[string]string) error {
+	if s.aead == nil {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		if _, err := s.cipher(salt, scryptN, scryptR, scryptP); err != nil {
+			return err
+		}
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(&sealedFile{
+		Version:    1,
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Salt:       hex.EncodeToString(s.salt),
+		Nonce:      hex.EncodeToString(nonce),
+		CipherText: hex.EncodeToString(s.aead.Seal(nil, nonce, plain, nil)),
+	}, "", " ")
+	if err != nil {
+		return err
+	}
+	return writeFile(s.path, data)
+}
+
+// Get ...
+func (s *FileStore) Get(id string) (string, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	secrets, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	v, b := secrets[id]
+	if !b {
+		return "", fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	return v, nil
+}
+
+// Set ...
+func (s *FileStore) Set(id string, value string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[id] = value
+	return s.save(secrets)
+}
+
+// Delete ...
+func (s *FileStore) Delete(id string) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(secrets, id)
+	return s.save(secrets)
+}
+
+// List ...
+func (s *FileStore) List() ([]string, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	secrets, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for id := range secrets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// DirStore keeps every secret in its own file, readable by the owner only.
+// It is the layout of keyring file backends and mounted container secrets.
+type DirStore string
+
+func (d DirStore) file(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id[0] == '.' {
+		return "", fmt.Errorf("invalid secret id %q", id)
+	}
+	return filepath.Join(string(d), id), nil
+}
+
+// Get ...
+func (d DirStore) Get(id string) (string, error) {
+	path, err := d.file(id)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Set ...
+func (d DirStore) Set(id string, value string) error {
+	path, err := d.file(id)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, []byte(value))
+}
+
+// Delete ...
+func (d DirStore) Delete(id string) error {
+	path, err := d.file(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List ...
+func (d DirStore) List() ([]string, error) {
+	infos, err := ioutil.ReadDir(string(d))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, info := range infos {
+		if !info.IsDir() && !strings.HasPrefix(info.Name(), ".") && !strings.HasSuffix(info.Name(), ".tmp") {
+			ids = append(ids, info.Name())
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,85 @@
+package secret
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, s Store) {
+	if _, err := s.Get("account"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("get missing: %v", err)
+	}
+	if err := s.Set("account", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("record_key", "key"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Get("account")
+	if err != nil || v != "value" {
+		t.Fatalf("get %q: %v", v, err)
+	}
+	if err := s.Delete("record_key"); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := s.List()
+	if err != nil || len(ids) != 1 || ids[0] != "account" {
+		t.Fatalf("list %v: %v", ids, err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	scryptN = 1 << 12
+	dir, err := ioutil.TempDir("", "accipfs-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "secrets.json")
+	pass := func(p string) func() (string, error) {
+		return func() (string, error) { return p, nil }
+	}
+	testStore(t, NewFileStore(path, pass("master")))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("mode %v", info.Mode())
+	}
+	if _, err := NewFileStore(path, pass("wrong")).Get("account"); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("wrong passphrase: %v", err)
+	}
+	v, err := NewFileStore(path, pass("master")).Get("account")
+	if err != nil || v != "value" {
+		t.Fatalf("reopened %q: %v", v, err)
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accipfs-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testStore(t, DirStore(filepath.Join(dir, "secrets")))
+	if err := DirStore(dir).Set("../escape", "v"); err == nil {
+		t.Fatal("id with a path was accepted")
+	}
+}
+
+func TestRef(t *testing.T) {
+	if id, b := ParseRef(Ref("aws_secret_access_key")); !b || id != "aws_secret_access_key" {
+		t.Fatalf("parsed %q", id)
+	}
+	if _, b := ParseRef("plain"); b {
+		t.Fatal("plain value parsed as reference")
+	}
+	if EnvName("aws_secret_access_key") != "ACCIPFS_SECRET_AWS_SECRET_ACCESS_KEY" {
+		t.Fatal(EnvName("aws_secret_access_key"))
+	}
+}
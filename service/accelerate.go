@@ -3,20 +3,26 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/glvd/accipfs/client"
 	"github.com/glvd/accipfs/task"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/glvd/accipfs/account"
 	"github.com/glvd/accipfs/cache"
 	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/contract/token"
 	"github.com/glvd/accipfs/core"
 	"github.com/glvd/accipfs/general"
+	"github.com/glvd/accipfs/record"
 	"github.com/goextension/log"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/atomic"
@@ -32,11 +38,31 @@ type Accelerate struct {
 	lock       *atomic.Bool
 	self       *account.Account
 	cfg        *config.Config
-	ethServer  *nodeServerETH
-	ethClient  *nodeClientETH
-	ipfsServer *nodeServerIPFS
-	ipfsClient *nodeClientIPFS
+	ethServer  NodeServer
+	ethClient  ethBackend
+	ipfsServer NodeServer
+	ipfsClient ipfsBackend
+	contract   contract.Contractor
+	records    *nodeRecords
+	signer     record.Signer
 	cron       *cron.Cron
+	bus        *eventBus
+	notifier   *notifier
+	writers    *writerIndex
+	scheduler  *pinScheduler
+	reputation *reputation
+	bandwidth  *bandwidthMeter
+	dialChain  func(ctx context.Context) (chainBackend, error)
+	loadConfig func() (*config.Config, error)
+	// live is cfg with the changes of the reloads, settingsMut guards it with the sync job and the hooks
+	settingsMut sync.RWMutex
+	live        *config.Config
+	reloaded    []func(cfg *config.Config)
+	job         cron.EntryID
+	// peerRefresh queues one reading of the node list
+	peerRefresh chan struct{}
+	ctx         context.Context
+	cancel      context.CancelFunc
 }
 
 // BootList ...
@@ -46,17 +72,73 @@ var BootList = []string{
 
 // NewAccelerateServer ...
 func NewAccelerateServer(cfg *config.Config) (acc *Accelerate, err error) {
+	signer, err := account.NewSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := signer.Unlock(); err != nil {
+		return nil, err
+	}
+	shared, err := newNodeShared(cfg, signer)
+	if err != nil {
+		return nil, err
+	}
+	ethClient, _ := newNodeETH(cfg, shared.contract, shared.leader, shared.records)
+	ipfsClient, _ := newNodeIPFS(cfg, shared.contract, shared.leader, shared.records)
+	acc, err = newAccelerate(cfg, &backend{
+		contract:   shared.contract,
+		ethServer:  newNodeServerETH(cfg, shared),
+		ipfsServer: newNodeServerIPFS(cfg, shared),
+		eth:        ethClient,
+		ipfs:       ipfsClient,
+		cache:      cache.New(cfg),
+		records:    shared.records,
+		signer:     signer,
+		chain:      dialChain(cfg),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// dns changes of a reload apply to the next sync
+	ethClient.settings = acc.settings
+	// the gateways are weighed by the traffic fetched from them
+	ethClient.served = acc.servedBy
+	return acc, nil
+}
+
+func newAccelerate(cfg *config.Config, b *backend) (acc *Accelerate, err error) {
 	acc = &Accelerate{
 		nodes:      core.NewNodeStore(),
 		dummyNodes: core.NewNodeStore(),
 		lock:       atomic.NewBool(false),
 		cfg:        cfg,
-	}
-	acc.ethServer = newNodeServerETH(cfg)
-	acc.ipfsServer = newNodeServerIPFS(cfg)
-	acc.ethClient, _ = newNodeETH(cfg)
-	acc.ipfsClient, _ = newNodeIPFS(cfg)
-	acc.cache = cache.New(cfg)
+		ethServer:  b.ethServer,
+		ethClient:  b.eth,
+		ipfsServer: b.ipfsServer,
+		ipfsClient: b.ipfs,
+		contract:   b.contract,
+		cache:      b.cache,
+		records:    b.records,
+		signer:     b.signer,
+		dialChain:  b.chain,
+		loadConfig: configLoader(cfg),
+		live:       cfg,
+		bus:        newEventBus(),
+		notifier:   newNotifier(),
+		writers:    newWriterIndex(),
+		reputation: newReputation(),
+		bandwidth:  newBandwidthMeter(),
+
+		peerRefresh: make(chan struct{}, 1),
+	}
+	acc.ctx, acc.cancel = context.WithCancel(context.Background())
+	acc.scheduler = newPinScheduler(acc.pinPaid, acc.revertPin)
+	acc.scheduler.queued = func(job pinJob) {
+		acc.pinProgress(&core.PinProgress{Hash: job.Hash, User: job.User, State: core.PinQueued}, nil)
+	}
+	acc.subscribe()
+	acc.loadWriters()
+	acc.loadBandwidth()
 	acc.tasks = task.New()
 	acc.cron = cron.New(cron.WithSeconds())
 	selfAcc, err := account.LoadAccount(cfg)
@@ -91,11 +173,24 @@ func (a *Accelerate) Start() {
 	//}
 	//fmt.Println(outputHead, "IPFS", "run id", jobIPFS)
 
-	jobAcc, err := a.cron.AddJob("0 1/3 * * * *", a)
-	if err != nil {
+	if a.dialChain != nil {
+		go a.watchChain(a.ctx)
+	}
+	// the node list is read once, the watcher only sees the changes of new blocks
+	select {
+	case a.peerRefresh <- struct{}{}:
+	default:
+	}
+	go a.managePeers(a.ctx)
+	go a.scheduler.Run(a.ctx)
+	if a.cfg.Challenge.Interval > 0 {
+		go a.challengeLoop(a.ctx)
+	}
+	go a.watchConfig(a.ctx, config.FilePath(a.cfg.Dir()))
+
+	if err := a.schedule(a.settings().Schedule); err != nil {
 		panic(err)
 	}
-	fmt.Println(outputHead, "Accelerate", "run id", jobAcc)
 	a.cron.Run()
 }
 
@@ -108,25 +203,17 @@ func (a *Accelerate) Run() {
 	a.lock.Store(true)
 	defer a.lock.Store(false)
 	ctx := context.TODO()
+	start := time.Now()
 	a.nodes.Range(func(info *core.NodeInfo) bool {
 		fmt.Println(outputHead, "Accelerate", "syncing node", info.Name)
 
-		err := client.Ping(info)
-		if err != nil {
-			a.nodes.Remove(info.Name)
-			a.dummyNodes.Add(info)
-			log.Errorw("ping failed", "account", info.Name, "error", err)
-			return true
-		}
-		url := info.Address().URL()
-		nodeInfos, err := client.Peers(url, info)
+		nodeInfos, err := a.syncNode(ctx, info)
 		if err != nil {
-			log.Errorw("get peers failed", "account", info.Name, "error", err)
 			return true
 		}
 
 		for _, nodeInfo := range nodeInfos {
-			if a.nodes.Length() > a.cfg.Limit {
+			if a.nodes.Length() > a.settings().Limit {
 				return false
 			}
 			result := new(bool)
@@ -135,12 +222,16 @@ func (a *Accelerate) Run() {
 				continue
 			}
 			if *result {
-				pins, err := client.Pins(nodeInfo)
+				pins, err := a.peerPins(ctx, nodeInfo)
 				if err != nil {
 					log.Errorw("get pin list", "error", err)
 					continue
 				}
 				for _, p := range pins {
+					if a.reputation.Failed(nodeInfo.Name, p) {
+						// the peer lied about the hash in a storage challenge
+						continue
+					}
 					err := a.cache.AddOrUpdate(p, nodeInfo)
 					if err != nil {
 						log.Errorw("cache add or update", "error", err)
@@ -153,11 +244,135 @@ func (a *Accelerate) Run() {
 		//time.Sleep(30 * time.Second)
 		return true
 	})
+	a.meterPeers(ctx)
+	a.notifier.Publish(core.TopicSyncDone, &core.SyncDone{Peers: a.nodes.Length(), Duration: time.Since(start).Milliseconds()})
 	fmt.Println(outputHead, "Accelerate", "syncing done")
 }
 
+// syncNode pings a node and asks for its peers, over grpc or in one batch
+// when the peer has the capability
+func (a *Accelerate) syncNode(ctx context.Context, info *core.NodeInfo) ([]*core.NodeInfo, error) {
+	timeout := time.Duration(a.settings().Interval) * time.Second
+	if timeout <= 0 || timeout > client.DefaultTimeout {
+		timeout = client.DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if g := a.grpcPeer(info); g != nil {
+		err := g.Ping(ctx)
+		var peers []*core.NodeInfo
+		if err == nil {
+			peers, err = g.Peers(ctx)
+		}
+		if err == nil {
+			return peers, nil
+		}
+		log.Debugw("grpc sync failed, using json-rpc", "tag", outputHead, "peer", info.Name, "error", err)
+	}
+	c := client.NewNode(info)
+	pong := new(string)
+	var peers []*core.NodeInfo
+	ping := &general.RPCCall{Method: "Accelerate.Ping", Input: core.DummyEmpty(), Output: pong}
+	list := &general.RPCCall{Method: "Accelerate.Peers", Input: core.DummyEmpty(), Output: &peers}
+	// legacy peers and peers without batch support are asked with single calls
+	batch := a.uses(info, core.CapabilityBatch)
+	var err error
+	if batch {
+		err = c.Batch(ctx, ping, list)
+	}
+	var status *general.StatusError
+	if !batch || errors.As(err, &status) {
+		err = c.Ping(ctx)
+		if err == nil {
+			peers, err = c.Peers(ctx)
+			if err != nil {
+				log.Errorw("get peers failed", "account", info.Name, "error", err)
+				return nil, err
+			}
+		}
+	} else if err == nil {
+		err = ping.Err
+		if err == nil && *pong != "pong" {
+			err = fmt.Errorf("get wrong response data:%s", *pong)
+		}
+		if err == nil && list.Err != nil {
+			log.Errorw("get peers failed", "account", info.Name, "error", list.Err)
+			return nil, list.Err
+		}
+	}
+	if err != nil {
+		a.dropPeer(info)
+		log.Errorw("ping failed", "account", info.Name, "error", err)
+		return nil, err
+	}
+	return peers, nil
+}
+
+func grpcAddr(info *core.NodeInfo) string {
+	return fmt.Sprintf("%s:%d", info.RemoteAddr, info.GRPCPort)
+}
+
+// grpcPeer returns the grpc client of a peer when both nodes speak grpc
+func (a *Accelerate) grpcPeer(info *core.NodeInfo) *client.GRPC {
+	if !a.uses(info, core.CapabilityGRPC) || info.GRPCPort <= 0 {
+		return nil
+	}
+	g, err := client.NewGRPC(grpcAddr(info))
+	if err != nil {
+		log.Debugw("grpc dial", "tag", outputHead, "peer", info.Name, "error", err)
+		return nil
+	}
+	return g
+}
+
+// closeGRPC closes the grpc connection of a peer that failed or was dropped
+func (a *Accelerate) closeGRPC(info *core.NodeInfo) {
+	if info.GRPCPort <= 0 {
+		return
+	}
+	if err := client.CloseGRPC(grpcAddr(info)); err != nil {
+		log.Debugw("grpc close", "tag", outputHead, "peer", info.Name, "error", err)
+	}
+}
+
+// pingPeer checks that the peer answers, a peer that speaks grpc is told
+// about this node with Connected so both nodes know each other
+func (a *Accelerate) pingPeer(ctx context.Context, info *core.NodeInfo) error {
+	if g := a.grpcPeer(info); g != nil {
+		id, err := a.localID()
+		if err != nil {
+			return err
+		}
+		remote, err := g.Connected(ctx, id)
+		if err == nil {
+			if err := core.Compatible(remote); err != nil {
+				return incompatible(err)
+			}
+			return nil
+		}
+		log.Debugw("grpc connected failed, using json-rpc", "tag", outputHead, "peer", info.Name, "error", err)
+		a.closeGRPC(info)
+	}
+	return client.NewNode(info).Ping(ctx)
+}
+
+// peerPins gets the pin list of a peer, streamed over grpc when it can
+func (a *Accelerate) peerPins(ctx context.Context, info *core.NodeInfo) ([]string, error) {
+	if g := a.grpcPeer(info); g != nil {
+		pins, err := g.Pins(ctx)
+		if err == nil {
+			return pins, nil
+		}
+		log.Debugw("grpc pins failed, using json-rpc", "tag", outputHead, "peer", info.Name, "error", err)
+		a.closeGRPC(info)
+	}
+	return client.NewNode(info).Pins(ctx)
+}
+
 // Stop ...
 func (a *Accelerate) Stop() {
+	a.cancel()
+	a.saveBandwidth()
 	ctx := a.cron.Stop()
 	<-ctx.Done()
 	if err := a.ethServer.Stop(); err != nil {
@@ -171,6 +386,20 @@ func (a *Accelerate) Stop() {
 	}
 }
 
+// gatewayIPs returns the addresses of the nodes that answered the last ping,
+// this node is included when its public ip is set
+func (a *Accelerate) gatewayIPs() []string {
+	var ips []string
+	if ip := os.Getenv("IP"); ip != "" {
+		ips = append(ips, ip)
+	}
+	a.nodes.Range(func(info *core.NodeInfo) bool {
+		ips = append(ips, info.RemoteAddr)
+		return true
+	})
+	return ips
+}
+
 // Ping ...
 func (a *Accelerate) Ping(r *http.Request, e *core.Empty, result *string) error {
 	*result = "pong"
@@ -181,8 +410,14 @@ func (a *Accelerate) localID() (*core.NodeInfo, error) {
 	var info core.NodeInfo
 	info.Name = a.self.Name
 	info.Version = core.Version
+	info.Protocol = core.ProtocolVersion
+	info.MinProtocol = core.MinProtocolVersion
+	info.Capabilities = a.capabilities()
 	info.RemoteAddr = "127.0.0.1"
 	info.Port = a.cfg.Port
+	if a.cfg.GRPC.Enable {
+		info.GRPCPort = a.cfg.GRPC.Port
+	}
 	log.Debugw("print remote ip", "tag", outputHead, "ip", info.RemoteAddr, "port", info.Port)
 	ds, err := a.ipfsClient.ID(context.Background())
 	if err != nil {
@@ -197,6 +432,21 @@ func (a *Accelerate) localID() (*core.NodeInfo, error) {
 	return &info, nil
 }
 
+// capabilities are announced to the peers with the id
+func (a *Accelerate) capabilities() []string {
+	caps := []string{core.CapabilityBatch, core.CapabilityEvents, core.CapabilityREST}
+	if a.cfg.GRPC.Enable {
+		caps = append(caps, core.CapabilityGRPC)
+	}
+	return caps
+}
+
+// uses reports whether this node and the peer both have the capability
+func (a *Accelerate) uses(info *core.NodeInfo, capability string) bool {
+	local := &core.NodeInfo{Protocol: core.ProtocolVersion, Capabilities: a.capabilities()}
+	return core.Common(local, info, capability)
+}
+
 // ID ...
 func (a *Accelerate) ID(r *http.Request, e *core.Empty, result *core.NodeInfo) error {
 	id, err := a.localID()
@@ -207,6 +457,13 @@ func (a *Accelerate) ID(r *http.Request, e *core.Empty, result *core.NodeInfo) e
 	return nil
 }
 
+// Version returns the build and the peer protocol of the node
+func (a *Accelerate) Version(r *http.Request, _ *core.Empty, result *core.BuildInfo) error {
+	*result = *core.Build()
+	result.Capabilities = a.capabilities()
+	return nil
+}
+
 // Connected ...
 func (a *Accelerate) Connected(r *http.Request, node *core.NodeInfo, result *core.NodeInfo) error {
 	log.Infow("connected", "tag", outputHead, "addr", r.RemoteAddr)
@@ -214,6 +471,9 @@ func (a *Accelerate) Connected(r *http.Request, node *core.NodeInfo, result *cor
 		return fmt.Errorf("nil node info")
 	}
 
+	if err := core.Compatible(node); err != nil {
+		return rpcError(incompatible(err))
+	}
 	node.RemoteAddr, _ = general.SplitIP(r.RemoteAddr)
 
 	id, err := a.localID()
@@ -229,25 +489,28 @@ func (a *Accelerate) Connected(r *http.Request, node *core.NodeInfo, result *cor
 		}
 		return nil
 	}
-	if !a.nodes.Check(node.Name) {
-		a.nodes.Add(node)
-		return nil
-	}
+	a.joinPeer(node)
 	return nil
 }
 
 // ConnectTo ...
-func (a Accelerate) ConnectTo(r *http.Request, addr *string, result *core.NodeInfo) error {
+func (a *Accelerate) ConnectTo(r *http.Request, addr *string, result *core.NodeInfo) error {
 	id, err := a.localID()
 	if err != nil {
 		return err
 	}
-	url := fmt.Sprintf("http://%s/rpc", *addr)
-
-	err = general.RPCPost(url, "Accelerate.Connected", id, result)
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	remote, err := client.New(fmt.Sprintf("http://%s/rpc", *addr)).Connected(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := core.Compatible(remote); err != nil {
+		return rpcError(incompatible(err))
+	}
+	*result = *remote
 	result.RemoteAddr, result.Port = general.SplitIP(*addr)
 	return nil
 }
@@ -259,15 +522,21 @@ func (a *Accelerate) addPeer(ctx context.Context, info *core.NodeInfo, result *b
 		//ignore self add
 		return nil
 	}
+	if !a.trusted(info.Name) {
+		return fmt.Errorf("peer %s failed too many storage challenges", info.Name)
+	}
+	if err := core.Compatible(info); err != nil {
+		return incompatible(err)
+	}
 
-	err := client.Ping(info)
+	err := a.pingPeer(ctx, info)
 	if err != nil {
 		log.Errorw("add peer", "tag", outputHead, "error", err)
 		a.dummyNodes.Add(info)
 		return err
 	}
 
-	ipfsTimeout, cancelFunc := context.WithTimeout(ctx, time.Duration(a.cfg.Interval)*time.Second)
+	ipfsTimeout, cancelFunc := context.WithTimeout(ctx, time.Duration(a.settings().Interval)*time.Second)
 	var ipfsErr error
 	for _, addr := range info.DataStore.Addresses {
 		ipfsErr = a.ipfsClient.SwarmConnect(ipfsTimeout, addr)
@@ -282,7 +551,8 @@ func (a *Accelerate) addPeer(ctx context.Context, info *core.NodeInfo, result *b
 
 		return err
 	}
-	ethTimeout, cancelFunc := context.WithTimeout(ctx, time.Duration(a.cfg.Interval)*time.Second)
+	ethTimeout, cancelFunc := context.WithTimeout(ctx, time.Duration(a.settings().Interval)*time.Second)
+	defer cancelFunc()
 	//fmt.Println("connect eth:", info.Contract.Enode)
 	err = a.ethClient.AddPeer(ethTimeout, info.Contract.Enode)
 	if err != nil {
@@ -290,16 +560,15 @@ func (a *Accelerate) addPeer(ctx context.Context, info *core.NodeInfo, result *b
 		log.Errorw("add peer", "tag", outputHead, "error", err)
 		return err
 	}
-	cancelFunc()
 
-	a.nodes.Add(info)
+	a.joinPeer(info)
 	*result = true
 	return nil
 }
 
 // AddPeer ...
 func (a *Accelerate) AddPeer(r *http.Request, info *core.NodeInfo, result *bool) error {
-	return a.addPeer(r.Context(), info, result)
+	return rpcError(a.addPeer(r.Context(), info, result))
 }
 
 // Peers ...
@@ -312,13 +581,11 @@ func (a *Accelerate) Peers(r *http.Request, _ *core.Empty, result *[]*core.NodeI
 }
 
 func (a *Accelerate) pins(ctx context.Context, result *[]string) error {
-	pins, e := a.ipfsClient.PinLS(ctx)
+	pins, e := a.ipfsClient.PinHashes(ctx)
 	if e != nil {
 		return e
 	}
-	for _, p := range pins {
-		*result = append(*result, p.Path().String())
-	}
+	*result = append(*result, pins...)
 	return nil
 }
 
@@ -329,21 +596,18 @@ func (a *Accelerate) Pins(r *http.Request, _ *core.Empty, result *[]string) erro
 
 // PinVideo ...
 func (a *Accelerate) PinVideo(r *http.Request, no *string, result *bool) error {
-	info := new(string)
-	err := a.tagInfo(*no, info)
+	v, err := a.video(*no)
 	if err != nil {
-		return err
+		return rpcError(err)
 	}
-
-	var v core.VideoV1
-	reader := strings.NewReader(*info)
-	decoder := json.NewDecoder(reader)
-	err = decoder.Decode(&v)
-	if err != nil {
-		return err
+	hashes := []string{v.PosterHash, v.ThumbHash, v.SourceHash, v.M3U8Hash}
+	if err := a.cache.SetVideoNo(v.No, hashes...); err != nil {
+		log.Errorw("index video hashes", "tag", outputHead, "no", v.No, "error", err)
 	}
+	local := a.pinnedSet(r.Context())
+	a.videoProgress(v.No, hashes, core.PinPinning, nil)
 	wg := sync.WaitGroup{}
-	resultErr := make(chan error)
+	resultErr := make(chan error, 4)
 	ctx, cancelFunc := context.WithCancel(r.Context())
 	wg.Add(1)
 	go func() {
@@ -414,32 +678,47 @@ func (a *Accelerate) PinVideo(r *http.Request, no *string, result *bool) error {
 	wg.Wait()
 	select {
 	case e := <-resultErr:
+		a.videoProgress(v.No, hashes, core.PinFailed, e)
 		return e
 	default:
 	}
+	a.videoProgress(v.No, hashes, core.PinPinned, nil)
+	a.meterVideo(r.Context(), v.No, hashes, local)
 	*result = true
 	return nil
 }
 
 func (a *Accelerate) tagInfo(tag string, info *string) error {
-	dTag, e := a.ethClient.DTag()
-	if e != nil {
-		return e
+	if err := checkNo(tag); err != nil {
+		return err
 	}
-	message, e := dTag.GetTagMessage(&bind.CallOpts{Pending: true}, "video", tag)
+	message, e := a.ethClient.FindNo(context.TODO(), tag)
 	if e != nil {
 		return e
 	}
-
-	if message.Size.Int64() > 0 {
-		*info = message.Value[0]
+	if message == "" {
+		return notFound("video %s not found", tag)
 	}
+	*info = message
 	return nil
 }
 
+// video reads the message of a video number
+func (a *Accelerate) video(no string) (*core.VideoV1, error) {
+	info := new(string)
+	if err := a.tagInfo(no, info); err != nil {
+		return nil, err
+	}
+	var v core.VideoV1
+	if err := json.NewDecoder(strings.NewReader(*info)).Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode video %s: %w", no, err)
+	}
+	return &v, nil
+}
+
 // TagInfo ...
 func (a *Accelerate) TagInfo(_ *http.Request, tag *string, info *string) error {
-	return a.tagInfo(*tag, info)
+	return rpcError(a.tagInfo(*tag, info))
 }
 
 // Info ...
@@ -452,12 +731,155 @@ func (a *Accelerate) Info(r *http.Request, hash *string, info *string) error {
 	return nil
 }
 
+// Transactions ...
+func (a *Accelerate) Transactions(r *http.Request, _ *core.Empty, result *[]contract.TxRecord) error {
+	if a.contract == nil {
+		return fmt.Errorf("contract is not loaded")
+	}
+	*result = a.contract.Transactions()
+	return nil
+}
+
+// Writers returns the accounts that may write tag messages
+func (a *Accelerate) Writers(r *http.Request, _ *core.Empty, result *[]string) error {
+	*result = a.writers.List()
+	return nil
+}
+
+// UserRepo lists the hashes a user paid to pin with the proofs of this node
+func (a *Accelerate) UserRepo(r *http.Request, address *string, result *[]core.RepoEntry) error {
+	if a.contract == nil {
+		return fmt.Errorf("contract is not loaded")
+	}
+	if !common.IsHexAddress(*address) {
+		return fmt.Errorf("invalid address: %s", *address)
+	}
+	var hashes []string
+	err := a.contract.Token(func(token *token.DhToken, tx *contract.Transactor) error {
+		var err error
+		hashes, err = token.GetUserRepo(&bind.CallOpts{Context: r.Context()}, common.HexToAddress(*address))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		entry := core.RepoEntry{Hash: hash}
+		if proof, err := a.cache.GetPinProof(hash); err == nil {
+			entry.Proof = proof
+		}
+		*result = append(*result, entry)
+	}
+	return nil
+}
+
+// PinProof ...
+func (a *Accelerate) PinProof(r *http.Request, hash *string, result *core.PinProof) error {
+	proof, err := a.cache.GetPinProof(*hash)
+	if err != nil {
+		return err
+	}
+	*result = *proof
+	return nil
+}
+
 // Exchange ...
 func (a *Accelerate) Exchange(r *http.Request, n *core.NodeInfo, to []string) error {
 
 	return nil
 }
 
+// pin connects to the nodes known to have the hash and pins it, ipfs looks
+// for other providers itself when none is known
+func (a *Accelerate) pin(ctx context.Context, hash string) error {
+	if err := a.nodeConnect(ctx, hash); err != nil {
+		log.Debugw("no known provider", "tag", outputHead, "hash", hash, "error", err)
+	}
+	return a.ipfsClient.PinAdd(ctx, hash)
+}
+
+// pinPaid pins a paid hash when this node is one of its assignees and keeps the proof
+func (a *Accelerate) pinPaid(ctx context.Context, job pinJob) error {
+	self := a.self.Name
+	nodes := []string{self}
+	a.nodes.Range(func(info *core.NodeInfo) bool {
+		nodes = append(nodes, info.Name)
+		return true
+	})
+	assigned := assignees(job.Hash, nodes, a.settings().Pin.Replicas)
+	serve := false
+	for _, node := range assigned {
+		serve = serve || node == self
+	}
+	progress := &core.PinProgress{Hash: job.Hash, User: job.User}
+	if !serve {
+		fmt.Println(outputHead, "Accelerate", "pin", job.Hash, "assigned to", assigned)
+		progress.State = core.PinSkipped
+		a.pinProgress(progress, nil)
+		return nil
+	}
+	progress.State = core.PinPinning
+	a.pinProgress(progress, nil)
+	if err := a.pin(ctx, job.Hash); err != nil {
+		a.pinProgress(progress, err)
+		return err
+	}
+	proof := &core.PinProof{
+		Hash:     job.Hash,
+		User:     job.User,
+		Date:     job.Date,
+		Block:    job.Block,
+		Tx:       job.Tx,
+		Node:     self,
+		PinnedAt: time.Now().Unix(),
+	}
+	if a.signer != nil {
+		proof.Account = a.signer.Address().Hex()
+		msg, err := proof.Message()
+		if err != nil {
+			return err
+		}
+		sig, err := a.signer.SignText(msg)
+		if err != nil {
+			return err
+		}
+		proof.Signature = common.Bytes2Hex(sig)
+	}
+	fmt.Println(outputHead, "Accelerate", "pinned", job.Hash, "for", job.User)
+	progress.State = core.PinPinned
+	a.pinProgress(progress, nil)
+	return a.cache.SetPinProof(proof)
+}
+
+// revertPin unpins the hash of a pin transaction that was reorganized away,
+// the pin stays when another payment or a video still references the hash
+func (a *Accelerate) revertPin(job pinJob) {
+	proof, err := a.cache.GetPinProof(job.Hash)
+	if err != nil || proof.Tx != job.Tx {
+		// the hash was not pinned for this payment
+		return
+	}
+	if err := a.cache.DeletePinProof(job.Hash); err != nil {
+		log.Errorw("delete pin proof", "tag", outputHead, "hash", job.Hash, "error", err)
+		return
+	}
+	progress := &core.PinProgress{Hash: job.Hash, User: job.User, State: core.PinReverted}
+	if no, err := a.cache.GetVideoNo(job.Hash); err == nil {
+		fmt.Println(outputHead, "Accelerate", "pin", job.Hash, "reverted, kept for video", no)
+		a.pinProgress(progress, nil)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := a.ipfsClient.PinRm(ctx, job.Hash); err != nil {
+		log.Errorw("unpin reverted hash", "tag", outputHead, "hash", job.Hash, "error", err)
+		a.pinProgress(progress, err)
+		return
+	}
+	fmt.Println(outputHead, "Accelerate", "unpinned", job.Hash, "reverted payment of", job.User)
+	a.pinProgress(progress, nil)
+}
+
 func (a *Accelerate) nodeConnect(ctx context.Context, hash string) error {
 	hashInfo, err := a.cache.GetHashInfo(hash)
 	if err != nil {
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/goextension/log"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// maxNoLength is the longest video number accepted
+const maxNoLength = 128
+
+// Json-rpc codes of the application, the server error range is free for it
+const (
+	// errNotFound a resource is missing
+	errNotFound json2.ErrorCode = -32004
+	// errIncompatible the peer protocol of the caller is not spoken
+	errIncompatible json2.ErrorCode = -32005
+)
+
+// apiError is an error of a request, the rpc and the rest api report it with
+// the same code and message
+type apiError struct {
+	Code    json2.ErrorCode
+	Message string
+}
+
+// Error ...
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func invalidParams(format string, args ...interface{}) *apiError {
+	return &apiError{Code: json2.E_BAD_PARAMS, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) *apiError {
+	return &apiError{Code: errNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func incompatible(err error) *apiError {
+	return &apiError{Code: errIncompatible, Message: err.Error()}
+}
+
+// checkNo validates a video number
+func checkNo(no string) error {
+	switch {
+	case no == "":
+		return invalidParams("video number is empty")
+	case len(no) > maxNoLength:
+		return invalidParams("video number is longer than %d", maxNoLength)
+	case strings.IndexFunc(no, unicode.IsSpace) >= 0:
+		return invalidParams("video number %q has spaces", no)
+	}
+	return nil
+}
+
+// rpcError returns the json-rpc error of an api error, gorilla sends other errors as server errors
+func rpcError(err error) error {
+	var e *apiError
+	if errors.As(err, &e) {
+		return &json2.Error{Code: e.Code, Message: e.Message}
+	}
+	return err
+}
+
+// errorCode is the json-rpc code of an error, the errors the rpc methods
+// return are mapped back by their code
+func errorCode(err error) json2.ErrorCode {
+	var e *apiError
+	var rpcErr *json2.Error
+	switch {
+	case errors.As(err, &e):
+		return e.Code
+	case errors.As(err, &rpcErr):
+		return rpcErr.Code
+	}
+	return json2.E_SERVER
+}
+
+// statusOf is the http status of a json-rpc error code
+func statusOf(code json2.ErrorCode) int {
+	switch code {
+	case json2.E_PARSE, json2.E_INVALID_REQ, json2.E_BAD_PARAMS:
+		return http.StatusBadRequest
+	case errNotFound:
+		return http.StatusNotFound
+	case errIncompatible:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers a rest request with the status of the error
+func writeError(w http.ResponseWriter, err error) {
+	code := errorCode(err)
+	body := &core.APIError{}
+	body.Error.Code, body.Error.Message = int(code), err.Error()
+	writeJSON(w, statusOf(code), body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debugw("write response", "tag", outputHead, "error", err)
+	}
+}
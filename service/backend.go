@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+
+	"github.com/glvd/accipfs/cache"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/record"
+)
+
+// ethBackend is the eth client used by Accelerate
+type ethBackend interface {
+	IsReady() bool
+	NodeInfo(ctx context.Context) (*core.ContractNode, error)
+	AddPeer(ctx context.Context, peer string) error
+	FindNo(ctx context.Context, no string) (string, error)
+}
+
+// ipfsBackend is the ipfs client used by Accelerate
+type ipfsBackend interface {
+	IsReady() bool
+	ID(ctx context.Context) (*core.DataStoreNode, error)
+	SwarmConnect(ctx context.Context, addr string) error
+	PinAdd(ctx context.Context, hash string) error
+	PinRm(ctx context.Context, hash string) error
+	PinHashes(ctx context.Context) ([]string, error)
+	BlockGet(ctx context.Context, hash string) ([]byte, error)
+	PeerBandwidth(ctx context.Context, id string) (*core.Traffic, error)
+	DagSize(ctx context.Context, hash string) (uint64, error)
+}
+
+// backend holds everything Accelerate talks to outside of the rpc service,
+// the daemon uses the geth/ipfs processes while tests can swap in fakes.
+type backend struct {
+	ethServer  NodeServer
+	ipfsServer NodeServer
+	eth        ethBackend
+	ipfs       ipfsBackend
+	contract   contract.Contractor
+	cache      *cache.MemoryCache
+	records    *nodeRecords
+	signer     record.Signer
+	// chain dials the endpoint of the chain watcher, the watcher is off when nil
+	chain func(ctx context.Context) (chainBackend, error)
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/dns"
+	"github.com/goextension/log"
+)
+
+const bandwidthKey = "bandwidth"
+
+// bandwidthDays is how many days of traffic are kept for every peer and video
+const bandwidthDays = 30
+
+// bandwidthMeter counts the traffic of this node by peer and by video number
+type bandwidthMeter struct {
+	mut    sync.Mutex
+	data   core.Bandwidth
+	totals map[string]core.Traffic // last ipfs totals by ipfs id
+	now    func() time.Time
+}
+
+func newBandwidthMeter() *bandwidthMeter {
+	return &bandwidthMeter{
+		data: core.Bandwidth{
+			Peers:  make(map[string]*core.TrafficCounter),
+			Videos: make(map[string]*core.TrafficCounter),
+		},
+		totals: make(map[string]core.Traffic),
+		now:    time.Now,
+	}
+}
+
+func (m *bandwidthMeter) add(counters map[string]*core.TrafficCounter, key string, t core.Traffic) {
+	if t.In == 0 && t.Out == 0 {
+		return
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	c, b := counters[key]
+	if !b {
+		c = &core.TrafficCounter{}
+		counters[key] = c
+	}
+	c.Add(m.now().UTC().Format("2006-01-02"), t, bandwidthDays)
+}
+
+// recent returns the traffic of the kept days with a peer
+func (m *bandwidthMeter) recent(name string) core.Traffic {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	c, b := m.data.Peers[name]
+	if !b {
+		return core.Traffic{}
+	}
+	return c.Recent()
+}
+
+// Peer ...
+func (m *bandwidthMeter) Peer(name string, t core.Traffic) {
+	m.add(m.data.Peers, name, t)
+}
+
+// Video ...
+func (m *bandwidthMeter) Video(no string, t core.Traffic) {
+	m.add(m.data.Videos, no, t)
+}
+
+// delta turns the ipfs totals of a peer since the ipfs start into the traffic
+// since the last reading
+func (m *bandwidthMeter) delta(id string, total core.Traffic) core.Traffic {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	last, b := m.totals[id]
+	m.totals[id] = total
+	if !b {
+		// the traffic before the first reading was counted by the last run
+		return core.Traffic{}
+	}
+	if total.In < last.In || total.Out < last.Out {
+		// ipfs restarted
+		return total
+	}
+	return core.Traffic{In: total.In - last.In, Out: total.Out - last.Out}
+}
+
+// Marshal ...
+func (m *bandwidthMeter) Marshal() ([]byte, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return json.Marshal(m.data)
+}
+
+// Unmarshal ...
+func (m *bandwidthMeter) Unmarshal(data []byte) error {
+	var bw core.Bandwidth
+	if err := json.Unmarshal(data, &bw); err != nil {
+		return err
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for k, v := range bw.Peers {
+		m.data.Peers[k] = v
+	}
+	for k, v := range bw.Videos {
+		m.data.Videos[k] = v
+	}
+	return nil
+}
+
+// loadBandwidth reads the counters of the last run
+func (a *Accelerate) loadBandwidth() {
+	data, err := a.cache.Get(bandwidthKey)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	if err := a.bandwidth.Unmarshal(data); err != nil {
+		log.Errorw("load bandwidth", "tag", outputHead, "error", err)
+	}
+}
+
+func (a *Accelerate) saveBandwidth() {
+	data, err := a.bandwidth.Marshal()
+	if err == nil {
+		err = a.cache.Set(bandwidthKey, data)
+	}
+	if err != nil {
+		log.Errorw("save bandwidth", "tag", outputHead, "error", err)
+	}
+}
+
+// meterPeers reads the ipfs traffic with every known node
+func (a *Accelerate) meterPeers(ctx context.Context) {
+	a.nodes.Range(func(info *core.NodeInfo) bool {
+		if info.DataStore.ID == "" {
+			return true
+		}
+		total, err := a.ipfsClient.PeerBandwidth(ctx, info.DataStore.ID)
+		if err != nil {
+			log.Debugw("peer bandwidth", "tag", outputHead, "peer", info.Name, "error", err)
+			return true
+		}
+		a.bandwidth.Peer(info.Name, a.bandwidth.delta(info.DataStore.ID, *total))
+		return true
+	})
+	a.saveBandwidth()
+}
+
+// servedBy returns the bytes fetched lately from the peer with the enode id
+func (a *Accelerate) servedBy(id string) uint64 {
+	var served uint64
+	a.nodes.Range(func(info *core.NodeInfo) bool {
+		node, err := dns.NodeFromEnode(info.Contract.Enode)
+		if err != nil || node.ID != id {
+			return true
+		}
+		served = a.bandwidth.recent(info.Name).In
+		return false
+	})
+	return served
+}
+
+// pinnedSet returns the hashes pinned before a video is fetched
+func (a *Accelerate) pinnedSet(ctx context.Context) map[string]bool {
+	pinned := make(map[string]bool)
+	hashes, err := a.ipfsClient.PinHashes(ctx)
+	if err != nil {
+		log.Debugw("pinned hashes", "tag", outputHead, "error", err)
+	}
+	for _, hash := range hashes {
+		pinned[strings.TrimPrefix(hash, "/ipfs/")] = true
+	}
+	return pinned
+}
+
+// meterVideo counts the size of the video hashes that were not pinned before as fetched
+func (a *Accelerate) meterVideo(ctx context.Context, no string, hashes []string, local map[string]bool) {
+	var fetched uint64
+	for _, hash := range hashes {
+		if hash == "" || local[strings.TrimPrefix(hash, "/ipfs/")] {
+			continue
+		}
+		size, err := a.ipfsClient.DagSize(ctx, hash)
+		if err != nil {
+			log.Debugw("dag size", "tag", outputHead, "hash", hash, "error", err)
+			continue
+		}
+		fetched += size
+	}
+	a.bandwidth.Video(no, core.Traffic{In: fetched})
+	a.saveBandwidth()
+}
+
+// Bandwidth ...
+func (a *Accelerate) Bandwidth(r *http.Request, _ *core.Empty, result *core.Bandwidth) error {
+	data, err := a.bandwidth.Marshal()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// countingWriter counts the bytes of a response
+type countingWriter struct {
+	http.ResponseWriter
+	n uint64
+}
+
+// Write ...
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += uint64(n)
+	return n, err
+}
+
+// Flush ...
+func (w *countingWriter) Flush() {
+	if f, b := w.ResponseWriter.(http.Flusher); b {
+		f.Flush()
+	}
+}
+
+// gateway serves /ipfs/ read only from the local ipfs gateway and counts the
+// bytes served for the video the hash belongs to, the ipfs gateway must only
+// listen on localhost so every request of a video passes here
+func (a *Accelerate) gateway() http.Handler {
+	target := &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", a.cfg.IPFS.GatewayPort)}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			// a writable ipfs gateway must not be opened to everyone
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		cw := &countingWriter{ResponseWriter: w}
+		proxy.ServeHTTP(cw, r)
+		hash := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/ipfs/"), "/", 2)[0]
+		no, err := a.cache.GetVideoNo(hash)
+		if err != nil {
+			return
+		}
+		a.bandwidth.Video(no, core.Traffic{Out: cw.n})
+	})
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxBatch is the largest number of calls in one batch request
+const maxBatch = 100
+
+// maxBatchBody is the largest batch request read
+const maxBatchBody = 16 << 20
+
+// recorder keeps the answer of one call of a batch
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header ...
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+// Write ...
+func (r *recorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+// WriteHeader ...
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+}
+
+// batchError answers a batch that could not be read, as json-rpc 2.0 does
+func batchError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"error":   map[string]interface{}{"code": code, "message": message},
+		"id":      nil,
+	})
+}
+
+// batchHandler serves json-rpc 2.0 batches, every call of the array is passed
+// to h on its own and the answers are sent back as one array
+func batchHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBatchBody))
+		r.Body.Close()
+		if err != nil {
+			batchError(w, -32700, err.Error())
+			return
+		}
+		trimmed := bytes.TrimSpace(body)
+		if len(trimmed) == 0 || trimmed[0] != '[' {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			h.ServeHTTP(w, r)
+			return
+		}
+		var calls []json.RawMessage
+		if err := json.Unmarshal(trimmed, &calls); err != nil {
+			batchError(w, -32700, err.Error())
+			return
+		}
+		if len(calls) == 0 {
+			batchError(w, -32600, "empty batch")
+			return
+		}
+		if len(calls) > maxBatch {
+			batchError(w, -32600, "too many calls in batch")
+			return
+		}
+		results := make([]json.RawMessage, 0, len(calls))
+		for _, call := range calls {
+			req := r.Clone(r.Context())
+			req.Body = ioutil.NopCloser(bytes.NewReader(call))
+			req.ContentLength = int64(len(call))
+			rec := &recorder{header: make(http.Header), status: http.StatusOK}
+			h.ServeHTTP(rec, req)
+			result := bytes.TrimSpace(rec.body.Bytes())
+			if len(result) == 0 || !json.Valid(result) {
+				continue
+			}
+			results = append(results, result)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if len(results) == 0 {
+			// only notifications
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(results)
+	})
+}
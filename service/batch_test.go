@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+	"github.com/gorilla/rpc/v2"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// Echo is a small rpc service for the batch tests
+type Echo struct{}
+
+// Say ...
+func (e *Echo) Say(r *http.Request, in *string, out *string) error {
+	if *in == "" {
+		return errors.New("nothing to say")
+	}
+	*out = *in
+	return nil
+}
+
+func TestBatchHandler(t *testing.T) {
+	s := rpc.NewServer()
+	s.RegisterCodec(json2.NewCodec(), "application/json")
+	if err := s.RegisterService(new(Echo), ""); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(batchHandler(s))
+	defer srv.Close()
+
+	var a, b, c string
+	hello, empty := "hello", ""
+	calls := []*general.RPCCall{
+		{Method: "Echo.Say", Input: &hello, Output: &a},
+		{Method: "Echo.Say", Input: &empty, Output: &b},
+		{Method: "Echo.Missing", Input: core.DummyEmpty(), Output: &c},
+	}
+	if err := new(general.RPCTransport).Batch(context.Background(), srv.URL, calls); err != nil {
+		t.Fatal(err)
+	}
+	if calls[0].Err != nil || a != "hello" {
+		t.Errorf("first call: %q %v", a, calls[0].Err)
+	}
+	var remote *general.RemoteError
+	if !errors.As(calls[1].Err, &remote) || remote.Message != "nothing to say" {
+		t.Errorf("second call: %v", calls[1].Err)
+	}
+	if calls[2].Err == nil {
+		t.Error("missing method did not fail")
+	}
+
+	// single calls still work
+	var out string
+	if err := general.RPCPost(srv.URL, "Echo.Say", &hello, &out); err != nil || out != "hello" {
+		t.Errorf("single call: %q %v", out, err)
+	}
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader("["+strings.Repeat(`{"jsonrpc":"2.0","method":"Echo.Say","params":"x","id":1},`, maxBatch)+`{"jsonrpc":"2.0","method":"Echo.Say","params":"x","id":1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("oversized batch: status %d", resp.StatusCode)
+	}
+}
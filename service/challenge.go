@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+	"github.com/goextension/log"
+)
+
+// challengeLength is the longest byte range a challenge asks for
+const challengeLength = 256
+
+// reputation changes of one storage challenge, a failure weighs more than a
+// pass so a peer can not make up for lying with the hashes it really stores
+const (
+	reputationPass = 1
+	reputationFail = -5
+	reputationMax  = 10
+)
+
+// reputation scores the peers by their storage challenges and remembers the
+// hashes a peer failed until it answers a challenge correctly again
+type reputation struct {
+	mut    sync.RWMutex
+	scores map[string]int
+	failed map[string]map[string]bool
+}
+
+func newReputation() *reputation {
+	return &reputation{scores: make(map[string]int), failed: make(map[string]map[string]bool)}
+}
+
+// Score ...
+func (r *reputation) Score(name string) int {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.scores[name]
+}
+
+// Failed reports whether the peer failed a challenge for the hash
+func (r *reputation) Failed(name string, hash string) bool {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.failed[name][hash]
+}
+
+// Pass ...
+func (r *reputation) Pass(name string) int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	delete(r.failed, name)
+	if r.scores[name] < reputationMax {
+		r.scores[name] += reputationPass
+	}
+	return r.scores[name]
+}
+
+// Fail ...
+func (r *reputation) Fail(name string, hash string) int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.failed[name] == nil {
+		r.failed[name] = make(map[string]bool)
+	}
+	r.failed[name][hash] = true
+	r.scores[name] += reputationFail
+	return r.scores[name]
+}
+
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// newChallenge picks a random range of the block and a fresh nonce, the answer
+// can not be computed ahead of time
+func newChallenge(hash string, block []byte) (*core.Challenge, error) {
+	if len(block) == 0 {
+		return nil, fmt.Errorf("empty block: %s", hash)
+	}
+	offset, err := randomInt(len(block))
+	if err != nil {
+		return nil, err
+	}
+	max := len(block) - offset
+	if max > challengeLength {
+		max = challengeLength
+	}
+	length, err := randomInt(max)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &core.Challenge{
+		Hash:   hash,
+		Offset: offset,
+		Length: length + 1,
+		Nonce:  hex.EncodeToString(nonce),
+	}, nil
+}
+
+// Challenge answers a storage challenge with the local block
+func (a *Accelerate) Challenge(r *http.Request, c *core.Challenge, result *core.ChallengeResponse) error {
+	block, err := a.ipfsClient.BlockGet(r.Context(), c.Hash)
+	if err != nil {
+		return err
+	}
+	answer, err := c.Answer(block)
+	if err != nil {
+		return err
+	}
+	result.Answer = answer
+	return nil
+}
+
+// challengeLoop challenges a random peer every interval until the context is done
+func (a *Accelerate) challengeLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(a.cfg.Challenge.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := a.challengeRound(ctx); err != nil {
+			log.Errorw("storage challenge", "tag", outputHead, "error", err)
+		}
+	}
+}
+
+// challengeRound challenges a random peer for a random hash both nodes pin,
+// the answer can only be checked when this node has the block
+func (a *Accelerate) challengeRound(ctx context.Context) error {
+	var peers []*core.NodeInfo
+	a.nodes.Range(func(info *core.NodeInfo) bool {
+		peers = append(peers, info)
+		return true
+	})
+	if len(peers) == 0 {
+		return nil
+	}
+	i, err := randomInt(len(peers))
+	if err != nil {
+		return err
+	}
+	peer := peers[i]
+	remote, err := client.Pins(peer)
+	if err != nil {
+		return err
+	}
+	local, err := a.ipfsClient.PinHashes(ctx)
+	if err != nil {
+		return err
+	}
+	pinned := make(map[string]bool)
+	for _, hash := range local {
+		pinned[hash] = true
+	}
+	var shared []string
+	for _, hash := range remote {
+		if pinned[hash] {
+			shared = append(shared, hash)
+		}
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+	i, err = randomInt(len(shared))
+	if err != nil {
+		return err
+	}
+	_, err = a.challenge(ctx, peer, shared[i])
+	return err
+}
+
+// challenge asks the peer for a range of the block of the hash, a peer that
+// answers wrong is no longer a provider of the hash until it passes a challenge
+// again and is dropped once its reputation falls below the configured minimum,
+// a peer that does not answer in time keeps its reputation and the error is
+// returned
+func (a *Accelerate) challenge(ctx context.Context, peer *core.NodeInfo, hash string) (bool, error) {
+	block, err := a.ipfsClient.BlockGet(ctx, hash)
+	if err != nil {
+		// not the fault of the peer
+		return false, fmt.Errorf("local block %s: %w", hash, err)
+	}
+	c, err := newChallenge(hash, block)
+	if err != nil {
+		return false, err
+	}
+	expect, err := c.Answer(block)
+	if err != nil {
+		return false, err
+	}
+	cctx, cancel := context.WithTimeout(ctx, time.Duration(a.settings().Challenge.Timeout)*time.Second)
+	defer cancel()
+	resp, err := client.NewNode(peer).Challenge(cctx, c)
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if unanswered(err) {
+		// a peer that can not be reached says nothing about the blocks it stores
+		log.Infow("storage challenge unanswered", "tag", outputHead, "peer", peer.Name, "hash", hash, "error", err)
+		return false, fmt.Errorf("challenge %s: %w", peer.Name, err)
+	}
+	if err == nil && resp.Answer == expect {
+		a.reputation.Pass(peer.Name)
+		return true, nil
+	}
+	score := a.reputation.Fail(peer.Name, hash)
+	log.Infow("storage challenge failed", "tag", outputHead, "peer", peer.Name, "hash", hash, "reputation", score, "error", err)
+	if err := a.cache.RemoveHashNode(hash, peer.Name); err != nil {
+		log.Debugw("remove hash node", "tag", outputHead, "hash", hash, "error", err)
+	}
+	if !a.trusted(peer.Name) {
+		fmt.Println(outputHead, "Accelerate", "drop peer", peer.Name, "reputation", score)
+		a.dropPeer(peer)
+	}
+	return false, nil
+}
+
+// unanswered reports whether the challenge failed before the peer could answer,
+// a timeout or a transport error is no verdict on the storage of the peer
+func unanswered(err error) bool {
+	var transport *general.TransportError
+	var status *general.StatusError
+	return general.IsTimeout(err) || errors.As(err, &transport) || errors.As(err, &status) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// trusted reports whether the peer has not fallen below the minimum reputation
+func (a *Accelerate) trusted(name string) bool {
+	return a.reputation.Score(name) >= a.settings().Challenge.MinReputation
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/contract/node"
+	"github.com/goextension/log"
+)
+
+const checkpointKey = "watch_block"
+
+// chainBackend is the eth client the chain watcher reads from
+type chainBackend interface {
+	contract.ChainReader
+	SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+	Close()
+}
+
+// dialChain connects to the geth endpoint that can subscribe, the ipc of the data dir by default
+func dialChain(cfg *config.Config) func(ctx context.Context) (chainBackend, error) {
+	return func(ctx context.Context) (chainBackend, error) {
+		return ethclient.DialContext(ctx, config.WatchEndpoint(cfg))
+	}
+}
+
+// eventBus fans the chain events out to the subscribers of the event name
+type eventBus struct {
+	mut  sync.RWMutex
+	subs map[string][]func(e contract.Event)
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[string][]func(e contract.Event))}
+}
+
+// Subscribe ...
+func (b *eventBus) Subscribe(name string, fn func(e contract.Event)) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.subs[name] = append(b.subs[name], fn)
+}
+
+// Publish runs the subscribers in order, they must not block
+func (b *eventBus) Publish(e contract.Event) {
+	b.mut.RLock()
+	subs := b.subs[e.Name]
+	b.mut.RUnlock()
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+// watchChain follows the contract events until the context is done, it
+// reconnects when geth restarts and resumes from the cached block
+func (a *Accelerate) watchChain(ctx context.Context) {
+	checkpoint := contract.NewStoreCheckpoint(a.cache, checkpointKey)
+	for ctx.Err() == nil {
+		err := a.watchOnce(ctx, checkpoint)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Errorw("chain watcher stopped", "tag", outputHead, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func (a *Accelerate) watchOnce(ctx context.Context, checkpoint contract.Checkpoint) error {
+	chain, err := a.dialChain(ctx)
+	if err != nil {
+		return err
+	}
+	defer chain.Close()
+	watches, err := contract.Watches(a.cfg, chain)
+	if err != nil {
+		return err
+	}
+	w := contract.NewWatcher(chain, checkpoint, a.bus.Publish, watches...)
+	w.Confirmations = a.cfg.Watch.Confirmations
+	w.Start = a.cfg.Watch.StartBlock
+	if a.cfg.Watch.Poll > 0 {
+		w.Poll = time.Duration(a.cfg.Watch.Poll) * time.Second
+	}
+	fmt.Println(outputHead, "Accelerate", "watching contract events")
+	return w.Run(ctx)
+}
+
+// subscribe connects the peer manager, the writer index and the pin scheduler to the chain events
+func (a *Accelerate) subscribe() {
+	a.bus.Subscribe(contract.EventNodeList, func(e contract.Event) {
+		if e.Removed {
+			return
+		}
+		select {
+		case a.peerRefresh <- struct{}{}:
+		default:
+			// a refresh is already queued
+		}
+	})
+	a.bus.Subscribe(contract.EventWritershipIncreased, a.writershipEvent)
+	a.bus.Subscribe(contract.EventWritershipDecreased, a.writershipEvent)
+	a.bus.Subscribe(contract.EventPinSuccess, a.scheduler.event)
+	a.bus.Subscribe(contract.EventMessage, a.videoPublished)
+}
+
+// managePeers connects geth to the eth nodes of the contract every time the node list changes
+func (a *Accelerate) managePeers(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.peerRefresh:
+		}
+		if err := a.refreshPeers(ctx); err != nil {
+			log.Errorw("refresh peers", "tag", outputHead, "error", err)
+		}
+	}
+}
+
+func (a *Accelerate) refreshPeers(ctx context.Context) error {
+	if a.contract == nil || a.records == nil {
+		return nil
+	}
+	var nodes []string
+	err := a.contract.Node(func(node *node.AccelerateNode, tx *contract.Transactor) error {
+		entries, err := node.GetEthNodes(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return err
+		}
+		nodes = a.records.decodeNodes(entries)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, enode := range nodes {
+		if err := a.ethClient.AddPeer(ctx, enode); err != nil {
+			log.Errorw("add eth peer", "tag", outputHead, "error", err, "enode", enode)
+		}
+	}
+	fmt.Println(outputHead, "Accelerate", "node list changed, eth peers", len(nodes))
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/core/pb"
+	"github.com/goextension/log"
+	"github.com/gorilla/rpc/v2/json2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// pinChunk is the number of hashes sent in one message of the pin stream
+const pinChunk = 1000
+
+// peerServer serves the peer protocol over grpc with the rpc methods of the node
+type peerServer struct {
+	a     *Accelerate
+	mut   sync.Mutex
+	calls map[string]int // served calls by method
+}
+
+func newPeerServer(a *Accelerate) *peerServer {
+	return &peerServer{a: a, calls: make(map[string]int)}
+}
+
+func (s *peerServer) served(method string) {
+	s.mut.Lock()
+	s.calls[method]++
+	s.mut.Unlock()
+}
+
+// count returns how many calls of the method were served
+func (s *peerServer) count(method string) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.calls[method]
+}
+
+// grpcRequest gives the rpc methods the caller of a grpc call
+func grpcRequest(ctx context.Context) *http.Request {
+	r := (&http.Request{Header: make(http.Header)}).WithContext(ctx)
+	if p, b := peer.FromContext(ctx); b {
+		r.RemoteAddr = p.Addr.String()
+	}
+	return r
+}
+
+// grpcError keeps the not found, invalid and incompatible errors of the rpc methods
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch errorCode(err) {
+	case json2.E_BAD_PARAMS:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errIncompatible:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// Ping ...
+func (s *peerServer) Ping(ctx context.Context, _ *pb.Empty) (*pb.Pong, error) {
+	s.served("Ping")
+	result := new(string)
+	if err := s.a.Ping(grpcRequest(ctx), new(core.Empty), result); err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.Pong{Message: *result}, nil
+}
+
+// Connected ...
+func (s *peerServer) Connected(ctx context.Context, in *pb.NodeInfo) (*pb.NodeInfo, error) {
+	s.served("Connected")
+	result := new(core.NodeInfo)
+	if err := s.a.Connected(grpcRequest(ctx), in.Info(), result); err != nil {
+		return nil, grpcError(err)
+	}
+	return pb.NewNodeInfo(result), nil
+}
+
+// Peers ...
+func (s *peerServer) Peers(_ *pb.Empty, stream pb.Peer_PeersServer) error {
+	s.served("Peers")
+	result := new([]*core.NodeInfo)
+	if err := s.a.Peers(grpcRequest(stream.Context()), new(core.Empty), result); err != nil {
+		return grpcError(err)
+	}
+	for _, info := range *result {
+		if err := stream.Send(pb.NewNodeInfo(info)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pins ...
+func (s *peerServer) Pins(_ *pb.Empty, stream pb.Peer_PinsServer) error {
+	s.served("Pins")
+	result := new([]string)
+	if err := s.a.Pins(grpcRequest(stream.Context()), new(core.Empty), result); err != nil {
+		return grpcError(err)
+	}
+	pins := *result
+	for len(pins) > 0 {
+		n := pinChunk
+		if len(pins) < n {
+			n = len(pins)
+		}
+		if err := stream.Send(&pb.PinChunk{Hashes: pins[:n]}); err != nil {
+			return err
+		}
+		pins = pins[n:]
+	}
+	return nil
+}
+
+// listenGRPC serves the peer protocol on the grpc port until the server is stopped
+func (s *Server) listenGRPC() error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.GRPC.Port))
+	if err != nil {
+		return err
+	}
+	s.grpcServer = grpc.NewServer()
+	pb.RegisterPeerServer(s.grpcServer, s.peerServer)
+	fmt.Println(outputHead, "GRPC peer service listen and serving on port", l.Addr())
+	go func() {
+		if err := s.grpcServer.Serve(l); err != nil {
+			log.Errorw("grpc server", "tag", outputHead, "error", err)
+		}
+	}()
+	return nil
+}
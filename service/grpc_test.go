@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/core"
+)
+
+func TestHarnessGRPC(t *testing.T) {
+	h := newHarness(t, 3)
+	for _, node := range h.nodes {
+		node.cfg.GRPC.Enable = true
+		node.cfg.GRPC.Port = freePort(t)
+	}
+	// more hashes than fit in one chunk of the stream
+	publisher := h.nodes[2]
+	for i := 0; i < pinChunk+10; i++ {
+		publisher.ipfs.pin(fmt.Sprintf("hash-%04d", i))
+	}
+	h.start()
+	defer h.stop()
+	h.connect(0, 1)
+	h.connect(1, 2)
+	h.sync(len(h.nodes))
+
+	// the peers were greeted and their pins read over grpc, not json-rpc
+	for _, method := range []string{"Connected", "Pins"} {
+		if publisher.server.peerServer.count(method) == 0 {
+			t.Errorf("%s of %s not called over grpc", method, publisher.name)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	g, err := client.NewGRPC(fmt.Sprintf("127.0.0.1:%d", publisher.cfg.GRPC.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pins, err := g.Pins(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != pinChunk+10 {
+		t.Errorf("%d pins streamed", len(pins))
+	}
+	peers, err := g.Peers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range peers {
+		if !info.Has(core.CapabilityGRPC) || info.GRPCPort == 0 {
+			t.Errorf("peer %s has no grpc capability: %+v", info.Name, info.Capabilities)
+		}
+	}
+
+	// the pins of the publisher reached the first node over the stream
+	node := h.nodes[0]
+	hashInfo, err := node.acc.cache.GetHashInfo("hash-1005")
+	if _, b := hashInfo[publisher.name]; err != nil || !b {
+		t.Errorf("hash of %s not cached on %s: %v %v", publisher.name, node.name, hashInfo, err)
+	}
+}
@@ -0,0 +1,948 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glvd/accipfs/account"
+	"github.com/glvd/accipfs/cache"
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/contract/dmessage"
+	"github.com/glvd/accipfs/contract/token"
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/dns"
+	"github.com/glvd/accipfs/general"
+)
+
+// fakeSwarm connects the fake ipfs nodes of one harness
+type fakeSwarm struct {
+	mut   sync.RWMutex
+	nodes map[string]*fakeIPFS
+}
+
+func (s *fakeSwarm) get(id string) *fakeIPFS {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.nodes[id]
+}
+
+type fakeIPFS struct {
+	swarm *fakeSwarm
+	id    string
+	mut   sync.RWMutex
+	pins  map[string]bool
+	conns map[string]bool
+	// claims are advertised as pinned without having the blocks
+	claims  map[string]bool
+	traffic map[string]core.Traffic
+	// delay holds back the blocks read by BlockGet
+	delay time.Duration
+}
+
+func newFakeIPFS(swarm *fakeSwarm, id string) *fakeIPFS {
+	f := &fakeIPFS{
+		swarm:   swarm,
+		id:      id,
+		pins:    make(map[string]bool),
+		conns:   make(map[string]bool),
+		claims:  make(map[string]bool),
+		traffic: make(map[string]core.Traffic),
+	}
+	swarm.mut.Lock()
+	swarm.nodes[id] = f
+	swarm.mut.Unlock()
+	return f
+}
+
+func (f *fakeIPFS) IsReady() bool {
+	return true
+}
+
+func (f *fakeIPFS) ID(ctx context.Context) (*core.DataStoreNode, error) {
+	return &core.DataStoreNode{
+		ID:        f.id,
+		Addresses: []string{"/ip4/127.0.0.1/tcp/4001/ipfs/" + f.id},
+	}, nil
+}
+
+func (f *fakeIPFS) SwarmConnect(ctx context.Context, addr string) error {
+	id := addr[strings.LastIndex(addr, "/")+1:]
+	remote := f.swarm.get(id)
+	if remote == nil {
+		return fmt.Errorf("dial %s: no route to peer", addr)
+	}
+	f.mut.Lock()
+	f.conns[id] = true
+	f.mut.Unlock()
+	remote.mut.Lock()
+	remote.conns[f.id] = true
+	remote.mut.Unlock()
+	return nil
+}
+
+func (f *fakeIPFS) has(hash string) bool {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+	return f.pins[hash]
+}
+
+func (f *fakeIPFS) pin(hash string) {
+	f.mut.Lock()
+	f.pins[hash] = true
+	f.mut.Unlock()
+}
+
+// PinAdd only succeeds when the block is local or one of the connected peers has it
+func (f *fakeIPFS) PinAdd(ctx context.Context, hash string) error {
+	f.mut.RLock()
+	var conns []string
+	for id := range f.conns {
+		conns = append(conns, id)
+	}
+	f.mut.RUnlock()
+	if f.has(hash) {
+		return nil
+	}
+	for _, id := range conns {
+		if remote := f.swarm.get(id); remote != nil && remote.has(hash) {
+			f.pin(hash)
+			return nil
+		}
+	}
+	return fmt.Errorf("pin %s: no provider connected", hash)
+}
+
+func (f *fakeIPFS) PinRm(ctx context.Context, hash string) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if !f.pins[hash] {
+		return fmt.Errorf("pin %s: not pinned", hash)
+	}
+	delete(f.pins, hash)
+	return nil
+}
+
+func (f *fakeIPFS) PinHashes(ctx context.Context) ([]string, error) {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+	var hashes []string
+	for hash := range f.pins {
+		hashes = append(hashes, hash)
+	}
+	for hash := range f.claims {
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func (f *fakeIPFS) PeerBandwidth(ctx context.Context, id string) (*core.Traffic, error) {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+	t, b := f.traffic[id]
+	if !b {
+		return nil, fmt.Errorf("peer %s not connected", id)
+	}
+	return &t, nil
+}
+
+func (f *fakeIPFS) DagSize(ctx context.Context, hash string) (uint64, error) {
+	return uint64(len(hash)) * 1000, nil
+}
+
+func (f *fakeIPFS) setTraffic(id string, in, out uint64) {
+	f.mut.Lock()
+	f.traffic[id] = core.Traffic{In: in, Out: out}
+	f.mut.Unlock()
+}
+
+func (f *fakeIPFS) claim(hash string) {
+	f.mut.Lock()
+	f.claims[hash] = true
+	f.mut.Unlock()
+}
+
+// BlockGet returns the same data for a hash on every node that pins it
+func (f *fakeIPFS) BlockGet(ctx context.Context, hash string) ([]byte, error) {
+	f.mut.RLock()
+	delay := f.delay
+	f.mut.RUnlock()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(delay):
+	}
+	if !f.has(hash) {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	return []byte(strings.Repeat(hash+"|", 100)), nil
+}
+
+// fakeChain stands in for the tag contract shared by every node
+type fakeChain struct {
+	mut  sync.RWMutex
+	tags map[string]string
+}
+
+type fakeETH struct {
+	chain *fakeChain
+	id    string
+	mut   sync.Mutex
+	peers map[string]bool
+}
+
+func (f *fakeETH) IsReady() bool {
+	return true
+}
+
+func (f *fakeETH) NodeInfo(ctx context.Context) (*core.ContractNode, error) {
+	return &core.ContractNode{
+		ID:    f.id,
+		Enode: fmt.Sprintf("enode://%s@127.0.0.1:30303", f.id),
+		IP:    "127.0.0.1",
+	}, nil
+}
+
+func (f *fakeETH) AddPeer(ctx context.Context, peer string) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.peers[peer] = true
+	return nil
+}
+
+func (f *fakeETH) FindNo(ctx context.Context, no string) (string, error) {
+	f.chain.mut.RLock()
+	defer f.chain.mut.RUnlock()
+	return f.chain.tags[no], nil
+}
+
+// fakeServer replaces the geth/ipfs processes
+type fakeServer struct{}
+
+func (fakeServer) Start() error {
+	return nil
+}
+
+func (fakeServer) Init() error {
+	return nil
+}
+
+func (fakeServer) Stop() error {
+	return nil
+}
+
+func (fakeServer) Node() (Node, error) {
+	return nil, nil
+}
+
+type harnessNode struct {
+	name   string
+	cfg    *config.Config
+	server *Server
+	acc    *Accelerate
+	ipfs   *fakeIPFS
+	eth    *fakeETH
+	done   chan error
+}
+
+func (n *harnessNode) url() string {
+	return fmt.Sprintf("http://127.0.0.1:%d/rpc", n.cfg.Port)
+}
+
+// harness runs several accelerate servers on loopback with fake backends
+type harness struct {
+	t     *testing.T
+	dir   string
+	swarm *fakeSwarm
+	chain *fakeChain
+	nodes []*harnessNode
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newHarness(t *testing.T, n int) *harness {
+	dir, err := ioutil.TempDir("", "accipfs-harness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &harness{
+		t:     t,
+		dir:   dir,
+		swarm: &fakeSwarm{nodes: make(map[string]*fakeIPFS)},
+		chain: &fakeChain{tags: make(map[string]string)},
+	}
+	for i := 0; i < n; i++ {
+		h.nodes = append(h.nodes, h.newNode(i))
+	}
+	return h
+}
+
+func (h *harness) newNode(i int) *harnessNode {
+	name := fmt.Sprintf("node%d", i)
+	acc, err := json.Marshal(&account.Account{Name: name})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	// every node keeps its own config and data, nothing is shared through the process
+	cfg := config.DefaultWithPath(filepath.Join(h.dir, name))
+	cfg.Port = freePort(h.t)
+	cfg.Interval = 3
+	cfg.Account = base64.StdEncoding.EncodeToString(acc)
+	if err := os.MkdirAll(cfg.Path, 0700); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := config.SaveDir(cfg.Path, cfg); err != nil {
+		h.t.Fatal(err)
+	}
+
+	node := &harnessNode{
+		name: name,
+		cfg:  cfg,
+		ipfs: newFakeIPFS(h.swarm, "Qm"+name),
+		eth:  &fakeETH{chain: h.chain, id: name, peers: make(map[string]bool)},
+		done: make(chan error, 1),
+	}
+	node.acc, err = newAccelerate(cfg, &backend{
+		ethServer:  fakeServer{},
+		ipfsServer: fakeServer{},
+		eth:        node.eth,
+		ipfs:       node.ipfs,
+		cache:      cache.NewInMemory(),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	node.server, err = newRPCServer(cfg, node.acc)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return node
+}
+
+func (h *harness) start() {
+	for _, node := range h.nodes {
+		go func(node *harnessNode) {
+			node.done <- node.server.Start()
+		}(node)
+	}
+	for _, node := range h.nodes {
+		h.waitReady(node)
+	}
+}
+
+func (h *harness) waitReady(node *harnessNode) {
+	info := &core.NodeInfo{RemoteAddr: "127.0.0.1", Port: node.cfg.Port}
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-node.done:
+			h.t.Fatalf("%s exited early: %v", node.name, err)
+		default:
+		}
+		if client.Ping(info) == nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	h.t.Fatalf("%s not ready", node.name)
+}
+
+// connect does the same as the 'node connect' command run on node a
+func (h *harness) connect(a, b int) {
+	from, to := h.nodes[a], h.nodes[b]
+	remote := new(core.NodeInfo)
+	addr := fmt.Sprintf("127.0.0.1:%d", to.cfg.Port)
+	if err := general.RPCPost(from.url(), "Accelerate.ConnectTo", addr, remote); err != nil {
+		h.t.Fatalf("connect %s to %s: %v", from.name, to.name, err)
+	}
+	if err := client.AddPeer(from.url(), remote); err != nil {
+		h.t.Fatalf("add peer %s to %s: %v", to.name, from.name, err)
+	}
+}
+
+// sync runs the accelerate cron job on every node
+func (h *harness) sync(rounds int) {
+	for i := 0; i < rounds; i++ {
+		for _, node := range h.nodes {
+			node.acc.Run()
+		}
+	}
+}
+
+func (h *harness) stop() {
+	for _, node := range h.nodes {
+		if err := node.server.Stop(); err != nil {
+			h.t.Error(err)
+		}
+	}
+	_ = os.RemoveAll(h.dir)
+}
+
+func TestHarnessPeerDiscovery(t *testing.T) {
+	h := newHarness(t, 4)
+	h.start()
+	defer h.stop()
+
+	// connected as a line: node0 - node1 - node2 - node3
+	for i := 0; i < len(h.nodes)-1; i++ {
+		h.connect(i, i+1)
+	}
+	h.sync(len(h.nodes))
+
+	for _, node := range h.nodes {
+		for _, other := range h.nodes {
+			if node == other {
+				continue
+			}
+			if !node.acc.nodes.Check(other.name) {
+				t.Errorf("%s did not discover %s", node.name, other.name)
+			}
+			if !node.eth.peers[fmt.Sprintf("enode://%s@127.0.0.1:30303", other.name)] {
+				t.Errorf("%s did not add eth peer %s", node.name, other.name)
+			}
+		}
+	}
+}
+
+func TestHarnessCacheConvergence(t *testing.T) {
+	h := newHarness(t, 3)
+	for _, node := range h.nodes {
+		node.ipfs.pin("hash-" + node.name)
+	}
+	h.start()
+	defer h.stop()
+
+	h.connect(0, 1)
+	h.connect(1, 2)
+	h.sync(len(h.nodes))
+
+	for _, node := range h.nodes {
+		for _, other := range h.nodes {
+			if node == other {
+				continue
+			}
+			info, err := node.acc.cache.GetHashInfo("hash-" + other.name)
+			if err != nil {
+				t.Errorf("%s has no cache for %s: %v", node.name, other.name, err)
+				continue
+			}
+			if _, b := info[other.name]; !b {
+				t.Errorf("%s does not know %s provides its pin", node.name, other.name)
+			}
+		}
+	}
+}
+
+func TestHarnessPinPropagation(t *testing.T) {
+	h := newHarness(t, 3)
+	video := core.VideoV1{
+		No:         "harness-001",
+		ThumbHash:  "thumb-001",
+		PosterHash: "poster-001",
+		SourceHash: "source-001",
+		M3U8Hash:   "m3u8-001",
+	}
+	bytes, err := video.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.chain.tags[video.No] = string(bytes)
+	publisher := h.nodes[2]
+	for _, hash := range []string{video.ThumbHash, video.PosterHash, video.SourceHash, video.M3U8Hash} {
+		publisher.ipfs.pin(hash)
+	}
+	h.start()
+	defer h.stop()
+
+	h.connect(0, 1)
+	h.connect(1, 2)
+	h.sync(len(h.nodes))
+
+	viewer := h.nodes[0]
+	result := new(bool)
+	if err := general.RPCPost(viewer.url(), "Accelerate.PinVideo", video.No, result); err != nil {
+		t.Fatal(err)
+	}
+	if !*result {
+		t.Fatal("pin video returned false")
+	}
+	for _, hash := range []string{video.ThumbHash, video.PosterHash, video.SourceHash, video.M3U8Hash} {
+		if !viewer.ipfs.has(hash) {
+			t.Errorf("%s was not pinned on %s", hash, viewer.name)
+		}
+	}
+}
+
+func TestHarnessGatewayDNS(t *testing.T) {
+	h := newHarness(t, 2)
+	h.start()
+	defer h.stop()
+
+	node := h.nodes[0]
+	server := dns.NewServer("gate.harness.test", 5, node.acc.gatewayIPs)
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = server.Serve()
+	}()
+	defer server.Close()
+	resolver := dns.NewRFC2136(server.Addr(), "", "udp")
+
+	records, err := resolver.Records(context.Background(), "gate.harness.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("answered %v without live nodes", records)
+	}
+
+	h.connect(0, 1)
+	records, err = resolver.Records(context.Background(), "gate.harness.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Value != "127.0.0.1" || records[0].TTL != 5 {
+		t.Fatalf("unexpected answers %v", records)
+	}
+}
+
+func TestHarnessPaidPin(t *testing.T) {
+	h := newHarness(t, 2)
+	for _, node := range h.nodes {
+		node.cfg.Pin.Replicas = 1
+	}
+	h.nodes[1].ipfs.pin("paid-001")
+	h.start()
+	defer h.stop()
+	h.connect(0, 1)
+	if err := h.nodes[0].ipfs.SwarmConnect(context.Background(), "/ipfs/"+h.nodes[1].ipfs.id); err != nil {
+		t.Fatal(err)
+	}
+
+	// every node sees the same pin event of the chain
+	paid := contract.Event{
+		Name:   contract.EventPinSuccess,
+		Block:  7,
+		TxHash: common.HexToHash("0x07"),
+		Data:   &token.DhTokenPinSuccess{User: common.HexToAddress("0x0a"), Hash: "paid-001", Date: "2020-04-01"},
+	}
+	for _, node := range h.nodes {
+		node.acc.bus.Publish(paid)
+	}
+	proofs := func() []*core.PinProof {
+		var proofs []*core.PinProof
+		for _, node := range h.nodes {
+			if proof, err := node.acc.cache.GetPinProof("paid-001"); err == nil {
+				proofs = append(proofs, proof)
+			}
+		}
+		return proofs
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(proofs()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	got := proofs()
+	if len(got) != 1 {
+		t.Fatalf("%d nodes serve the hash, want 1", len(got))
+	}
+	if got[0].User != common.HexToAddress("0x0a").Hex() || got[0].Block != 7 {
+		t.Fatalf("unexpected proof %+v", got[0])
+	}
+
+	var server *harnessNode
+	for _, node := range h.nodes {
+		if _, err := node.acc.cache.GetPinProof("paid-001"); err == nil {
+			server = node
+		}
+	}
+	if server == nil {
+		t.Fatal("no node pinned the hash")
+	}
+
+	// the pin transaction was reorganized away
+	paid.Removed = true
+	for _, node := range h.nodes {
+		node.acc.bus.Publish(paid)
+	}
+	if len(proofs()) != 0 {
+		t.Fatal("proof kept after the pin was reverted")
+	}
+	if server.ipfs.has("paid-001") {
+		t.Fatal("hash still pinned after the pin was reverted")
+	}
+
+	// a hash of a video stays pinned when its payment is reverted
+	server.ipfs.pin("paid-001")
+	if err := server.acc.cache.SetVideoNo("abc-001", "paid-001"); err != nil {
+		t.Fatal(err)
+	}
+	paid.Removed = false
+	paid.TxHash = common.HexToHash("0x08")
+	for _, node := range h.nodes {
+		node.acc.bus.Publish(paid)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for len(proofs()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(proofs()) != 1 {
+		t.Fatal("hash was not pinned again")
+	}
+	paid.Removed = true
+	for _, node := range h.nodes {
+		node.acc.bus.Publish(paid)
+	}
+	if len(proofs()) != 0 || !server.ipfs.has("paid-001") {
+		t.Fatal("video hash unpinned after the pin was reverted")
+	}
+}
+
+func TestHarnessWritersRestart(t *testing.T) {
+	h := newHarness(t, 1)
+	node := h.nodes[0]
+	kept, dropped := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	for _, e := range []contract.Event{
+		{Name: contract.EventWritershipIncreased, Data: &dmessage.DMessageWritershipIncreased{NewWriter: kept}},
+		{Name: contract.EventWritershipIncreased, Data: &dmessage.DMessageWritershipIncreased{NewWriter: dropped}},
+		{Name: contract.EventWritershipDecreased, Data: &dmessage.DMessageWritershipDecreased{OldWriter: dropped}},
+	} {
+		node.acc.bus.Publish(e)
+	}
+
+	// the watcher of a restarted node resumes from the cached checkpoint
+	restarted, err := newAccelerate(node.cfg, &backend{
+		ethServer:  fakeServer{},
+		ipfsServer: fakeServer{},
+		eth:        node.eth,
+		ipfs:       node.ipfs,
+		cache:      node.acc.cache,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writers := restarted.writers.List(); len(writers) != 1 || writers[0] != kept.Hex() {
+		t.Fatalf("writers after restart: %v", writers)
+	}
+}
+
+func TestHarnessStorageChallenge(t *testing.T) {
+	h := newHarness(t, 3)
+	for _, node := range h.nodes {
+		node.cfg.Challenge.MinReputation = -1
+		node.cfg.Challenge.Timeout = 1
+		node.ipfs.pin("stored-001")
+	}
+	h.nodes[2].ipfs.claim("claimed-001")
+	h.nodes[0].ipfs.pin("claimed-001")
+	h.start()
+	defer h.stop()
+	h.connect(0, 1)
+	h.connect(0, 2)
+	h.sync(len(h.nodes))
+
+	challenger := h.nodes[0]
+	ctx := context.Background()
+	honest, liar := h.nodes[1], h.nodes[2]
+	for _, node := range []*harnessNode{honest, liar} {
+		passed, err := challenger.acc.challenge(ctx, node.acc.id, "stored-001")
+		if err != nil || !passed {
+			t.Fatalf("%s failed the challenge of a stored block: %v", node.name, err)
+		}
+	}
+
+	// a peer that does not answer in time is no liar
+	honest.ipfs.mut.Lock()
+	honest.ipfs.delay = 2 * time.Second
+	honest.ipfs.mut.Unlock()
+	for i := 0; i < 3; i++ {
+		passed, err := challenger.acc.challenge(ctx, honest.acc.id, "stored-001")
+		if passed || !general.IsTimeout(err) {
+			t.Fatalf("slow challenge: %v %v", passed, err)
+		}
+	}
+	honest.ipfs.mut.Lock()
+	honest.ipfs.delay = 0
+	honest.ipfs.mut.Unlock()
+	if score := challenger.acc.reputation.Score(honest.name); score != 1 {
+		t.Fatalf("%s has reputation %d after timeouts", honest.name, score)
+	}
+	if !challenger.acc.nodes.Check(honest.name) {
+		t.Fatalf("%s was dropped after timeouts", honest.name)
+	}
+
+	info, err := challenger.acc.cache.GetHashInfo("claimed-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, b := info[liar.name]; !b {
+		t.Fatalf("%s is not cached as provider", liar.name)
+	}
+
+	passed, err := challenger.acc.challenge(ctx, liar.acc.id, "claimed-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed {
+		t.Fatal("challenge passed without the block")
+	}
+	if _, err := challenger.acc.cache.GetHashInfo("claimed-001"); err == nil {
+		t.Fatalf("%s is still cached as provider", liar.name)
+	}
+	if challenger.acc.nodes.Check(liar.name) {
+		t.Fatalf("%s was not dropped", liar.name)
+	}
+	if !challenger.acc.nodes.Check(honest.name) {
+		t.Fatalf("%s was dropped", honest.name)
+	}
+	if err := client.AddPeer(challenger.url(), liar.acc.id); err == nil {
+		t.Fatalf("%s was added again", liar.name)
+	}
+}
+
+func TestHarnessChallengeFailedHash(t *testing.T) {
+	h := newHarness(t, 3)
+	for _, node := range h.nodes {
+		node.cfg.Challenge.MinReputation = -100
+		node.ipfs.pin("stored-001")
+	}
+	h.nodes[2].ipfs.claim("claimed-001")
+	h.nodes[0].ipfs.pin("claimed-001")
+	h.start()
+	defer h.stop()
+	h.connect(0, 1)
+	h.connect(0, 2)
+	h.sync(len(h.nodes))
+
+	challenger, liar := h.nodes[0], h.nodes[2]
+	ctx := context.Background()
+	provides := func() bool {
+		info, err := challenger.acc.cache.GetHashInfo("claimed-001")
+		if err != nil {
+			return false
+		}
+		_, b := info[liar.name]
+		return b
+	}
+	if !provides() {
+		t.Fatalf("%s is not cached as provider", liar.name)
+	}
+	passed, err := challenger.acc.challenge(ctx, liar.acc.id, "claimed-001")
+	if err != nil || passed {
+		t.Fatalf("challenge without the block: %v %v", passed, err)
+	}
+	if !challenger.acc.nodes.Check(liar.name) {
+		t.Fatalf("%s was dropped", liar.name)
+	}
+	h.sync(1)
+	if provides() {
+		t.Fatalf("%s is cached as provider again after the failed challenge", liar.name)
+	}
+
+	passed, err = challenger.acc.challenge(ctx, liar.acc.id, "stored-001")
+	if err != nil || !passed {
+		t.Fatalf("%s failed the challenge of a stored block: %v", liar.name, err)
+	}
+	h.sync(1)
+	if !provides() {
+		t.Fatalf("%s is not cached as provider after a passed challenge", liar.name)
+	}
+}
+
+func TestHarnessBandwidth(t *testing.T) {
+	var methods sync.Map
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods.Store(r.Method, true)
+		if strings.HasSuffix(r.URL.Path, ".ts") {
+			// segments are streamed in chunks
+			for i := 0; i < 3; i++ {
+				_, _ = w.Write([]byte(strings.Repeat("s", 4096)))
+				w.(http.Flusher).Flush()
+			}
+			return
+		}
+		http.ServeContent(w, r, "index.m3u8", time.Time{}, strings.NewReader(strings.Repeat("x", 1000)))
+	}))
+	defer gateway.Close()
+	h := newHarness(t, 3)
+	viewer, publisher := h.nodes[0], h.nodes[2]
+	viewer.cfg.IPFS.GatewayPort = gateway.Listener.Addr().(*net.TCPAddr).Port
+	viewer.cfg.IPFS.GatewayProxy = true
+	video := core.VideoV1{No: "bw-001", ThumbHash: "thumb-bw", PosterHash: "poster-bw", SourceHash: "source-bw", M3U8Hash: "m3u8-bw"}
+	bytes, err := video.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.chain.tags[video.No] = string(bytes)
+	for _, hash := range []string{video.ThumbHash, video.PosterHash, video.SourceHash, video.M3U8Hash} {
+		publisher.ipfs.pin(hash)
+	}
+	viewer.ipfs.pin(video.M3U8Hash)
+	h.start()
+	defer h.stop()
+	h.connect(0, 1)
+	h.connect(1, 2)
+	h.sync(len(h.nodes))
+
+	peerID := publisher.ipfs.id
+	viewer.ipfs.setTraffic(peerID, 100, 200)
+	viewer.acc.meterPeers(context.Background())
+	viewer.ipfs.setTraffic(peerID, 150, 260)
+	viewer.acc.meterPeers(context.Background())
+
+	result := new(bool)
+	if err := general.RPCPost(viewer.url(), "Accelerate.PinVideo", video.No, result); err != nil {
+		t.Fatal(err)
+	}
+	// the counter of the video must match the bytes the viewers received
+	fetch := func(node *harnessNode, method, path string, header http.Header) (int, uint64) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://127.0.0.1:%d%s", node.cfg.Port, path), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, uint64(len(body))
+	}
+	playlist := "/ipfs/" + video.M3U8Hash + "/index.m3u8"
+	var served uint64
+	for _, c := range []struct {
+		method string
+		path   string
+		header http.Header
+		status int
+		video  bool
+	}{
+		{http.MethodGet, playlist, nil, http.StatusOK, true},
+		{http.MethodGet, playlist, http.Header{"Range": {"bytes=100-599"}}, http.StatusPartialContent, true},
+		{http.MethodHead, playlist, nil, http.StatusOK, true},
+		{http.MethodGet, "/ipfs/" + video.M3U8Hash + "/0.ts", nil, http.StatusOK, true},
+		{http.MethodGet, "/ipfs/other-bw", nil, http.StatusOK, false},
+		{http.MethodPost, "/ipfs/" + video.M3U8Hash, nil, http.StatusMethodNotAllowed, false},
+	} {
+		status, n := fetch(viewer, c.method, c.path, c.header)
+		if status != c.status {
+			t.Fatalf("%s %s: status %d, want %d", c.method, c.path, status, c.status)
+		}
+		if c.video {
+			served += n
+		}
+	}
+	if _, b := methods.Load(http.MethodPost); b {
+		t.Fatal("post passed to the ipfs gateway")
+	}
+	// the proxy is off unless it is configured
+	if status, _ := fetch(publisher, http.MethodGet, playlist, nil); status != http.StatusNotFound {
+		t.Fatalf("gateway of %s answered %d", publisher.name, status)
+	}
+	if served != 1000+500+3*4096 {
+		t.Fatalf("viewers received %d bytes", served)
+	}
+
+	bw, err := client.Bandwidth(viewer.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := bw.Peers[publisher.name]
+	if peer == nil || peer.Total != (core.Traffic{In: 50, Out: 60}) {
+		t.Fatalf("unexpected peer traffic %+v", peer)
+	}
+	v := bw.Videos[video.No]
+	// the playlist was pinned already
+	fetched := uint64(len(video.ThumbHash)+len(video.PosterHash)+len(video.SourceHash)) * 1000
+	if v == nil || v.Total != (core.Traffic{In: fetched, Out: served}) {
+		t.Fatalf("unexpected video traffic %+v", v)
+	}
+	if len(v.Days) != 1 || v.Recent() != v.Total {
+		t.Fatalf("unexpected days %+v", v.Days)
+	}
+}
+
+func TestHarnessReloadConfig(t *testing.T) {
+	h := newHarness(t, 2)
+	h.start()
+	defer h.stop()
+
+	node := h.nodes[0]
+	next := *node.cfg
+	next.Limit = 1
+	next.Schedule = "0 */5 * * * *"
+	next.Peers = []string{fmt.Sprintf("127.0.0.1:%d", h.nodes[1].cfg.Port)}
+	next.Port = node.cfg.Port + 1
+	node.acc.loadConfig = func() (*config.Config, error) {
+		return &next, nil
+	}
+	changes, err := client.ReloadConfig(node.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(changes.Applied, ",") != "limit,peers,schedule" || strings.Join(changes.Restart, ",") != "port" {
+		t.Fatalf("changes %+v", changes)
+	}
+	if node.acc.settings().Limit != 1 || node.acc.settings().Port != node.cfg.Port {
+		t.Fatalf("settings %+v", node.acc.settings())
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !node.acc.nodes.Check(h.nodes[1].name) {
+		if time.Now().After(deadline) {
+			t.Fatal("peer of the config not connected")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestHarnessIsolatedConfigs(t *testing.T) {
+	h := newHarness(t, 2)
+	h.start()
+	defer h.stop()
+
+	node := h.nodes[1]
+	next := *node.cfg
+	next.Limit = 7
+	deadline := time.Now().Add(10 * time.Second)
+	// written again until the watcher of the node has seen it, slower than the reload delay
+	for node.acc.settings().Limit != 7 {
+		if time.Now().After(deadline) {
+			t.Fatal("config change not applied")
+		}
+		if err := config.SaveDir(node.cfg.Path, &next); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * reloadDelay)
+	}
+	if limit := h.nodes[0].acc.settings().Limit; limit != node.cfg.Limit {
+		t.Fatalf("config of %s changed the limit of %s to %d", node.name, h.nodes[0].name, limit)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/contract/dmessage"
+	"github.com/goextension/log"
+)
+
+const writersKey = "writers"
+
+// writerIndex keeps the accounts that may write tag messages, it follows the
+// writership events of the message contract and is cached with the watch
+// checkpoint so a restarted watcher does not lose the writers of past blocks
+type writerIndex struct {
+	mut     sync.RWMutex
+	writers map[string]bool
+}
+
+func newWriterIndex() *writerIndex {
+	return &writerIndex{writers: make(map[string]bool)}
+}
+
+func (i *writerIndex) update(e contract.Event) {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+	switch v := e.Data.(type) {
+	case *dmessage.DMessageWritershipIncreased:
+		// a reverted event undoes the change
+		i.writers[v.NewWriter.Hex()] = !e.Removed
+	case *dmessage.DMessageWritershipDecreased:
+		i.writers[v.OldWriter.Hex()] = e.Removed
+	}
+}
+
+// List ...
+func (i *writerIndex) List() []string {
+	i.mut.RLock()
+	defer i.mut.RUnlock()
+	var writers []string
+	for writer, b := range i.writers {
+		if b {
+			writers = append(writers, writer)
+		}
+	}
+	sort.Strings(writers)
+	return writers
+}
+
+// Marshal ...
+func (i *writerIndex) Marshal() ([]byte, error) {
+	i.mut.RLock()
+	defer i.mut.RUnlock()
+	return json.Marshal(i.writers)
+}
+
+// Unmarshal ...
+func (i *writerIndex) Unmarshal(data []byte) error {
+	writers := make(map[string]bool)
+	if err := json.Unmarshal(data, &writers); err != nil {
+		return err
+	}
+	i.mut.Lock()
+	i.writers = writers
+	i.mut.Unlock()
+	return nil
+}
+
+// writershipEvent updates the writer index and saves it before the watcher
+// moves the checkpoint past the block of the event
+func (a *Accelerate) writershipEvent(e contract.Event) {
+	a.writers.update(e)
+	data, err := a.writers.Marshal()
+	if err == nil {
+		err = a.cache.Set(writersKey, data)
+	}
+	if err != nil {
+		log.Errorw("save writers", "tag", outputHead, "error", err)
+	}
+}
+
+func (a *Accelerate) loadWriters() {
+	data, err := a.cache.Get(writersKey)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	if err := a.writers.Unmarshal(data); err != nil {
+		log.Errorw("load writers", "tag", outputHead, "error", err)
+	}
+}
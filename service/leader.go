@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/glvd/accipfs/config"
+)
+
+// leaderElection picks the one signer node that does the contract and dns
+// maintenance of a period. Every node ranks the signer nodes stored on chain
+// by the hash of the period and the node id, so all nodes agree on the order
+// without talking to each other. The first candidate that can be dialed is the
+// leader; when it disappears the next one takes over on the following run.
+type leaderElection struct {
+	period time.Duration
+	alive  func(node string) bool
+	now    func() time.Time
+
+	mut    sync.RWMutex
+	leader string
+	until  time.Time
+	self   bool
+}
+
+func newLeaderElection(cfg *config.Config) *leaderElection {
+	period := time.Duration(cfg.LeaderPeriod) * time.Second
+	if period <= 0 {
+		period = 10 * time.Minute
+	}
+	return &leaderElection{
+		period: period,
+		alive: func(node string) bool {
+			return len(getAccessibleEthNodes([]string{node}, "30303", 3*time.Second)) > 0
+		},
+		now: time.Now,
+	}
+}
+
+func rankKey(term int64, id string) []byte {
+	buf := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(buf, uint64(term))
+	sum := sha256.Sum256(append(buf, id...))
+	return sum[:]
+}
+
+// rank orders the candidates of one term, duplicated ids are dropped
+func rank(term int64, candidates []string) []string {
+	var ranked []string
+	keys := make(map[string]string)
+	for _, node := range candidates {
+		id := enodeID(node)
+		if id == "" {
+			continue
+		}
+		if _, b := keys[id]; b {
+			continue
+		}
+		keys[id] = string(rankKey(term, id))
+		ranked = append(ranked, node)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return keys[enodeID(ranked[i])] < keys[enodeID(ranked[j])]
+	})
+	return ranked
+}
+
+// Elect decides the leader of the current period from the signer nodes,
+// self is the enode of this node and is never dialed.
+func (l *leaderElection) Elect(self string, candidates []string) bool {
+	now := l.now()
+	term := now.UnixNano() / int64(l.period)
+	until := time.Unix(0, (term+1)*int64(l.period))
+	leader := ""
+	for _, node := range rank(term, candidates) {
+		if enodeID(node) == enodeID(self) || l.alive(node) {
+			leader = node
+			break
+		}
+	}
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	l.leader = leader
+	l.until = until
+	l.self = leader != "" && enodeID(leader) == enodeID(self)
+	return l.self
+}
+
+// Leading reports whether this node won the election of the current period
+func (l *leaderElection) Leading() bool {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.self && l.now().Before(l.until)
+}
+
+// Leader returns the elected node of the current period
+func (l *leaderElection) Leader() string {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	if l.now().Before(l.until) {
+		return l.leader
+	}
+	return ""
+}
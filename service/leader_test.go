@@ -0,0 +1,105 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testElection(now time.Time, dead map[string]bool) *leaderElection {
+	return &leaderElection{
+		period: 10 * time.Minute,
+		alive: func(node string) bool {
+			return !dead[enodeID(node)]
+		},
+		now: func() time.Time {
+			return now
+		},
+	}
+}
+
+func testSigners(n int) []string {
+	var nodes []string
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, fmt.Sprintf("enode://signer%d@10.0.0.%d:30303", i, i+1))
+	}
+	return nodes
+}
+
+func TestLeaderElectionAgreement(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	signers := testSigners(5)
+	leaders := 0
+	for _, self := range signers {
+		// every node sees the signer list in another order
+		var candidates []string
+		for i := range signers {
+			candidates = append(candidates, signers[(i+len(self))%len(signers)])
+		}
+		l := testElection(now, nil)
+		if l.Elect(self, candidates) {
+			leaders++
+		}
+		if !l.Leading() && l.Leader() == self {
+			t.Errorf("%s is the leader but not leading", self)
+		}
+	}
+	if leaders != 1 {
+		t.Fatalf("%d leaders elected, want 1", leaders)
+	}
+}
+
+func TestLeaderElectionFailover(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	signers := testSigners(5)
+	leader := testElection(now, nil)
+	leader.Elect(signers[0], signers)
+	first := leader.Leader()
+
+	dead := map[string]bool{enodeID(first): true}
+	var next string
+	for _, self := range signers {
+		if self == first {
+			continue
+		}
+		l := testElection(now, dead)
+		if l.Elect(self, signers) {
+			if next != "" {
+				t.Fatalf("%s and %s both took over", next, self)
+			}
+			next = self
+		}
+	}
+	if next == "" || next == first {
+		t.Fatalf("nobody took over from %s", first)
+	}
+}
+
+func TestLeaderElectionRotation(t *testing.T) {
+	signers := testSigners(5)
+	seen := make(map[string]bool)
+	start := time.Unix(1600000000, 0)
+	for i := 0; i < 50; i++ {
+		l := testElection(start.Add(time.Duration(i)*10*time.Minute), nil)
+		l.Elect(signers[0], signers)
+		seen[l.Leader()] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("leadership never rotated: %v", seen)
+	}
+}
+
+func TestLeaderElectionExpires(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := testElection(now, nil)
+	self := "enode://self@127.0.0.1:30303"
+	if !l.Elect(self, []string{self}) {
+		t.Fatal("the only node was not elected")
+	}
+	l.now = func() time.Time {
+		return now.Add(l.period)
+	}
+	if l.Leading() {
+		t.Fatal("leadership did not expire with the period")
+	}
+}
@@ -2,9 +2,16 @@ package service
 
 import (
 	"bug.vlavr.com/godcong/dhcrypto"
+	"errors"
+	"fmt"
+	"github.com/glvd/accipfs/account"
 	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/record"
+	"github.com/goextension/log"
 	"go.uber.org/atomic"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -24,34 +31,119 @@ func nodeInstance() *serviceNode {
 	return &serviceNode{lock: atomic.NewBool(false)}
 }
 
-func decodeNodes(cfg *config.Config, nodes []string) []string {
-	// init contract
-	var decodeNodes []string
-	decoder := dhcrypto.NewCipherDecode([]byte(cfg.PrivateKey), dateKey)
-	if len(nodes) == 0 {
-		return decodeNodes
+// nodeRecords reads and writes the signed node records of the contract lists
+type nodeRecords struct {
+	cfg    *config.Config
+	codec  *record.Codec
+	signer record.Signer
+}
+
+func newNodeRecords(cfg *config.Config, signer record.Signer) (*nodeRecords, error) {
+	codec, err := record.NewCodec(cfg.Record.Key)
+	if err != nil {
+		return nil, err
 	}
-	for _, node := range nodes {
-		decoded, err := decoder.Decode(node)
+	return &nodeRecords{cfg: cfg, codec: codec, signer: signer}, nil
+}
+
+// nodeShared is what the eth and ipfs nodes of one daemon share, they write
+// as the node account through one transaction manager and follow one election
+type nodeShared struct {
+	contract contract.Contractor
+	leader   *leaderElection
+	records  *nodeRecords
+}
+
+func newNodeShared(cfg *config.Config, signer account.Signer) (*nodeShared, error) {
+	records, err := newNodeRecords(cfg, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeShared{
+		contract: contract.Loader(cfg, contract.NewTxManager(signer)),
+		leader:   newLeaderElection(cfg),
+		records:  records,
+	}, nil
+}
+
+// loadNodeShared loads the node account of the config
+func loadNodeShared(cfg *config.Config) (*nodeShared, error) {
+	signer, err := account.NewSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newNodeShared(cfg, signer)
+}
+
+// decodeLegacy reads the dhcrypto entries written by older versions
+func (r *nodeRecords) decodeLegacy(entry string) (string, error) {
+	decoded, err := dhcrypto.NewCipherDecode([]byte(r.cfg.PrivateKey), dateKey).Decode(entry)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// decode returns the node of an entry and whether it is a legacy entry
+func (r *nodeRecords) decode(entry string) (string, bool, error) {
+	n, err := r.codec.Decode(entry)
+	if errors.Is(err, record.ErrLegacy) && r.cfg.Record.Legacy {
+		node, err := r.decodeLegacy(entry)
+		return node, true, err
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if n.Enode != "" {
+		return n.Enode, false, nil
+	}
+	if len(n.IPFS) > 0 {
+		return n.IPFS[0], false, nil
+	}
+	return "", false, fmt.Errorf("node record of %s has no address", n.Account)
+}
+
+func (r *nodeRecords) decodeNodes(entries []string) []string {
+	var nodes []string
+	for _, entry := range entries {
+		node, _, err := r.decode(entry)
 		if err != nil {
+			log.Errorw("skip node record", "tag", outputHead, "error", err)
 			continue
 		}
-		decodeNodes = append(decodeNodes, string(decoded))
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// encode signs the record of an enode url or an ipfs address
+func (r *nodeRecords) encode(node string) (string, error) {
+	n := &record.Node{}
+	if strings.HasPrefix(node, "enode://") {
+		n.Enode = node
+		if host, _, err := net.SplitHostPort(node[strings.LastIndex(node, "@")+1:]); err == nil {
+			n.RPC = net.JoinHostPort(host, strconv.Itoa(r.cfg.Port))
+		}
+	} else {
+		n.IPFS = []string{node}
+	}
+	if err := n.Sign(r.signer); err != nil {
+		return "", err
 	}
-	return decodeNodes
+	return r.codec.Encode(n)
 }
 
-func encodeNodes(cfg *config.Config, nodes []string) []string {
-	var encodedNodes []string
-	encoder := dhcrypto.NewCipherEncoder([]byte(cfg.PublicKey), 10, dateKey)
+func (r *nodeRecords) encodeNodes(nodes []string) []string {
+	var entries []string
 	for _, node := range nodes {
-		encoded, err := encoder.Encode(node)
+		entry, err := r.encode(node)
 		if err != nil {
+			log.Errorw("encode node record", "tag", outputHead, "error", err, "node", node)
 			continue
 		}
-		encodedNodes = append(encodedNodes, string(encoded))
+		entries = append(entries, entry)
 	}
-	return encodedNodes
+	return entries
 }
 
 func getAccessibleEthNodes(addresses []string, port string, to time.Duration) []string {
@@ -73,3 +165,70 @@ func getAccessibleEthNodes(addresses []string, port string, to time.Duration) []
 	}
 	return accessible
 }
+
+// enodeID returns the node id part of an enode url
+func enodeID(node string) string {
+	return strings.Split(strings.TrimPrefix(node, "enode://"), "@")[0]
+}
+
+// ipfsNodeID public ipfs nodes are stored by their swarm address
+func ipfsNodeID(addr string) string {
+	return strings.TrimSpace(addr)
+}
+
+// nodeIdentity returns the identity of an encoded contract entry
+func nodeIdentity(records *nodeRecords, identity func(node string) string) func(entry string) string {
+	return func(entry string) string {
+		node, _, err := records.decode(entry)
+		if err != nil {
+			return ""
+		}
+		return identity(node)
+	}
+}
+
+// unreadable reports whether an entry is in a format or sealed with a key this node
+// does not have, such entries are left to the nodes that can read them
+func (r *nodeRecords) unreadable(err error) bool {
+	return errors.Is(err, record.ErrLegacy) || errors.Is(err, record.ErrSealed) && r.cfg.Record.Key == ""
+}
+
+// keepReachable keeps the contract entries that can still be dialed, every node is checked once.
+// Legacy entries are dropped so the reachable nodes are published again as signed records,
+// entries that fail the signature check or can not be parsed are dropped as well.
+func keepReachable(records *nodeRecords, check func(node string) bool) func(entry string) bool {
+	checked := make(map[string]bool)
+	return func(entry string) bool {
+		node, legacy, err := records.decode(entry)
+		if records.unreadable(err) {
+			return true
+		}
+		if err != nil {
+			log.Infow("drop node record", "tag", outputHead, "error", err)
+			return false
+		}
+		if legacy {
+			return false
+		}
+		b, ok := checked[node]
+		if !ok {
+			b = check(node)
+			checked[node] = b
+		}
+		return b
+	}
+}
+
+// publishNodes encodes the nodes to add keyed by identity
+func publishNodes(records *nodeRecords, nodes []string, identity func(node string) string) map[string]string {
+	publish := make(map[string]string)
+	for _, node := range nodes {
+		entry, err := records.encode(node)
+		if err != nil {
+			log.Errorw("encode node record", "tag", outputHead, "error", err, "node", node)
+			continue
+		}
+		publish[identity(node)] = entry
+	}
+	return publish
+}
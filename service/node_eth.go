@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/fatih/color"
@@ -17,7 +18,6 @@ import (
 	"github.com/glvd/accipfs/core"
 	"github.com/goextension/log"
 	"os"
-	"sort"
 	"strings"
 	"time"
 )
@@ -27,9 +27,14 @@ const endPoint = "geth.ipc"
 
 type nodeClientETH struct {
 	*serviceNode
-	cfg    *config.Config
-	client *ethclient.Client
-	out    *color.Color
+	cfg      *config.Config
+	contract contract.Contractor
+	leader   *leaderElection
+	records  *nodeRecords
+	settings func() *config.Config
+	served   func(id string) uint64
+	client   *ethclient.Client
+	out      *color.Color
 }
 
 // Network ...
@@ -127,21 +132,9 @@ func (n *nodeClientETH) Run() {
 		}
 	}
 
-	// init contract
-	cl := contract.Loader(n.cfg)
-
 	// get decoded contract nodes
-	err = cl.Node(func(node *node.AccelerateNode, opts *bind.TransactOpts) error {
+	err = n.contract.Node(func(node *node.AccelerateNode, tx *contract.Transactor) error {
 		o := &bind.CallOpts{Pending: true}
-		nodes, e := node.GetEthNodes(o)
-		if e != nil {
-			n.output("get contract node failed", e.Error())
-			return e
-		}
-		n.output("get contract nodes", len(nodes))
-
-		nodes = decodeNodes(n.cfg, nodes)
-		//fmt.Println("[cPeers]", cPeers)
 		// get decoded contract signer nodes
 		masterNodes, e := node.GetSignerNodes(o)
 		if e != nil {
@@ -150,71 +143,53 @@ func (n *nodeClientETH) Run() {
 		}
 		n.output("get contract nodes", len(masterNodes))
 
-		masterNodes = decodeNodes(n.cfg, masterNodes)
+		masterNodes = n.records.decodeNodes(masterNodes)
 		// filter public network accessible nodes
 		accessibleNodes := getAccessibleEthNodes(activePeers, "30303", 3*time.Second)
 		// sync nodes
 		newSignerNodes := difference([]string{cnode}, masterNodes)
-		newAccNodes := difference(accessibleNodes, nodes)
-		// node to be deleted
-		deleteNodes := difference(nodes, getAccessibleEthNodes(nodes, "30303", 3*time.Second))
-		var deleteIdx []int
-		for _, dNode := range deleteNodes {
-			for idx, cNode := range nodes {
-				if cNode == dNode {
-					deleteIdx = append(deleteIdx, idx)
-				}
-			}
-		}
-
-		// delete rest node
-		if len(deleteIdx) > 0 {
-			var err error
-			sort.Sort(sort.Reverse(sort.IntSlice(deleteIdx)))
-			for _, idx := range deleteIdx {
-				_, err = node.DeleteEthNodes(opts, uint32(idx))
-			}
-
-			if err != nil {
-				fmt.Println("<删除失效节点失败>", err.Error())
-			} else {
-				fmt.Println("[删除失效节点成功]")
-			}
-		}
-
-		// crypto node info && add to contract
-		if len(newAccNodes) > 0 {
-			var err error
-			fmt.Println("[adding node]", newAccNodes)
-			for _, n := range encodeNodes(n.cfg, newAccNodes) {
-				_, err = node.AddEthNodes(opts, []string{n})
-			}
-			if err != nil {
-				fmt.Println("[add node failed]", err.Error())
-			} else {
-				fmt.Println("[add node success]")
-			}
-			// update gateway info
-		} else {
-			fmt.Println("[已经是最新节点数据]")
-		}
 
 		// add signer nodes
 		if len(newSignerNodes) > 0 {
 			fmt.Println("[adding signer node]", newSignerNodes)
-			_, err := node.AddSignerNodes(opts, encodeNodes(n.cfg, newSignerNodes))
+			encoded := n.records.encodeNodes(newSignerNodes)
+			_, err := tx.Transact("AddSignerNodes", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return node.AddSignerNodes(opts, encoded)
+			})
 			if err != nil {
 				fmt.Println("<添加主节点失败>", err.Error())
 			} else {
 				fmt.Println("[添加主节点成功]")
+				masterNodes = append(masterNodes, newSignerNodes...)
 			}
 		}
+		// only the elected signer node maintains the node list and the dns records
+		if !n.leader.Elect(cnode, masterNodes) {
+			n.output("leader of this period", n.leader.Leader(), "skip node list and dns maintenance")
+			return nil
+		}
+		n.output("elected as leader of this period")
+
+		reconciler := contract.NewReconciler(contract.ContractNodeList(node, tx, contract.EthNodes), nodeIdentity(n.records, enodeID))
+		result, e := reconciler.Reconcile(ctx, contract.DesiredNodes{
+			Add: publishNodes(n.records, accessibleNodes, enodeID),
+			Keep: keepReachable(n.records, func(node string) bool {
+				return len(getAccessibleEthNodes([]string{node}, "30303", 3*time.Second)) > 0
+			}),
+		})
+		if e != nil {
+			fmt.Println("<同步节点失败>", e.Error())
+		} else {
+			fmt.Printf("[同步节点成功] added:%d removed:%d replaced:%d restored:%d conflicts:%d\n",
+				result.Added, result.Removed, result.Replaced, result.Restored, result.Conflicts)
+		}
+
 		vNodes := difference(accessibleNodes, masterNodes)
 		mNodes := make(map[string]bool)
 		for _, value := range vNodes {
 			mNodes[value] = true
 		}
-		syncDNS(n.cfg, mNodes)
+		syncDNS(n.dnsConfig(), mNodes, n.served)
 		return nil
 	})
 
@@ -227,16 +202,27 @@ func (n *nodeClientETH) Run() {
 	return
 }
 
-func newNodeETH(cfg *config.Config) (*nodeClientETH, error) {
+func newNodeETH(cfg *config.Config, contractor contract.Contractor, leader *leaderElection, records *nodeRecords) (*nodeClientETH, error) {
 	return &nodeClientETH{
 		cfg:         cfg,
+		contract:    contractor,
+		leader:      leader,
+		records:     records,
 		serviceNode: nodeInstance(),
 	}, nil
 }
 
+// dnsConfig returns the config with the dns settings of the last reload
+func (n *nodeClientETH) dnsConfig() *config.Config {
+	if n.settings == nil {
+		return n.cfg
+	}
+	return n.settings()
+}
+
 // IsReady ...
 func (n *nodeClientETH) IsReady() bool {
-	client, err := ethclient.Dial(config.ETHAddr())
+	client, err := ethclient.Dial(n.cfg.ETHAddr())
 	if err != nil {
 		log.Errorw("new serviceNode eth", "tag", outputHead, "error", err)
 		return false
@@ -268,7 +254,7 @@ func (n *nodeClientETH) AllPeers(ctx context.Context) ([]ETHPeer, error) {
 	var peers []ETHPeer
 	cancelCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	client, err := rpc.DialContext(cancelCtx, config.ETHAddr())
+	client, err := rpc.DialContext(cancelCtx, n.cfg.ETHAddr())
 	if err != nil {
 		return nil, err
 	}
@@ -286,7 +272,7 @@ func (n *nodeClientETH) NodeInfo(ctx context.Context) (*core.ContractNode, error
 	var node core.ContractNode
 	cancelCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	client, err := rpc.DialContext(cancelCtx, config.ETHAddr())
+	client, err := rpc.DialContext(cancelCtx, n.cfg.ETHAddr())
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +290,7 @@ func (n *nodeClientETH) AddPeer(ctx context.Context, peer string) error {
 	var b bool
 	cancelCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	client, err := rpc.DialContext(cancelCtx, config.ETHAddr())
+	client, err := rpc.DialContext(cancelCtx, n.cfg.ETHAddr())
 	if err != nil {
 		return err
 	}
@@ -318,19 +304,20 @@ func (n *nodeClientETH) AddPeer(ctx context.Context, peer string) error {
 }
 
 // FindNo ...
-func (n *nodeClientETH) FindNo(ctx context.Context, no string) error {
+func (n *nodeClientETH) FindNo(ctx context.Context, no string) (string, error) {
 	t, err := n.DTag()
 	if err != nil {
-		return err
+		return "", err
 	}
 	message, err := t.GetTagMessage(&bind.CallOpts{
 		Pending: true,
 		Context: ctx,
 	}, "video", no)
 	if err != nil {
-		return err
+		return "", err
 	}
-	fmt.Println("message", message.Value)
-
-	return nil
+	if message.Size.Int64() > 0 {
+		return message.Value[0], nil
+	}
+	return "", nil
 }
@@ -14,7 +14,7 @@ func TestNodeClientETH_NewAccount(t *testing.T) {
 	var inf interface{}
 	cancelCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	client, err := rpc.DialContext(cancelCtx, "http://127.0.0.1:8545")
+	client, err := rpc.DialContext(cancelCtx, liveEnv(t, "ACCIPFS_ETH_RPC"))
 	if err != nil {
 		t.Fatal(err)
 	}
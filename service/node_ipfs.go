@@ -3,12 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/glvd/accipfs/contract/node"
 	"github.com/glvd/accipfs/core"
 	"github.com/ipfs/interface-go-ipfs-core/options"
+	"io/ioutil"
 	"net"
-	"sort"
 	"strings"
 	"time"
 
@@ -28,8 +27,11 @@ const ipfsAPI = "api"
 
 type nodeClientIPFS struct {
 	*serviceNode
-	cfg *config.Config
-	api *httpapi.HttpApi
+	cfg      *config.Config
+	contract contract.Contractor
+	leader   *leaderElection
+	records  *nodeRecords
+	api      *httpapi.HttpApi
 }
 
 // PeerID ...
@@ -43,12 +45,19 @@ type PeerID struct {
 
 // NewNodeIPFS ...
 func NewNodeIPFS(cfg *config.Config) (Node, error) {
-	return newNodeIPFS(cfg)
+	shared, err := loadNodeShared(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newNodeIPFS(cfg, shared.contract, shared.leader, shared.records)
 }
 
-func newNodeIPFS(cfg *config.Config) (*nodeClientIPFS, error) {
+func newNodeIPFS(cfg *config.Config, contractor contract.Contractor, leader *leaderElection, records *nodeRecords) (*nodeClientIPFS, error) {
 	node := &nodeClientIPFS{
 		cfg:         cfg,
+		contract:    contractor,
+		leader:      leader,
+		records:     records,
 		serviceNode: nodeInstance(),
 	}
 	if err := node.connect(); err != nil {
@@ -75,7 +84,7 @@ func (n *nodeClientIPFS) SwarmConnect(ctx context.Context, addr string) (e error
 }
 
 func (n *nodeClientIPFS) connect() (e error) {
-	ma, err := multiaddr.NewMultiaddr(config.IPFSAddr())
+	ma, err := multiaddr.NewMultiaddr(n.cfg.IPFSAddr())
 	if err != nil {
 		return err
 	}
@@ -109,6 +118,54 @@ func (n *nodeClientIPFS) PinLS(ctx context.Context) (pins []iface.Pin, e error)
 	return n.api.Pin().Ls(ctx, options.Pin.Type.Recursive())
 }
 
+// PinHashes ...
+func (n *nodeClientIPFS) PinHashes(ctx context.Context) ([]string, error) {
+	pins, e := n.PinLS(ctx)
+	if e != nil {
+		return nil, e
+	}
+	var hashes []string
+	for _, p := range pins {
+		hashes = append(hashes, p.Path().String())
+	}
+	return hashes, nil
+}
+
+// BlockGet reads a block from the local repo, it is never fetched from the network
+func (n *nodeClientIPFS) BlockGet(ctx context.Context, hash string) ([]byte, error) {
+	resp, e := n.api.Request("block/get", strings.TrimPrefix(hash, "/ipfs/")).Option("offline", true).Send(ctx)
+	if e != nil {
+		return nil, e
+	}
+	defer resp.Close()
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return ioutil.ReadAll(resp.Output)
+}
+
+// PeerBandwidth returns the traffic with the peer since ipfs started
+func (n *nodeClientIPFS) PeerBandwidth(ctx context.Context, id string) (*core.Traffic, error) {
+	var stats struct {
+		TotalIn  int64
+		TotalOut int64
+	}
+	e := n.api.Request("stats/bw").Option("peer", id).Exec(ctx, &stats)
+	if e != nil {
+		return nil, e
+	}
+	return &core.Traffic{In: uint64(stats.TotalIn), Out: uint64(stats.TotalOut)}, nil
+}
+
+// DagSize ...
+func (n *nodeClientIPFS) DagSize(ctx context.Context, hash string) (uint64, error) {
+	stat, e := n.api.Object().Stat(ctx, path.New(hash))
+	if e != nil {
+		return 0, e
+	}
+	return uint64(stat.CumulativeSize), nil
+}
+
 // PinRm ...
 func (n *nodeClientIPFS) PinRm(ctx context.Context, hash string) (e error) {
 	p := path.New(hash)
@@ -117,7 +174,7 @@ func (n *nodeClientIPFS) PinRm(ctx context.Context, hash string) (e error) {
 
 // IsReady ...
 func (n *nodeClientIPFS) IsReady() bool {
-	ma, err := multiaddr.NewMultiaddr(config.IPFSAddr())
+	ma, err := multiaddr.NewMultiaddr(n.cfg.IPFSAddr())
 	if err != nil {
 		return false
 	}
@@ -189,68 +246,29 @@ func (n *nodeClientIPFS) Run() {
 	//	fmt.Println("<IPFS节点状态已是最新>")
 	//	return
 	//}
-	cl := contract.Loader(n.cfg)
-	err := cl.Node(func(node *node.AccelerateNode, opts *bind.TransactOpts) error {
-		op := &bind.CallOpts{Pending: true}
-		cPeers, err := node.GetIpfsNodes(op)
-		if err != nil {
-			log.Errorw("get ipfs node error", "tag", outputHead, "error", err)
-			return err
-		}
-		cNodes, err := node.GetPublicIpfsNodes(op)
+	// the leader is elected by the eth node of the same process
+	if !n.leader.Leading() {
+		n.output("not the leader of this period, skip public node maintenance")
+		return
+	}
+	var nodes []string
+	for addr := range publicNodes {
+		nodes = append(nodes, addr)
+	}
+	err := n.contract.Node(func(node *node.AccelerateNode, tx *contract.Transactor) error {
+		reconciler := contract.NewReconciler(contract.ContractNodeList(node, tx, contract.PublicIPFSNodes), nodeIdentity(n.records, ipfsNodeID))
+		result, err := reconciler.Reconcile(context.Background(), contract.DesiredNodes{
+			Add: publishNodes(n.records, nodes, ipfsNodeID),
+			Keep: keepReachable(n.records, func(node string) bool {
+				return len(getAccessibleIPFSNodes([]string{node}, "4001")) > 0
+			}),
+		})
 		if err != nil {
-			log.Errorw("get public ipfs node error", "tag", outputHead, "error", err)
+			fmt.Println("[同步节点失败]", err.Error())
 			return err
 		}
-		cPeers = decodeNodes(n.cfg, cPeers)
-		cNodes = decodeNodes(n.cfg, cNodes)
-
-		//TODO:fix sta
-		//fmt.Println("[adding ipfs nodes]", difference(peers, cPeers))
-		fmt.Println("[adding public ipfs nodes]", DiffStrArray(cNodes, publicNodes))
-		// delete nodes
-		var deleteIdx []int
-		for _, dNode := range difference(cNodes, getAccessibleIPFSNodes(cNodes, "4001")) {
-			for idx, cNode := range cNodes {
-				if cNode == dNode {
-					deleteIdx = append(deleteIdx, idx)
-				}
-			}
-		}
-		//TODO:fix end
-		if len(deleteIdx) > 0 {
-			var err error
-			sort.Sort(sort.Reverse(sort.IntSlice(deleteIdx)))
-			for _, idx := range deleteIdx {
-				_, err = node.DeletePublicIpfsNodes(opts, uint32(idx))
-			}
-
-			if err != nil {
-				fmt.Println("<删除失效节点失败>", err.Error())
-			} else {
-				fmt.Println("[删除失效节点成功]")
-			}
-		}
-
-		// add new nodes
-		//for _, n := range n.encodeNodes(difference(peers, cPeers)) {
-		//	if n == "" {
-		//		continue
-		//	}
-		//	_, err = ac.AddIpfsNodes(auth, []string{n})
-		//}
-		for _, n := range encodeNodes(n.cfg, DiffStrArray(cNodes, publicNodes)) {
-			if n == "" {
-				continue
-			}
-			_, err = node.AddPublicIpfsNodes(opts, []string{n})
-		}
-
-		if err != nil {
-			fmt.Println("[添加节点失败]", err.Error())
-		} else {
-			fmt.Println("[添加节点成功] ")
-		}
+		fmt.Printf("[同步节点成功] added:%d removed:%d restored:%d conflicts:%d\n",
+			result.Added, result.Removed, result.Restored, result.Conflicts)
 		return nil
 	})
 
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/record"
+)
+
+type recordSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *recordSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *recordSigner) SignText(data []byte) ([]byte, error) {
+	return crypto.Sign(accounts.TextHash(data), s.key)
+}
+
+func TestKeepReachable(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &recordSigner{key: key}
+	newRecords := func(key string) *nodeRecords {
+		cfg := *config.Default()
+		cfg.Record.Key, cfg.Record.Legacy = key, false
+		records, err := newNodeRecords(&cfg, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return records
+	}
+	plain, sealed := newRecords(""), newRecords("network key")
+	encode := func(records *nodeRecords, node string) string {
+		entry, err := records.encode(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+	up, down := "/ip4/10.0.0.1/tcp/4001/ipfs/QmUp", "/ip4/10.0.0.2/tcp/4001/ipfs/QmDown"
+	forged := &record.Node{IPFS: []string{up}}
+	if err := forged.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	forged.IPFS = []string{"/ip4/10.0.0.3/tcp/4001/ipfs/QmForged"}
+	forgedEntry, err := plain.codec.Encode(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(node string) bool {
+		return node == up
+	}
+
+	for _, c := range []struct {
+		name    string
+		records *nodeRecords
+		entry   string
+		keep    bool
+	}{
+		{"reachable", plain, encode(plain, up), true},
+		{"unreachable", plain, encode(plain, down), false},
+		{"forged", plain, forgedEntry, false},
+		{"corrupt", plain, "acc1:e30", false},
+		{"legacy", plain, "c2VjcmV0IG5vZGU", true},
+		{"sealed without the key", plain, encode(sealed, down), true},
+		{"sealed with another key", newRecords("other key"), encode(sealed, up), false},
+		{"sealed", sealed, encode(sealed, up), true},
+	} {
+		if keep := keepReachable(c.records, check)(c.entry); keep != c.keep {
+			t.Errorf("%s: keep %v, want %v", c.name, keep, c.keep)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/core"
+	"github.com/goextension/log"
+)
+
+// notifyQueue is the number of notifications kept for a slow subscriber
+const notifyQueue = 64
+
+// notifySub receives the notifications of its topics
+type notifySub struct {
+	topics map[string]bool
+	ch     chan *core.Notification
+}
+
+// notifier hands the events of the daemon to the websocket subscribers
+type notifier struct {
+	mut  sync.RWMutex
+	next uint64
+	subs map[uint64]*notifySub
+}
+
+func newNotifier() *notifier {
+	return &notifier{subs: make(map[uint64]*notifySub)}
+}
+
+// Subscribe returns the id of the subscription and the channel of its notifications
+func (n *notifier) Subscribe(topics ...string) (uint64, <-chan *core.Notification) {
+	sub := &notifySub{topics: make(map[string]bool), ch: make(chan *core.Notification, notifyQueue)}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	n.next++
+	n.subs[n.next] = sub
+	return n.next, sub.ch
+}
+
+// Unsubscribe closes the channel of the subscription
+func (n *notifier) Unsubscribe(id uint64) bool {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	sub, b := n.subs[id]
+	if !b {
+		return false
+	}
+	delete(n.subs, id)
+	close(sub.ch)
+	return true
+}
+
+// Publish sends the event to the subscribers of the topic, a subscriber
+// whose queue is full misses it
+func (n *notifier) Publish(topic string, data interface{}) {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+	if len(n.subs) == 0 {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Errorw("encode notification", "tag", outputHead, "topic", topic, "error", err)
+		return
+	}
+	msg := &core.Notification{Topic: topic, Time: time.Now().Unix(), Data: raw}
+	for id, sub := range n.subs {
+		if !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			log.Debugw("notification dropped", "tag", outputHead, "subscription", id, "topic", topic)
+		}
+	}
+}
+
+// joinPeer adds a connected peer to the nodes
+func (a *Accelerate) joinPeer(info *core.NodeInfo) {
+	if a.nodes.Add(info) {
+		a.notifier.Publish(core.TopicPeerJoined, &core.PeerEvent{Node: info})
+	}
+}
+
+// dropPeer moves a peer that can not be reached or is not trusted to the dummy nodes
+func (a *Accelerate) dropPeer(info *core.NodeInfo) {
+	removed := a.nodes.Remove(info.Name)
+	a.dummyNodes.Add(info)
+	a.closeGRPC(info)
+	if removed {
+		a.notifier.Publish(core.TopicPeerLeft, &core.PeerEvent{Node: info})
+	}
+}
+
+// pinProgress ...
+func (a *Accelerate) pinProgress(p *core.PinProgress, err error) {
+	if err != nil {
+		p.State, p.Error = core.PinFailed, err.Error()
+	}
+	a.notifier.Publish(core.TopicPinProgress, p)
+}
+
+// videoProgress sends the state of the hashes of a video
+func (a *Accelerate) videoProgress(no string, hashes []string, state string, err error) {
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		a.pinProgress(&core.PinProgress{Hash: hash, No: no, State: state}, err)
+	}
+}
+
+// videoPublished tells the subscribers about the videos added to the message contract
+func (a *Accelerate) videoPublished(e contract.Event) {
+	tx, b := e.Data.(*types.Transaction)
+	if !b {
+		return
+	}
+	id, _, err := contract.AddedMessage(tx)
+	if err != nil {
+		log.Debugw("not a new video", "tag", outputHead, "tx", e.TxHash.Hex(), "error", err)
+		return
+	}
+	a.notifier.Publish(core.TopicVideoPublished, &core.VideoPublished{
+		No:      id,
+		Block:   e.Block,
+		Tx:      e.TxHash.Hex(),
+		Removed: e.Removed,
+	})
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/glvd/accipfs/core"
+)
+
+// pathParam finds the {name} parameters of a route path
+var pathParam = regexp.MustCompile(`{([^}/]+)}`)
+
+var rawMessage = reflect.TypeOf(json.RawMessage{})
+
+// newOf returns a pointer to a new value of the type of v
+func newOf(v interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(v)).Interface()
+}
+
+// schemas builds the json schemas of go types, named structs are kept as components
+type schemas struct {
+	components map[string]interface{}
+}
+
+func (s *schemas) of(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == rawMessage {
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := t.Name()
+		if _, b := s.components[name]; !b {
+			// the placeholder stops the recursion of types that refer to themselves
+			s.components[name] = nil
+			s.components[name] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+// object is the schema of the exported fields of a struct with their json names
+func (s *schemas) object(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		props[name] = s.of(f.Type)
+	}
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// openAPI returns the openapi 3 document of the rest routes
+func openAPI(routes []*restRoute) map[string]interface{} {
+	s := &schemas{components: make(map[string]interface{})}
+	errSchema := s.of(reflect.TypeOf(core.APIError{}))
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		op := map[string]interface{}{
+			"summary":     rt.Summary,
+			"operationId": operationID(rt),
+		}
+		var params []interface{}
+		for _, m := range pathParam.FindAllStringSubmatch(rt.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if rt.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(s.of(reflect.TypeOf(rt.Request))),
+			}
+		}
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     jsonContent(s.of(reflect.TypeOf(rt.Response))),
+			},
+			"default": map[string]interface{}{
+				"description": "error",
+				"content":     jsonContent(errSchema),
+			},
+		}
+		for _, status := range rt.Errors {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     jsonContent(errSchema),
+			}
+		}
+		op["responses"] = responses
+		item, b := paths[rt.Path].(map[string]interface{})
+		if !b {
+			item = make(map[string]interface{})
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "accipfs",
+			"version": "v1",
+		},
+		"servers":    []interface{}{map[string]interface{}{"url": restPrefix}},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": s.components},
+	}
+}
+
+// operationID names a route after its method and path, GET /videos/{no} is getVideosNo
+func operationID(rt *restRoute) string {
+	id := strings.ToLower(rt.Method)
+	for _, part := range strings.FieldsFunc(rt.Path, func(r rune) bool { return r == '/' || r == '{' || r == '}' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
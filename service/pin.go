@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"sort"
+	"sync"
+
+	"github.com/glvd/accipfs/contract"
+	"github.com/glvd/accipfs/contract/token"
+	"github.com/goextension/log"
+)
+
+const pinQueueSize = 256
+
+// pinJob is one hash a user paid to pin
+type pinJob struct {
+	Hash  string
+	User  string
+	Date  string
+	Block uint64
+	Tx    string
+}
+
+// pinScheduler pins the hashes of the pin events one after another
+type pinScheduler struct {
+	mut     sync.Mutex
+	pending map[string]bool
+	queue   chan pinJob
+	pin     func(ctx context.Context, job pinJob) error
+	revert  func(job pinJob)
+	// queued is told about every job added to the queue
+	queued func(job pinJob)
+}
+
+func newPinScheduler(pin func(ctx context.Context, job pinJob) error, revert func(job pinJob)) *pinScheduler {
+	return &pinScheduler{
+		pending: make(map[string]bool),
+		queue:   make(chan pinJob, pinQueueSize),
+		pin:     pin,
+		revert:  revert,
+	}
+}
+
+func (s *pinScheduler) event(e contract.Event) {
+	v, b := e.Data.(*token.DhTokenPinSuccess)
+	if !b {
+		return
+	}
+	job := pinJob{
+		Hash:  v.Hash,
+		User:  v.User.Hex(),
+		Date:  v.Date,
+		Block: e.Block,
+		Tx:    e.TxHash.Hex(),
+	}
+	if e.Removed {
+		// the pin transaction is no longer in the chain
+		s.Cancel(v.Hash)
+		s.revert(job)
+		return
+	}
+	s.Add(job)
+}
+
+// Add queues a job, it returns false when the hash is already queued or the queue is full
+func (s *pinScheduler) Add(job pinJob) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.pending[job.Hash] {
+		return false
+	}
+	select {
+	case s.queue <- job:
+		s.pending[job.Hash] = true
+		if s.queued != nil {
+			s.queued(job)
+		}
+		return true
+	default:
+		log.Errorw("pin queue is full", "tag", outputHead, "hash", job.Hash)
+		return false
+	}
+}
+
+// Cancel drops a queued hash that was not pinned yet
+func (s *pinScheduler) Cancel(hash string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.pending, hash)
+}
+
+// Run ...
+func (s *pinScheduler) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.queue:
+			s.mut.Lock()
+			b := s.pending[job.Hash]
+			delete(s.pending, job.Hash)
+			s.mut.Unlock()
+			if !b {
+				continue
+			}
+			if err := s.pin(ctx, job); err != nil {
+				log.Errorw("pin", "tag", outputHead, "hash", job.Hash, "error", err)
+			}
+		}
+	}
+}
+
+// assignees ranks the nodes for a hash, every node that knows the same nodes
+// picks the same ones to serve it
+func assignees(hash string, nodes []string, replicas int) []string {
+	seen := make(map[string]bool)
+	var ranked []string
+	for _, node := range nodes {
+		if node != "" && !seen[node] {
+			seen[node] = true
+			ranked = append(ranked, node)
+		}
+	}
+	score := func(node string) [32]byte {
+		return sha256.Sum256([]byte(hash + "|" + node))
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		si, sj := score(ranked[i]), score(ranked[j])
+		return string(si[:]) < string(sj[:])
+	})
+	if replicas > 0 && len(ranked) > replicas {
+		ranked = ranked[:replicas]
+	}
+	return ranked
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/core"
+	"github.com/goextension/log"
+)
+
+// reloadDelay collects the events of one save, editors write a file in several steps
+const reloadDelay = 500 * time.Millisecond
+
+// configLoader reads the config.json of the node again
+func configLoader(cfg *config.Config) func() (*config.Config, error) {
+	dir := cfg.Dir()
+	return func() (*config.Config, error) {
+		return config.LoadDir(dir)
+	}
+}
+
+// settings returns the config with the changes applied since the start
+func (a *Accelerate) settings() *config.Config {
+	a.settingsMut.RLock()
+	defer a.settingsMut.RUnlock()
+	return a.live
+}
+
+// OnReload adds a function called with the config after every reload
+func (a *Accelerate) OnReload(fn func(cfg *config.Config)) {
+	a.settingsMut.Lock()
+	defer a.settingsMut.Unlock()
+	a.reloaded = append(a.reloaded, fn)
+}
+
+// reload applies the changes of the config that need no restart
+func (a *Accelerate) reload(next *config.Config) *core.ConfigChanges {
+	a.settingsMut.Lock()
+	old := a.live
+	live, restart := config.Changes(old, next)
+	cur := config.Live(old, next)
+	a.live = cur
+	hooks := a.reloaded
+	a.settingsMut.Unlock()
+
+	changes := &core.ConfigChanges{Applied: live, Restart: restart}
+	if len(live) == 0 && len(restart) == 0 {
+		return changes
+	}
+	if cur.Schedule != old.Schedule {
+		if err := a.schedule(cur.Schedule); err != nil {
+			log.Errorw("reschedule", "tag", outputHead, "error", err)
+		}
+	}
+	if added := DiffStrArray(cur.Peers, stringSet(old.Peers)); len(added) > 0 && a.id != nil {
+		go a.connectPeers(a.ctx, added)
+	}
+	for _, fn := range hooks {
+		fn(cur)
+	}
+	fmt.Println(outputHead, "Accelerate", "config reloaded, applied", live)
+	if len(restart) > 0 {
+		fmt.Println(outputHead, "Accelerate", "restart to apply", restart)
+	}
+	return changes
+}
+
+// schedule runs the peer sync with the cron spec, the job of the last spec is removed
+func (a *Accelerate) schedule(spec string) error {
+	id, err := a.cron.AddJob(spec, a)
+	if err != nil {
+		return err
+	}
+	a.settingsMut.Lock()
+	last := a.job
+	a.job = id
+	a.settingsMut.Unlock()
+	if last != 0 {
+		a.cron.Remove(last)
+	}
+	fmt.Println(outputHead, "Accelerate", "run id", id)
+	return nil
+}
+
+// connectPeers connects the nodes of the config
+func (a *Accelerate) connectPeers(ctx context.Context, addrs []string) {
+	for _, addr := range addrs {
+		info := new(core.NodeInfo)
+		if err := a.ConnectTo(nil, &addr, info); err != nil {
+			log.Errorw("connect peer", "tag", outputHead, "addr", addr, "error", err)
+			continue
+		}
+		result := new(bool)
+		if err := a.addPeer(ctx, info, result); err != nil {
+			log.Errorw("connect peer", "tag", outputHead, "addr", addr, "error", err)
+		}
+	}
+}
+
+// ReloadConfig reads config.json again and applies the changes that need no restart
+func (a *Accelerate) ReloadConfig(r *http.Request, _ *core.Empty, result *core.ConfigChanges) error {
+	next, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+	*result = *a.reload(next)
+	return nil
+}
+
+// watchConfig reloads the config when config.json changes or the process gets a reload signal
+func (a *Accelerate) watchConfig(ctx context.Context, path string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Errorw("watch config", "tag", outputHead, "error", err)
+		return
+	}
+	defer watcher.Close()
+	// the dir is watched, editors and SaveConfig replace the file
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		log.Errorw("watch config", "tag", outputHead, "error", err)
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(signals, reloadSignals...)
+		defer signal.Stop(signals)
+	}
+	delay := time.NewTimer(reloadDelay)
+	delay.Stop()
+	defer delay.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(path) || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			delay.Reset(reloadDelay)
+			continue
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Errorw("watch config", "tag", outputHead, "error", err)
+			continue
+		case <-signals:
+		case <-delay.C:
+		}
+		next, err := a.loadConfig()
+		if err != nil {
+			// the running config stays until the file is fixed
+			log.Errorw("reload config", "tag", outputHead, "error", err)
+			continue
+		}
+		a.reload(next)
+	}
+}
+
+func stringSet(list []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, s := range list {
+		set[s] = true
+	}
+	return set
+}
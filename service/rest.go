@@ -0,0 +1,135 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/gorilla/mux"
+)
+
+// restPrefix is the path of the rest api
+const restPrefix = "/api/v1"
+
+// maxRestBody is the largest request body read
+const maxRestBody = 1 << 20
+
+// restRoute is one endpoint of the rest api, the openapi document is built from the routes
+type restRoute struct {
+	Method   string
+	Path     string // below restPrefix, {name} for a path parameter
+	Summary  string
+	Request  interface{} // the body, nil when there is none
+	Response interface{}
+	Errors   []int
+	handle   func(r *http.Request, body interface{}) (interface{}, error)
+}
+
+// restRoutes are served with the rpc methods of the node, they check and fail the same way
+func (a *Accelerate) restRoutes() []*restRoute {
+	return []*restRoute{
+		{
+			Method:   http.MethodGet,
+			Path:     "/id",
+			Summary:  "the info of this node",
+			Response: core.NodeInfo{},
+			handle: func(r *http.Request, _ interface{}) (interface{}, error) {
+				result := new(core.NodeInfo)
+				return result, a.ID(r, new(core.Empty), result)
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/version",
+			Summary:  "the build and the peer protocol of this node",
+			Response: core.BuildInfo{},
+			handle: func(r *http.Request, _ interface{}) (interface{}, error) {
+				result := new(core.BuildInfo)
+				return result, a.Version(r, new(core.Empty), result)
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/peers",
+			Summary:  "the connected peers",
+			Response: []*core.NodeInfo{},
+			handle: func(r *http.Request, _ interface{}) (interface{}, error) {
+				result := new([]*core.NodeInfo)
+				return result, a.Peers(r, new(core.Empty), result)
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/pins",
+			Summary:  "the hashes pinned by this node",
+			Response: []string{},
+			handle: func(r *http.Request, _ interface{}) (interface{}, error) {
+				result := new([]string)
+				return result, a.Pins(r, new(core.Empty), result)
+			},
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/videos/{no}",
+			Summary:  "the message of a video number",
+			Response: core.VideoV1{},
+			Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(r *http.Request, _ interface{}) (interface{}, error) {
+				no, info := mux.Vars(r)["no"], new(string)
+				if err := a.TagInfo(r, &no, info); err != nil {
+					return nil, err
+				}
+				return json.RawMessage(*info), nil
+			},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/pins",
+			Summary:  "pin the hashes of a video number, it returns when they are pinned",
+			Request:  core.PinRequest{},
+			Response: core.PinResult{},
+			Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(r *http.Request, body interface{}) (interface{}, error) {
+				req := body.(*core.PinRequest)
+				result := &core.PinResult{No: req.No}
+				return result, a.PinVideo(r, &req.No, &result.Pinned)
+			},
+		},
+	}
+}
+
+// serve decodes the body of the request, calls the route and encodes its result
+func (rt *restRoute) serve(w http.ResponseWriter, r *http.Request) {
+	var body interface{}
+	if rt.Request != nil {
+		body = newOf(rt.Request)
+		dec := json.NewDecoder(io.LimitReader(r.Body, maxRestBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(body); err != nil {
+			writeError(w, invalidParams("decode body: %v", err))
+			return
+		}
+	}
+	result, err := rt.handle(r, body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleREST serves the rest api and its openapi document below restPrefix
+func handleREST(route *mux.Router, routes []*restRoute) {
+	api := route.PathPrefix(restPrefix).Subrouter()
+	for _, rt := range routes {
+		api.HandleFunc(rt.Path, rt.serve).Methods(rt.Method)
+	}
+	doc := openAPI(routes)
+	api.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, doc)
+	}).Methods(http.MethodGet)
+	api.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, notFound("no endpoint %s %s", r.Method, r.URL.Path))
+	})
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+)
+
+func (n *harnessNode) api(path string) string {
+	return fmt.Sprintf("http://127.0.0.1:%d%s%s", n.cfg.Port, restPrefix, path)
+}
+
+// request sends a rest request and decodes the answer, it returns the status
+func request(t *testing.T, method, url string, body, out interface{}) int {
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestHarnessREST(t *testing.T) {
+	h := newHarness(t, 3)
+	video := core.VideoV1{
+		No:         "rest-001",
+		ThumbHash:  "thumb-rest",
+		PosterHash: "poster-rest",
+		SourceHash: "source-rest",
+		M3U8Hash:   "m3u8-rest",
+	}
+	data, err := video.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.chain.tags[video.No] = string(data)
+	for _, hash := range []string{video.ThumbHash, video.PosterHash, video.SourceHash, video.M3U8Hash} {
+		h.nodes[2].ipfs.pin(hash)
+	}
+	h.start()
+	defer h.stop()
+	h.connect(0, 1)
+	h.connect(1, 2)
+	h.sync(len(h.nodes))
+	node := h.nodes[0]
+
+	var id core.NodeInfo
+	if status := request(t, http.MethodGet, node.api("/id"), nil, &id); status != http.StatusOK || id.Name != node.name {
+		t.Errorf("id: %d %+v", status, id)
+	}
+	var peers []*core.NodeInfo
+	if status := request(t, http.MethodGet, node.api("/peers"), nil, &peers); status != http.StatusOK || len(peers) == 0 {
+		t.Errorf("peers: %d %d", status, len(peers))
+	}
+	var v core.VideoV1
+	if status := request(t, http.MethodGet, node.api("/videos/"+video.No), nil, &v); status != http.StatusOK || v.SourceHash != video.SourceHash {
+		t.Errorf("video: %d %+v", status, v)
+	}
+	var apiErr core.APIError
+	if status := request(t, http.MethodGet, node.api("/videos/missing-001"), nil, &apiErr); status != http.StatusNotFound || apiErr.Error.Code != int(errNotFound) {
+		t.Errorf("missing video: %d %+v", status, apiErr)
+	}
+	if status := request(t, http.MethodPost, node.api("/pins"), &core.PinRequest{No: "bad no"}, &apiErr); status != http.StatusBadRequest {
+		t.Errorf("bad number: %d %+v", status, apiErr)
+	}
+	if status := request(t, http.MethodPost, node.api("/pins"), map[string]int{"number": 1}, &apiErr); status != http.StatusBadRequest {
+		t.Errorf("bad body: %d %+v", status, apiErr)
+	}
+	var pinned core.PinResult
+	if status := request(t, http.MethodPost, node.api("/pins"), &core.PinRequest{No: video.No}, &pinned); status != http.StatusOK || !pinned.Pinned {
+		t.Fatalf("pin: %d %+v", status, pinned)
+	}
+	var pins []string
+	if status := request(t, http.MethodGet, node.api("/pins"), nil, &pins); status != http.StatusOK || len(pins) != 4 {
+		t.Errorf("pins: %d %v", status, pins)
+	}
+
+	// the rpc reports the same error
+	info := new(string)
+	err = general.RPCPost(node.url(), "Accelerate.TagInfo", "missing-001", info)
+	var remote *general.RemoteError
+	if !errors.As(err, &remote) || remote.Code != int(errNotFound) {
+		t.Errorf("rpc missing video: %v", err)
+	}
+
+	var doc struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	if status := request(t, http.MethodGet, node.api("/openapi.json"), nil, &doc); status != http.StatusOK || doc.OpenAPI == "" {
+		t.Fatalf("openapi: %d", status)
+	}
+	for _, rt := range node.acc.restRoutes() {
+		if doc.Paths[rt.Path][strings.ToLower(rt.Method)] == nil {
+			t.Errorf("%s %s is not documented", rt.Method, rt.Path)
+		}
+	}
+}
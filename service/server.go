@@ -5,9 +5,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/dns"
+	"github.com/goextension/log"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/rpc/v2"
 	"github.com/gorilla/rpc/v2/json2"
+	"google.golang.org/grpc"
 	"io"
 	"net/http"
 	"strings"
@@ -29,21 +32,27 @@ type Server struct {
 	accelerate *Accelerate
 	rpcServer  *rpc.Server
 	httpServer *http.Server
+	dnsServer  *dns.Server
+	grpcServer *grpc.Server
+	peerServer *peerServer
 	route      *mux.Router
 }
 
 // NewRPCServer ...
 func NewRPCServer(cfg *config.Config) (*Server, error) {
-	rpcServer := rpc.NewServer()
-	rpcServer.RegisterCodec(json2.NewCodec(), "application/json")
-	rpcServer.RegisterCodec(json2.NewCodec(), "application/json;charset=UTF-8")
-
 	acc, err := NewAccelerateServer(cfg)
 	if err != nil {
 		return nil, err
 	}
+	return newRPCServer(cfg, acc)
+}
 
-	err = rpcServer.RegisterService(acc, "")
+func newRPCServer(cfg *config.Config, acc *Accelerate) (*Server, error) {
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterCodec(json2.NewCodec(), "application/json")
+	rpcServer.RegisterCodec(json2.NewCodec(), "application/json;charset=UTF-8")
+
+	err := rpcServer.RegisterService(acc, "")
 	if err != nil {
 		return nil, err
 	}
@@ -51,13 +60,21 @@ func NewRPCServer(cfg *config.Config) (*Server, error) {
 		cfg:        cfg,
 		rpcServer:  rpcServer,
 		accelerate: acc,
+		peerServer: newPeerServer(acc),
 		route:      mux.NewRouter(),
 	}, nil
 }
 
 // Start ...
 func (s *Server) Start() error {
-	s.route.Handle("/rpc", s.rpcServer)
+	s.route.Handle("/rpc", batchHandler(s.rpcServer))
+	s.route.Handle("/ws", wsHandler(s.accelerate.notifier, func() []string {
+		return s.accelerate.settings().WSOrigins
+	}))
+	handleREST(s.route, s.accelerate.restRoutes())
+	if s.cfg.IPFS.GatewayProxy {
+		s.route.PathPrefix("/ipfs/").Handler(s.accelerate.gateway())
+	}
 
 	port := fmt.Sprintf(":%d", s.cfg.Port)
 	s.httpServer = &http.Server{Addr: port, Handler: s.route}
@@ -95,16 +112,45 @@ func (s *Server) Start() error {
 	if idError != nil {
 		return idError
 	}
+	go s.accelerate.connectPeers(s.accelerate.ctx, s.accelerate.settings().Peers)
+	if s.cfg.DNS.Listen != "" {
+		s.dnsServer = dns.NewServer(dns.RecordName(s.cfg), s.cfg.DNS.ListenTTL, s.accelerate.gatewayIPs)
+		if err := s.dnsServer.Listen(s.cfg.DNS.Listen); err != nil {
+			return err
+		}
+		fmt.Println(outputHead, "DNS service answering", dns.RecordName(s.cfg), "on", s.dnsServer.Addr())
+		go func() {
+			if err := s.dnsServer.Serve(); err != nil {
+				log.Errorw("dns server", "tag", outputHead, "error", err)
+			}
+		}()
+	}
+	if s.cfg.GRPC.Enable {
+		if err := s.listenGRPC(); err != nil {
+			return err
+		}
+	}
 	fmt.Println(outputHead, "JSON RPC service listen and serving on port", port)
 	s.httpServer.ListenAndServe()
 	return nil
 }
 
+// OnReload adds a function called with the config after every reload
+func (s *Server) OnReload(fn func(cfg *config.Config)) {
+	s.accelerate.OnReload(fn)
+}
+
 // Stop ...
 func (s *Server) Stop() error {
 	if err := s.httpServer.Shutdown(context.Background()); err != nil {
 		return err
 	}
+	if s.dnsServer != nil {
+		_ = s.dnsServer.Close()
+	}
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
 	s.accelerate.Stop()
 	return nil
 }
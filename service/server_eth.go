@@ -18,11 +18,19 @@ type nodeServerETH struct {
 	genesis *config.Genesis
 	name    string
 	cmd     *exec.Cmd
+	shared  *nodeShared
 }
 
 // Node ...
 func (n *nodeServerETH) Node() (Node, error) {
-	return newNodeETH(n.cfg)
+	if n.shared == nil {
+		shared, err := loadNodeShared(n.cfg)
+		if err != nil {
+			return nil, err
+		}
+		n.shared = shared
+	}
+	return newNodeETH(n.cfg, n.shared.contract, n.shared.leader, n.shared.records)
 }
 
 // Stop ...
@@ -37,10 +45,10 @@ func (n *nodeServerETH) Stop() error {
 // Start ...
 func (n *nodeServerETH) Start() error {
 	n.cmd = exec.CommandContext(n.ctx, n.name,
-		"--datadir", config.DataDirETH(),
+		"--datadir", n.cfg.DataDirETH(),
 		"--networkid", strconv.FormatInt(n.genesis.Config.ChainID, 10),
 		"--allow-insecure-unlock",
-		"--rpccorsdomain", "*", "--rpc", "--rpcport", "8545", "--rpcaddr", "127.0.0.1",
+		"--rpccorsdomain", "*", "--rpc", "--rpcport", strconv.Itoa(n.cfg.ETH.Port), "--rpcaddr", "127.0.0.1",
 		"--rpcapi", "admin,eth,net,web3,personal,miner",
 		//"--unlock", "945d35cd4a6549213e8d37feb5d708ec98906902",
 		"--mine", "--nodiscover",
@@ -67,11 +75,11 @@ func (n *nodeServerETH) Start() error {
 
 // Init ...
 func (n *nodeServerETH) Init() error {
-	_, err := os.Stat(config.DataDirETH())
+	_, err := os.Stat(n.cfg.DataDirETH())
 	if err != nil && os.IsNotExist(err) {
-		_ = os.MkdirAll(config.DataDirETH(), 0755)
+		_ = os.MkdirAll(n.cfg.DataDirETH(), 0755)
 	}
-	cmd := exec.Command(n.name, "--datadir", config.DataDirETH(), "init", filepath.Join(n.cfg.Path, "genesis.json"))
+	cmd := exec.Command(n.name, "--datadir", n.cfg.DataDirETH(), "init", filepath.Join(n.cfg.Path, "genesis.json"))
 	err = cmd.Run()
 	if err != nil {
 		return err
@@ -82,10 +90,12 @@ func (n *nodeServerETH) Init() error {
 
 // NewNodeServerETH ...
 func NewNodeServerETH(cfg *config.Config) NodeServer {
-	return newNodeServerETH(cfg)
+	return newNodeServerETH(cfg, nil)
 }
 
-func newNodeServerETH(cfg *config.Config) *nodeServerETH {
+// newNodeServerETH returns the server of the node, its client writes through
+// the shared contracts, they are loaded on the first call of Node when nil
+func newNodeServerETH(cfg *config.Config, shared *nodeShared) *nodeServerETH {
 	path := filepath.Join(cfg.Path, "bin", binName(cfg.ETH.Name))
 	genesis, err := config.LoadGenesis(cfg)
 	if err != nil {
@@ -98,5 +108,6 @@ func newNodeServerETH(cfg *config.Config) *nodeServerETH {
 		cfg:     cfg,
 		genesis: genesis,
 		name:    path,
+		shared:  shared,
 	}
 }
@@ -17,16 +17,25 @@ type nodeServerIPFS struct {
 	cfg    *config.Config
 	name   string
 	cmd    *exec.Cmd
+	shared *nodeShared
 }
 
 // Node ...
 func (n *nodeServerIPFS) Node() (Node, error) {
-	return newNodeIPFS(n.cfg)
+	if n.shared == nil {
+		shared, err := loadNodeShared(n.cfg)
+		if err != nil {
+			return nil, err
+		}
+		n.shared = shared
+	}
+	return newNodeIPFS(n.cfg, n.shared.contract, n.shared.leader, n.shared.records)
 }
 
 // Start ...
 func (n *nodeServerIPFS) Start() error {
 	n.cmd = exec.CommandContext(n.ctx, n.name, "daemon", "--routing", "none")
+	n.cmd.Env = n.cfg.IPFSEnv()
 	fmt.Println("ipfs cmd: ", n.cmd.Args)
 	pipe, err2 := n.cmd.StderrPipe()
 	if err2 != nil {
@@ -57,24 +66,26 @@ func (n *nodeServerIPFS) Stop() error {
 
 // Init ...
 func (n *nodeServerIPFS) Init() error {
-	_, err := os.Stat(config.DataDirIPFS())
+	_, err := os.Stat(n.cfg.DataDirIPFS())
 	if err != nil && os.IsNotExist(err) {
-		_ = os.MkdirAll(config.DataDirIPFS(), 0755)
+		_ = os.MkdirAll(n.cfg.DataDirIPFS(), 0755)
 	}
-	//os.Setenv("IPFS_PATH", filepath.Join(n.cfg.Path, config.DataDirIPFS()))
 	cmd := exec.Command(n.name, "init", "--profile", "badgerds")
+	cmd.Env = n.cfg.IPFSEnv()
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("init:%w", err)
 	}
 	log.Infow("ipfs init", "tag", outputHead, "log", string(out))
 	cmd = exec.Command(n.name, "config", "Swarm.EnableAutoNATService", "--bool", "true")
+	cmd.Env = n.cfg.IPFSEnv()
 	out, err = cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("config(nat):%w", err)
 	}
 	log.Infow("ipfs init config set", "tag", outputHead, "log", string(out))
 	cmd = exec.Command(n.name, "config", "Swarm.EnableRelayHop", "--bool", "true")
+	cmd.Env = n.cfg.IPFSEnv()
 	out, err = cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("config(relay):%w", err)
@@ -86,10 +97,12 @@ func (n *nodeServerIPFS) Init() error {
 
 // NewNodeServerIPFS ...
 func NewNodeServerIPFS(cfg *config.Config) NodeServer {
-	return newNodeServerIPFS(cfg)
+	return newNodeServerIPFS(cfg, nil)
 }
 
-func newNodeServerIPFS(cfg *config.Config) *nodeServerIPFS {
+// newNodeServerIPFS returns the server of the node, its client writes through
+// the shared contracts, they are loaded on the first call of Node when nil
+func newNodeServerIPFS(cfg *config.Config, shared *nodeShared) *nodeServerIPFS {
 	path := filepath.Join(cfg.Path, "bin", binName(cfg.IPFS.Name))
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	return &nodeServerIPFS{
@@ -97,5 +110,6 @@ func newNodeServerIPFS(cfg *config.Config) *nodeServerIPFS {
 		cancel: cancelFunc,
 		cfg:    cfg,
 		name:   path,
+		shared: shared,
 	}
 }
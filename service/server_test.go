@@ -8,14 +8,26 @@ import (
 	"github.com/gorilla/rpc/v2/json2"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"testing"
 )
 
 func init() {
 	zap.InitZapFileSugar()
 }
+
+// liveEnv returns the value of an environment variable the test needs, tests
+// that run against a real work path or remote node are skipped without it.
+func liveEnv(t *testing.T, key string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		t.Skipf("%s is not set", key)
+	}
+	return v
+}
+
 func TestNodeServerETH(t *testing.T) {
-	config.WorkDir = "D:\\workspace\\pvt"
+	config.WorkDir = liveEnv(t, "ACCIPFS_WORKDIR")
 	err := config.SaveConfig(config.Default())
 	if err != nil {
 		t.Error(err)
@@ -60,7 +72,7 @@ func TestNodeServerETH(t *testing.T) {
 }
 
 func TestNewServer(t *testing.T) {
-	config.WorkDir = "d:\\workspace\\pvt"
+	config.WorkDir = liveEnv(t, "ACCIPFS_WORKDIR")
 	config.Initialize()
 	//cfg := config.Global()
 	//acc, e := account.NewAccount(&cfg)
@@ -76,9 +88,9 @@ func TestNewServer(t *testing.T) {
 	//	t.Fatal(e)
 	//}
 	//go server.Start()
-	url := "http://47.101.169.94:14009/rpc"
+	url := liveEnv(t, "ACCIPFS_REMOTE_RPC")
 
-	m1, e := json2.EncodeClientRequest("Accelerate.Ping", &Empty{})
+	m1, e := json2.EncodeClientRequest("Accelerate.Ping", &core.Empty{})
 	if e != nil {
 		return
 	}
@@ -91,7 +103,7 @@ func TestNewServer(t *testing.T) {
 		return
 	}
 	t.Log(string(readAll))
-	message, err := json2.EncodeClientRequest("Accelerate.ID", &Empty{})
+	message, err := json2.EncodeClientRequest("Accelerate.ID", &core.Empty{})
 	if err != nil {
 		t.Fatal(err)
 	}
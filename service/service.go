@@ -1,9 +1,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
-	"github.com/glvd/accipfs/aws"
 	"github.com/glvd/accipfs/config"
+	"github.com/glvd/accipfs/dns"
 	"github.com/gocacher/cacher"
 	"github.com/goextension/log"
 	"github.com/robfig/cron/v3"
@@ -26,65 +27,54 @@ type Service struct {
 	nodes map[string]bool
 }
 
-func syncDNS(cfg *config.Config, nodes map[string]bool) {
+// syncDNS publishes the nodes, served returns the bytes fetched from a node lately
+// and weighs the nodes by their capacity
+func syncDNS(cfg *config.Config, nodes map[string]bool, served func(id string) uint64) {
 	//defer fmt.Println("<更新网关数据完成...>")
-	var records []string
+	var records []dns.Node
 	// build serviceNode records
 	for node := range nodes {
 		if !strings.Contains(node, "enode") {
 			continue
 		}
-		// get ip address
-		uri := strings.Split(node, "@")[1]
-		ip := strings.Split(uri, ":")[0]
-		records = append(records, ip)
+		record, err := dns.NodeFromEnode(node)
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
 	}
 
 	if len(records) == 0 {
 		return
 	}
-	fmt.Println(outputHead, "<正在更新网关数据...>", records)
-
-	dnsService := aws.NewRoute53(cfg)
+	if served != nil {
+		dns.SetCapacity(records, func(id string) float64 {
+			return float64(served(id))
+		})
+	}
+	fmt.Println(outputHead, "<正在更新网关数据...>", len(records))
 
-	// get remote dns record
-	remoteIPs := make(map[string]bool)
-	remoteRecordSets, err := dnsService.GetRecordSets()
+	syncer, err := dns.NewSyncer(cfg)
 	if err != nil {
-		log.Infow("visit remote record failed", "tag", outputHead, "error", err.Error())
+		log.Infow("dns provider failed", "tag", outputHead, "error", err)
 		return
 	}
-	if len(remoteRecordSets) != 0 {
-		for _, recordSet := range remoteRecordSets {
-			remoteIPs[*recordSet.ResourceRecords[0].Value] = true
-		}
-	}
-	// add new record
-	ipAdd := DiffStrArray(records, remoteIPs)
-	setsAdd := dnsService.BuildMultiValueRecordSets(ipAdd)
-	log.Infow("resource adding", "tag", outputHead, "list", ipAdd, "count", len(setsAdd))
-	if len(setsAdd) > 0 {
-		res, err := dnsService.ChangeSets(setsAdd, "UPSERT")
+	if cfg.DNS.DryRun {
+		changes, err := syncer.Plan(context.Background(), records)
 		if err != nil {
-			log.Infow("add resource record fail", "tag", outputHead, "error", err)
-		} else {
-			log.Infow("add resource record success", "tag", outputHead, "error", "result", res.String())
+			log.Infow("plan resource record fail", "tag", outputHead, "error", err)
+			return
 		}
+		fmt.Println(outputHead, "<dry run>", syncer.Provider.Name())
+		fmt.Println(changes)
+		return
 	}
-
-	// delete record out of date
-	failedSets := dnsService.FilterFailedRecords(remoteRecordSets)
-	log.Infow("resource deleting", "tag", outputHead, "list", remoteRecordSets, "count", len(failedSets))
-	if len(failedSets) > 0 {
-		res, err := dnsService.ChangeSets(failedSets, "DELETE")
-		if err != nil {
-			log.Infow("delete resource record fail", "tag", outputHead, "error", err)
-		} else {
-			log.Infow("delete resource record success", "tag", outputHead, "error", "result", res.String())
-		}
+	changes, err := syncer.Sync(context.Background(), records)
+	if err != nil {
+		log.Infow("sync resource record fail", "tag", outputHead, "provider", syncer.Provider.Name(), "error", err)
+		return
 	}
-
-	return
+	log.Infow("sync resource record success", "tag", outputHead, "provider", syncer.Provider.Name(), "count", len(changes))
 }
 
 // DiffStrArray return the elements in `a` that aren't in `b`.
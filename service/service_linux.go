@@ -1,5 +1,13 @@
 package service
 
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals make the daemon read its config again
+var reloadSignals = []os.Signal{syscall.SIGHUP}
+
 func binName(name string) string {
 	return name
 }
@@ -1,5 +1,10 @@
 package service
 
+import "os"
+
+// reloadSignals make the daemon read its config again, windows has no SIGHUP
+var reloadSignals []os.Signal
+
 func binName(name string) string {
 	return name + ".exe"
 }
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+)
+
+func TestHarnessVersion(t *testing.T) {
+	h := newHarness(t, 2)
+	h.start()
+	defer h.stop()
+	node := h.nodes[0]
+
+	info, err := client.New(node.url()).Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != core.Version || info.ProtocolVersion != core.ProtocolVersion {
+		t.Errorf("version %+v", info)
+	}
+
+	// a node that requires a newer protocol is refused
+	newer := &core.NodeInfo{Name: "newer", Protocol: core.ProtocolVersion + 1, MinProtocol: core.ProtocolVersion + 1}
+	_, err = client.New(node.url()).Connected(context.Background(), newer)
+	var remote *general.RemoteError
+	if !errors.As(err, &remote) || remote.Code != int(errIncompatible) {
+		t.Errorf("incompatible node: %v", err)
+	}
+	_, err = client.New(node.url()).AddPeer(context.Background(), newer)
+	if !errors.As(err, &remote) || remote.Code != int(errIncompatible) {
+		t.Errorf("incompatible peer added: %v", err)
+	}
+	if node.acc.nodes.Check("newer") || node.acc.dummyNodes.Check("newer") {
+		t.Error("incompatible node was kept")
+	}
+
+	// legacy nodes are spoken to without the capabilities
+	legacy := &core.NodeInfo{Name: "legacy", Capabilities: []string{core.CapabilityBatch}}
+	if err := core.Compatible(legacy); err != nil {
+		t.Errorf("legacy node refused: %v", err)
+	}
+	if node.acc.uses(legacy, core.CapabilityBatch) {
+		t.Error("batch used with a legacy node")
+	}
+	peer := &core.NodeInfo{Name: "peer", Protocol: core.ProtocolVersion, Capabilities: []string{core.CapabilityBatch, core.CapabilityGRPC}}
+	if !node.acc.uses(peer, core.CapabilityBatch) {
+		t.Error("batch not used with a current node")
+	}
+	if node.acc.uses(peer, core.CapabilityGRPC) {
+		t.Error("grpc used without the local capability")
+	}
+}
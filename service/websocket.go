@@ -0,0 +1,239 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glvd/accipfs/core"
+	"github.com/goextension/log"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsMaxMessage = 64 << 10
+)
+
+// wsOrigin allows the clients that send no origin, the pages served by the node
+// itself and the origins of the config, other pages can not use the browser of
+// a visitor to read the events of the node
+func wsOrigin(origins func() []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		for _, allowed := range origins() {
+			if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// wsRequest is a json-rpc 2.0 request of a websocket client
+type wsRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type wsError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type wsResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *wsError        `json:"error,omitempty"`
+}
+
+// wsNotification is sent for every event of a subscription
+type wsNotification struct {
+	Version string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Subscription string             `json:"subscription"`
+		Result       *core.Notification `json:"result"`
+	} `json:"params"`
+}
+
+// wsConn is one websocket client and its subscriptions
+type wsConn struct {
+	n    *notifier
+	conn *websocket.Conn
+	out  chan interface{}
+	done chan struct{}
+	mut  sync.Mutex
+	subs map[string]uint64
+}
+
+// wsHandler serves the subscriptions of the notifier over websocket. A client
+// sends {"method":"subscribe","params":[topics...]} and gets the id of the
+// subscription, the events follow as "subscription" notifications until it
+// sends {"method":"unsubscribe","params":[id]} or disconnects.
+// Browsers are only let in from the origins wsOrigin allows.
+func wsHandler(n *notifier, origins func() []string) http.Handler {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     wsOrigin(origins),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Errorw("websocket upgrade", "tag", outputHead, "error", err)
+			return
+		}
+		c := &wsConn{
+			n:    n,
+			conn: conn,
+			out:  make(chan interface{}, notifyQueue),
+			done: make(chan struct{}),
+			subs: make(map[string]uint64),
+		}
+		go c.writeLoop()
+		c.readLoop()
+	})
+}
+
+func (c *wsConn) readLoop() {
+	defer func() {
+		close(c.done)
+		c.mut.Lock()
+		for _, id := range c.subs {
+			c.n.Unsubscribe(id)
+		}
+		c.mut.Unlock()
+		c.conn.Close()
+	}()
+	c.conn.SetReadLimit(wsMaxMessage)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		var req wsRequest
+		if err := c.conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Debugw("websocket read", "tag", outputHead, "error", err)
+			}
+			return
+		}
+		resp := c.handle(&req)
+		select {
+		case c.out <- resp:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *wsConn) handle(req *wsRequest) *wsResponse {
+	resp := &wsResponse{Version: "2.0", ID: req.ID}
+	var params []string
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &wsError{Code: -32602, Message: err.Error()}
+			return resp
+		}
+	}
+	switch req.Method {
+	case "subscribe":
+		if len(params) == 0 {
+			params = core.Topics
+		}
+		for _, topic := range params {
+			if !validTopic(topic) {
+				resp.Error = &wsError{Code: -32602, Message: "unknown topic " + topic}
+				return resp
+			}
+		}
+		resp.Result = c.subscribe(params)
+	case "unsubscribe":
+		if len(params) != 1 {
+			resp.Error = &wsError{Code: -32602, Message: "unsubscribe needs the subscription id"}
+			return resp
+		}
+		resp.Result = c.unsubscribe(params[0])
+	default:
+		resp.Error = &wsError{Code: -32601, Message: "method not found: " + req.Method}
+	}
+	return resp
+}
+
+func validTopic(topic string) bool {
+	for _, t := range core.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *wsConn) subscribe(topics []string) string {
+	id, ch := c.n.Subscribe(topics...)
+	key := strconv.FormatUint(id, 10)
+	c.mut.Lock()
+	c.subs[key] = id
+	c.mut.Unlock()
+	go func() {
+		for msg := range ch {
+			note := &wsNotification{Version: "2.0", Method: "subscription"}
+			note.Params.Subscription = key
+			note.Params.Result = msg
+			select {
+			case c.out <- note:
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return key
+}
+
+func (c *wsConn) unsubscribe(key string) bool {
+	c.mut.Lock()
+	id, b := c.subs[key]
+	delete(c.subs, key)
+	c.mut.Unlock()
+	return b && c.n.Unsubscribe(id)
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.conn.Close()
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glvd/accipfs/client"
+	"github.com/glvd/accipfs/core"
+	"github.com/glvd/accipfs/general"
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketSubscribe(t *testing.T) {
+	n := newNotifier()
+	route := http.NewServeMux()
+	route.Handle("/ws", wsHandler(n, func() []string { return nil }))
+	srv := httptest.NewServer(route)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := client.New(srv.URL + "/rpc")
+	if _, err := c.Subscribe(ctx, "no.such.topic"); !errors.As(err, new(*general.RemoteError)) {
+		t.Fatalf("unknown topic: %v", err)
+	}
+	sub, err := c.Subscribe(ctx, core.TopicPeerJoined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Publish(core.TopicSyncDone, &core.SyncDone{Peers: 1})
+	n.Publish(core.TopicPeerJoined, &core.PeerEvent{Node: &core.NodeInfo{Name: "node1"}})
+	select {
+	case msg := <-sub.C:
+		var e core.PeerEvent
+		if err := msg.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Topic != core.TopicPeerJoined || e.Node.Name != "node1" {
+			t.Errorf("got %s %+v", msg.Topic, e.Node)
+		}
+	case <-ctx.Done():
+		t.Fatal("no notification")
+	}
+
+	sub.Close()
+	for range sub.C {
+	}
+	if sub.Err() != nil {
+		t.Errorf("closed subscription: %v", sub.Err())
+	}
+	// the server drops the subscriptions of the connection
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n.mut.RLock()
+		left := len(n.subs)
+		n.mut.RUnlock()
+		if left == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d subscriptions left", left)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	srv := httptest.NewServer(wsHandler(newNotifier(), func() []string {
+		return []string{"https://viewer.example.com/"}
+	}))
+	defer srv.Close()
+	for origin, allowed := range map[string]bool{
+		"":                                true,
+		srv.URL:                           true,
+		"https://viewer.example.com":      true,
+		"https://viewer.example.com:8443": false,
+		"https://evil.example.com":        false,
+	} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+		if err == nil {
+			conn.Close()
+		}
+		if (err == nil) != allowed {
+			t.Errorf("origin %q: allowed %v, want %v", origin, err == nil, allowed)
+		}
+		if !allowed && resp != nil && resp.StatusCode != http.StatusForbidden {
+			t.Errorf("origin %q: status %d", origin, resp.StatusCode)
+		}
+	}
+}
+
+func TestPeerEventsOnce(t *testing.T) {
+	a := &Accelerate{nodes: core.NewNodeStore(), dummyNodes: core.NewNodeStore(), notifier: newNotifier()}
+	_, ch := a.notifier.Subscribe(core.TopicPeerJoined, core.TopicPeerLeft)
+	info := &core.NodeInfo{Name: "node1"}
+	for _, fn := range []func(info *core.NodeInfo){a.joinPeer, a.dropPeer} {
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(fn func(info *core.NodeInfo)) {
+				defer wg.Done()
+				fn(info)
+			}(fn)
+		}
+		wg.Wait()
+	}
+	var topics []string
+	for len(ch) > 0 {
+		topics = append(topics, (<-ch).Topic)
+	}
+	if len(topics) != 2 || topics[0] != core.TopicPeerJoined || topics[1] != core.TopicPeerLeft {
+		t.Fatalf("published %v", topics)
+	}
+	if a.nodes.Length() != 0 || a.dummyNodes.Length() != 1 {
+		t.Fatalf("%d nodes, %d dummy nodes", a.nodes.Length(), a.dummyNodes.Length())
+	}
+}